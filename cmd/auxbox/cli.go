@@ -5,12 +5,16 @@ import (
 	"log"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/cerberussg/auxbox/internal/audio"
 	"github.com/cerberussg/auxbox/internal/server"
+	"github.com/cerberussg/auxbox/internal/server/commands"
 	"github.com/cerberussg/auxbox/internal/shared"
 )
 
@@ -27,15 +31,31 @@ Usage:
   auxbox play -p <path>            Load playlist and play instantly
   auxbox play --playlist <path>    Load playlist and play instantly
   auxbox play                      Resume playback (if paused)
+  auxbox resume                    Restart the daemon and restore the last session
   auxbox pause                     Pause playback
   auxbox stop                      Stop playback (reset to beginning)
   auxbox skip [n]                  Skip forward n tracks (default: 1)
   auxbox back [n]                  Skip backward n tracks (default: 1)
+  auxbox jump <n|name>             Jump to track n of the list, or by name
+  auxbox seek <position>           Seek to 1:30, +15s, -10s or 50%
   auxbox shuffle                   Toggle shuffle on/off
   auxbox repeat                    Cycle repeat modes (off → all → one → off)
+  auxbox gapless                   Toggle gapless playback on/off
+  auxbox crossfade [s] [--skips]   Show or set crossfade seconds (0 = off)
   auxbox volume [0-100]            Show or set volume percentage
+  auxbox resample [1-16]           Show or set resampling quality (default: 4)
   auxbox status                    Show current track info
   auxbox list                      List tracks in current queue
+  auxbox watch [--json]            Print playback events as they happen
+  auxbox add <path>                Add a folder, playlist or file to the end of the queue
+  auxbox playnext <path>           Insert a folder, playlist or file after the current track
+  auxbox remove <n>[-<m>]          Remove track n, or tracks n to m, from the queue
+  auxbox move <from> <to>          Move a track to another queue position
+  auxbox clear                     Remove every track after the current one
+  auxbox save <path> [--relative]  Save queue as .m3u8, .pls or .xspf playlist
+  auxbox stars <0-5>               Rate the current track (0 clears the rating)
+  auxbox genre [a, b] [--clear]    Show or set the current track's genres
+  auxbox label [name] [--clear]    Show or set the current track's label
   auxbox exit                      Exit daemon (stop everything)
   auxbox --help, -h                Show this help
   auxbox --version, -v             Show version
@@ -48,9 +68,29 @@ Examples:
   auxbox shuffle                           # Toggle shuffle on current playlist
   auxbox repeat                            # Cycle repeat modes
   auxbox skip 3
+  auxbox jump 57                           # Straight to track 57
+  auxbox jump one more time                # First track whose name matches
+  auxbox seek +30s                         # Jump 30 seconds ahead
+  auxbox playnext ~/promos/new-edit.flac   # Play this one next
+  auxbox move 12 3                         # Bring track 12 up to position 3
+  auxbox save ~/playlists/friday.m3u8      # Save current queue order
+  auxbox save /media/usb/set.m3u8 --relative  # Paths relative to the playlist
+  auxbox stars 5                           # Rate the current track 5 stars
+  auxbox genre Deep House, Minimal         # Two genres for the current track
+  auxbox label Drumcode                    # Add --new if it is flagged as a typo
+  auxbox genre --forget Tech Hosue         # Stop offering a genre added by mistake
+  auxbox crossfade 6 --skips               # 6s crossfade, also on skip/back
+  auxbox watch --json                      # Event stream for status bars
   auxbox volume 75
   auxbox pause
-  auxbox exit`
+  auxbox exit
+
+Environment (read when the daemon starts):
+  AUXBOX_HTTP_TOKEN                Enable the HTTP API, requiring this bearer token
+  AUXBOX_HTTP_ADDR                 HTTP API address (default: 127.0.0.1:7733)
+  AUXBOX_MPD_ADDR                  Enable the MPD protocol server on this address (e.g. 127.0.0.1:6600)
+  AUXBOX_MPD_PASSWORD              Password MPD clients must send first
+  AUXBOX_MPRIS=0                   Don't register with desktop media controls (MPRIS)`
 )
 
 type CLI struct {
@@ -88,24 +128,56 @@ func (c *CLI) Run(args []string) {
 		c.runDaemonProcess(sourceType, sourcePath)
 	case "play":
 		c.handlePlayCommand(args)
+	case "resume":
+		c.handleResumeCommand()
 	case "pause":
 		c.sendCommand(shared.NewPauseCommand())
 	case "skip":
 		c.handleSkipCommand(args)
 	case "back":
 		c.handleBackCommand(args)
+	case "jump":
+		c.handleJumpCommand(args)
+	case "seek":
+		c.handleSeekCommand(args)
 	case "shuffle":
 		c.sendCommand(shared.Command{Type: shared.CmdShuffle})
 	case "repeat":
 		c.sendCommand(shared.Command{Type: shared.CmdRepeat})
+	case "gapless":
+		c.sendCommand(shared.NewGaplessCommand())
 	case "status":
 		c.sendCommand(shared.NewStatusCommand())
 	case "list":
 		c.sendCommand(shared.NewListCommand())
+	case "watch":
+		c.handleWatchCommand(args)
 	case "stop":
 		c.sendCommand(shared.NewStopCommand())
 	case "volume":
 		c.handleVolumeCommand(args)
+	case "resample":
+		c.handleResampleCommand(args)
+	case "crossfade":
+		c.handleCrossfadeCommand(args)
+	case "save":
+		c.handleSaveCommand(args)
+	case "add":
+		c.handleEnqueueCommand(args, false)
+	case "playnext":
+		c.handleEnqueueCommand(args, true)
+	case "remove":
+		c.handleRemoveCommand(args)
+	case "move":
+		c.handleMoveCommand(args)
+	case "clear":
+		c.sendCommand(shared.NewClearCommand())
+	case "stars":
+		c.handleStarsCommand(args)
+	case "genre":
+		c.handleTagCommand(args, shared.CmdGenre)
+	case "label":
+		c.handleTagCommand(args, shared.CmdLabel)
 	case "exit":
 		c.sendCommand(shared.NewExitCommand())
 	default:
@@ -142,6 +214,79 @@ func (c *CLI) handleBackCommand(args []string) {
 	c.sendCommand(shared.NewBackCommand(count))
 }
 
+func (c *CLI) handleJumpCommand(args []string) {
+	if len(args) <= 2 {
+		fmt.Println("Usage: auxbox jump <track number | part of a filename>")
+		os.Exit(1)
+	}
+
+	// Let names with spaces be given without quotes
+	c.sendCommand(shared.NewJumpCommand(strings.Join(args[2:], " ")))
+}
+
+func (c *CLI) handleWatchCommand(args []string) {
+	asJSON := false
+	for _, arg := range args[2:] {
+		switch arg {
+		case "--json":
+			asJSON = true
+		default:
+			fmt.Printf("Unexpected argument: %s\n", arg)
+			fmt.Println("Usage: auxbox watch [--json]")
+			os.Exit(1)
+		}
+	}
+
+	transport := shared.NewUnixSocketTransport()
+	if !transport.IsRunning() {
+		fmt.Printf("auxbox daemon is not running.\n")
+		fmt.Printf("Start it with: auxbox play -f <path>\n")
+		os.Exit(1)
+	}
+
+	err := transport.Subscribe(func(event shared.Event) {
+		if asJSON {
+			// One object per line, as sent by the daemon
+			data, _ := event.ToJSON()
+			fmt.Printf("%s\n", data)
+			return
+		}
+		fmt.Println(formatEvent(event))
+	})
+	if err != nil {
+		fmt.Printf("Event stream failed: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !asJSON {
+		fmt.Println("auxbox daemon exited.")
+	}
+}
+
+// formatEvent renders an event as a single line for `auxbox watch`
+func formatEvent(event shared.Event) string {
+	line := event.Time.Local().Format("15:04:05") + " " + event.Message
+	if event.Type == shared.EventTrack && event.Track != nil && event.Track.TotalTracks > 0 {
+		line += fmt.Sprintf(" [%d/%d]", event.Track.TrackNumber, event.Track.TotalTracks)
+	}
+	return line
+}
+
+func (c *CLI) handleSeekCommand(args []string) {
+	if len(args) <= 2 {
+		fmt.Println("Usage: auxbox seek <position>  (e.g. 1:30, +15s, -10s, 50%)")
+		os.Exit(1)
+	}
+
+	// Catch typos before they reach the daemon
+	if _, err := shared.ParseSeekTarget(args[2]); err != nil {
+		fmt.Printf("Invalid seek position: %v\n", err)
+		os.Exit(1)
+	}
+
+	c.sendCommand(shared.NewSeekCommand(args[2]))
+}
+
 func (c *CLI) handlePlayCommand(args []string) {
 	// If no additional args, just play/resume current playlist
 	if len(args) <= 2 {
@@ -239,6 +384,11 @@ func (c *CLI) sendCommand(cmd shared.Command) {
 		c.printListResponse(resp)
 	case shared.CmdVolume:
 		c.printVolumeResponse(resp)
+	case shared.CmdPlay, shared.CmdEnqueue, shared.CmdPlayNext:
+		fmt.Println(resp.Message)
+		c.printSkippedEntries(resp)
+	case shared.CmdGenre, shared.CmdLabel:
+		c.printTagResponse(resp)
 	case shared.CmdStop:
 		fmt.Println("Playback stopped.")
 	case shared.CmdExit:
@@ -261,11 +411,19 @@ func (c *CLI) printStatusResponse(resp *shared.Response) {
 	// Data comes back as map[string]interface{} after JSON round-trip
 	if dataMap, ok := resp.Data.(map[string]interface{}); ok {
 		filename := c.getStringFromMap(dataMap, "filename", "Unknown")
+		if title := c.getStringFromMap(dataMap, "title", ""); title != "" {
+			filename = title
+			if artist := c.getStringFromMap(dataMap, "artist", ""); artist != "" {
+				filename = artist + " - " + title
+			}
+		}
 		duration := c.getStringFromMap(dataMap, "duration", "")
 		position := c.getStringFromMap(dataMap, "position", "")
 		trackNum := c.getIntFromMap(dataMap, "track_number", 0)
 		totalTracks := c.getIntFromMap(dataMap, "total_tracks", 0)
 		source := c.getStringFromMap(dataMap, "source", "")
+		sampleRate := c.getIntFromMap(dataMap, "sample_rate", 0)
+		outputRate := c.getIntFromMap(dataMap, "output_rate", 0)
 
 		// Build status line: "▶ filename | position/duration | Track N/total | Source: path"
 		status := fmt.Sprintf("▶ %s", filename)
@@ -280,11 +438,41 @@ func (c *CLI) printStatusResponse(resp *shared.Response) {
 			status += fmt.Sprintf(" | Track %d/%d", trackNum, totalTracks)
 		}
 
+		if sampleRate > 0 && outputRate > 0 && sampleRate != outputRate {
+			status += fmt.Sprintf(" | %d Hz → %d Hz", sampleRate, outputRate)
+		} else if sampleRate > 0 {
+			status += fmt.Sprintf(" | %d Hz", sampleRate)
+		}
+
 		if source != "" {
 			status += fmt.Sprintf(" | Source: %s", source)
 		}
 
 		fmt.Println(status)
+
+		// Second line with the rating and whatever tags the track has:
+		// "  ★★★★☆ | Album | Genre | 124 BPM | Key Am | Label"
+		var tags []string
+		if rating := c.getIntFromMap(dataMap, "rating", 0); rating > 0 {
+			tags = append(tags, shared.FormatStars(rating))
+		}
+		for _, tag := range []struct{ key, format string }{
+			{"album", "%s"},
+			{"genre", "%s"},
+			{"bpm", "%s BPM"},
+			{"key", "Key %s"},
+			{"label", "%s"},
+		} {
+			if value := c.getStringFromMap(dataMap, tag.key, ""); value != "" {
+				tags = append(tags, fmt.Sprintf(tag.format, value))
+			}
+		}
+		if len(tags) > 0 {
+			fmt.Printf("  %s\n", strings.Join(tags, " | "))
+		}
+		if comment := c.getStringFromMap(dataMap, "comment", ""); comment != "" {
+			fmt.Printf("  %q\n", comment)
+		}
 	} else {
 		fmt.Printf("Status: %s\n", resp.Message)
 	}
@@ -331,6 +519,27 @@ func (c *CLI) printListResponse(resp *shared.Response) {
 	}
 }
 
+func (c *CLI) printSkippedEntries(resp *shared.Response) {
+	dataMap, ok := resp.Data.(map[string]interface{})
+	if !ok {
+		return
+	}
+
+	skipped, ok := dataMap["skipped"].([]interface{})
+	if !ok || len(skipped) == 0 {
+		return
+	}
+
+	fmt.Printf("Skipped %d playlist entries:\n", len(skipped))
+	for _, entryInterface := range skipped {
+		if entry, ok := entryInterface.(map[string]interface{}); ok {
+			location := c.getStringFromMap(entry, "location", "")
+			reason := c.getStringFromMap(entry, "reason", "")
+			fmt.Printf("  %s (%s)\n", location, reason)
+		}
+	}
+}
+
 func (c *CLI) printVolumeResponse(resp *shared.Response) {
 	if resp.Data == nil {
 		// Just print the message (e.g., "Volume set to 75%")
@@ -401,6 +610,294 @@ func (c *CLI) handleVolumeCommand(args []string) {
 	c.sendCommand(shared.NewVolumeCommand(volume))
 }
 
+func (c *CLI) handleResampleCommand(args []string) {
+	// If no quality specified, get current quality
+	if len(args) <= 2 {
+		c.sendCommand(shared.NewResampleCommand(-1)) // -1 means "get current quality"
+		return
+	}
+
+	qualityStr := args[2]
+	quality, err := strconv.Atoi(qualityStr)
+	if err != nil {
+		fmt.Printf("Invalid resample quality: %s. Use a number from 1-16.\n", qualityStr)
+		return
+	}
+
+	if quality < 1 || quality > 16 {
+		fmt.Printf("Resample quality must be between 1-16, got %d.\n", quality)
+		return
+	}
+
+	c.sendCommand(shared.NewResampleCommand(quality))
+}
+
+func (c *CLI) handleCrossfadeCommand(args []string) {
+	// If no duration specified, get current setting
+	if len(args) <= 2 {
+		c.sendCommand(shared.NewCrossfadeCommand(-1, false)) // -1 means "get current crossfade"
+		return
+	}
+
+	var secondsStr string
+	fadeSkips := false
+	for _, arg := range args[2:] {
+		switch arg {
+		case "--skips":
+			fadeSkips = true
+		default:
+			if secondsStr != "" {
+				fmt.Printf("Unexpected argument: %s\n", arg)
+				fmt.Println("Usage: auxbox crossfade [seconds] [--skips]")
+				return
+			}
+			secondsStr = arg
+		}
+	}
+
+	maxSeconds := audio.MaxCrossfade.Seconds()
+	seconds, err := strconv.ParseFloat(secondsStr, 64)
+	if err != nil {
+		fmt.Printf("Invalid crossfade: %s. Use a number of seconds from 0-%g.\n", secondsStr, maxSeconds)
+		return
+	}
+
+	if seconds < 0 || seconds > maxSeconds {
+		fmt.Printf("Crossfade must be between 0-%g seconds, got %g.\n", maxSeconds, seconds)
+		return
+	}
+
+	c.sendCommand(shared.NewCrossfadeCommand(seconds, fadeSkips))
+}
+
+func (c *CLI) handleSaveCommand(args []string) {
+	var path string
+	relative := false
+
+	for _, arg := range args[2:] {
+		switch arg {
+		case "--relative":
+			relative = true
+		default:
+			if path != "" {
+				fmt.Printf("Unexpected argument: %s\n", arg)
+				fmt.Println("Usage: auxbox save <path> [--relative]")
+				os.Exit(1)
+			}
+			path = arg
+		}
+	}
+
+	if path == "" {
+		fmt.Println("Usage: auxbox save <path> [--relative]")
+		os.Exit(1)
+	}
+
+	// The daemon has its own working directory, so send an absolute path
+	if !strings.HasPrefix(path, "~/") {
+		if absPath, err := filepath.Abs(path); err == nil {
+			path = absPath
+		}
+	}
+
+	c.sendCommand(shared.NewSaveCommand(path, relative))
+}
+
+func (c *CLI) handleEnqueueCommand(args []string, playNext bool) {
+	if len(args) <= 2 {
+		fmt.Printf("Usage: auxbox %s <folder | playlist | file>\n", args[1])
+		os.Exit(1)
+	}
+
+	path := args[2]
+	if !c.pathExists(path) {
+		fmt.Printf("Path does not exist: %s\n", path)
+		os.Exit(1)
+	}
+
+	// The daemon has its own working directory, so send an absolute path
+	if absPath, err := filepath.Abs(path); err == nil {
+		path = absPath
+	}
+
+	if playNext {
+		c.sendCommand(shared.NewPlayNextCommand(path))
+	} else {
+		c.sendCommand(shared.NewEnqueueCommand(path))
+	}
+}
+
+func (c *CLI) handleRemoveCommand(args []string) {
+	if len(args) <= 2 {
+		fmt.Println("Usage: auxbox remove <n>[-<m>]")
+		os.Exit(1)
+	}
+
+	from, to, err := parseQueueRange(args[2])
+	if err != nil {
+		fmt.Printf("Invalid queue position: %s. Use a track number from 'auxbox list', or a range like 3-7.\n", args[2])
+		os.Exit(1)
+	}
+
+	c.sendCommand(shared.NewRemoveCommand(from, to))
+}
+
+func (c *CLI) handleMoveCommand(args []string) {
+	if len(args) <= 3 {
+		fmt.Println("Usage: auxbox move <from> <to>")
+		os.Exit(1)
+	}
+
+	from, errFrom := strconv.Atoi(args[2])
+	to, errTo := strconv.Atoi(args[3])
+	if errFrom != nil || errTo != nil || from < 1 || to < 1 {
+		fmt.Println("Queue positions must be track numbers from 'auxbox list'.")
+		os.Exit(1)
+	}
+
+	c.sendCommand(shared.NewMoveCommand(from, to))
+}
+
+func (c *CLI) handleStarsCommand(args []string) {
+	if len(args) <= 2 {
+		fmt.Println("Usage: auxbox stars <0-5>")
+		os.Exit(1)
+	}
+
+	stars, err := strconv.Atoi(args[2])
+	if err != nil || stars < 0 || stars > 5 {
+		fmt.Printf("Invalid rating: %s. Use 1-5 stars, or 0 to clear the rating.\n", args[2])
+		os.Exit(1)
+	}
+
+	c.sendCommand(shared.NewStarsCommand(stars))
+}
+
+// handleTagCommand handles `auxbox genre` and `auxbox label`. Genres are
+// separated by commas; --forget drops known values instead of setting them,
+// and --complete lists the known values starting with a prefix, for shell
+// completion, without needing the daemon.
+func (c *CLI) handleTagCommand(args []string, cmdType shared.CommandType) {
+	cmd := shared.Command{Type: cmdType}
+	complete := false
+	var words []string
+	for _, arg := range args[2:] {
+		switch arg {
+		case "--clear":
+			cmd.Clear = true
+		case "--new":
+			cmd.AllowNew = true
+		case "--forget":
+			cmd.Forget = true
+		case "--complete":
+			complete = true
+		default:
+			words = append(words, arg)
+		}
+	}
+	value := strings.Join(words, " ")
+
+	if complete {
+		vocabulary, err := commands.LoadVocabulary(server.DefaultVocabularyPath())
+		if err != nil {
+			fmt.Printf("Cannot read known values: %v\n", err)
+			os.Exit(1)
+		}
+		field := "GENRE"
+		if cmdType == shared.CmdLabel {
+			field = "LABEL"
+		}
+		for _, known := range vocabulary.Complete(field, strings.TrimSpace(value)) {
+			fmt.Println(known)
+		}
+		return
+	}
+
+	if cmd.Clear && value != "" {
+		fmt.Printf("Usage: auxbox %s --clear\n", cmdType)
+		os.Exit(1)
+	}
+	if cmd.Forget && value == "" {
+		fmt.Printf("Usage: auxbox %s --forget <name>\n", cmdType)
+		os.Exit(1)
+	}
+	if value != "" && cmdType == shared.CmdGenre {
+		cmd.Values = strings.Split(value, ",")
+	} else if value != "" {
+		cmd.Values = []string{value}
+	}
+	c.sendCommand(cmd)
+}
+
+func (c *CLI) printTagResponse(resp *shared.Response) {
+	dataMap, ok := resp.Data.(map[string]interface{})
+	if !ok {
+		fmt.Println(resp.Message)
+		return
+	}
+
+	fmt.Printf("Current: %s\n", c.getStringFromMap(dataMap, "current", "(none)"))
+	if known, ok := dataMap["known"].([]interface{}); ok {
+		values := make([]string, 0, len(known))
+		for _, value := range known {
+			if str, ok := value.(string); ok {
+				values = append(values, str)
+			}
+		}
+		fmt.Printf("Known:   %s\n", strings.Join(values, ", "))
+	}
+}
+
+// parseQueueRange parses "n" or "n-m" into 1-based positions
+func parseQueueRange(s string) (from, to int, err error) {
+	first, last, isRange := strings.Cut(s, "-")
+	if from, err = strconv.Atoi(first); err != nil || from < 1 {
+		return 0, 0, fmt.Errorf("invalid position %q", first)
+	}
+	if !isRange {
+		return from, from, nil
+	}
+	if to, err = strconv.Atoi(last); err != nil || to < from {
+		return 0, 0, fmt.Errorf("invalid range %q", s)
+	}
+	return from, to, nil
+}
+
+// handleResumeCommand starts the daemon and restores the session it saved
+// when it last exited
+func (c *CLI) handleResumeCommand() {
+	transport := shared.NewUnixSocketTransport()
+	if transport.IsRunning() {
+		fmt.Println("auxbox daemon is already running. Use 'auxbox play' to resume playback.")
+		os.Exit(1)
+	}
+
+	state, err := server.LoadState(server.DefaultStatePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			fmt.Println("No saved session to resume. Start one with: auxbox play -f <path>")
+		} else {
+			fmt.Printf("Cannot resume: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
+	fmt.Printf("Starting auxbox daemon to resume %s\n", state.Queue.Source)
+	transport = c.spawnDaemon(state.Queue.SourceType, state.Queue.Source)
+
+	resp, err := transport.Send(shared.NewResumeCommand())
+	if err != nil {
+		fmt.Printf("Failed to resume session: %v\n", err)
+		os.Exit(1)
+	}
+	if !resp.Success {
+		fmt.Printf("Failed to resume session: %s\n", resp.Message)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ %s\n", resp.Message)
+}
+
 // startDaemonAndPlay starts the daemon and immediately begins playback
 func (c *CLI) startDaemonAndPlay(sourceType shared.SourceType, sourcePath string, shuffle bool, repeat bool) {
 	// Check if we're being called as the daemon itself
@@ -409,6 +906,31 @@ func (c *CLI) startDaemonAndPlay(sourceType shared.SourceType, sourcePath string
 		return
 	}
 
+	transport := c.spawnDaemon(sourceType, sourcePath)
+
+	// Send play command with source info to load and play immediately
+	playCmd := shared.NewPlayCommand()
+	playCmd.Source = sourceType
+	playCmd.Path = sourcePath
+	playCmd.Shuffle = shuffle
+	playCmd.Repeat = repeat
+	resp, err := transport.Send(playCmd)
+	if err != nil {
+		fmt.Printf("Failed to initialize daemon: %v\n", err)
+		os.Exit(1)
+	}
+
+	if !resp.Success {
+		fmt.Printf("Failed to load source and start playback: %s\n", resp.Message)
+		os.Exit(1)
+	}
+
+	fmt.Printf("✓ %s\n", resp.Message)
+	c.printSkippedEntries(resp)
+}
+
+// spawnDaemon starts the daemon in the background and waits for it to listen
+func (c *CLI) spawnDaemon(sourceType shared.SourceType, sourcePath string) *shared.UnixSocketTransport {
 	// Spawn daemon as background process
 	executable, err := os.Executable()
 	if err != nil {
@@ -439,29 +961,48 @@ func (c *CLI) startDaemonAndPlay(sourceType shared.SourceType, sourcePath string
 		os.Exit(1)
 	}
 
-	// Send play command with source info to load and play immediately
-	playCmd := shared.NewPlayCommand()
-	playCmd.Source = sourceType
-	playCmd.Path = sourcePath
-	playCmd.Shuffle = shuffle
-	playCmd.Repeat = repeat
-	resp, err := transport.Send(playCmd)
-	if err != nil {
-		fmt.Printf("Failed to initialize daemon: %v\n", err)
-		os.Exit(1)
-	}
-
-	if !resp.Success {
-		fmt.Printf("Failed to load source and start playback: %s\n", resp.Message)
-		os.Exit(1)
-	}
-
-	fmt.Printf("✓ %s\n", resp.Message)
+	return transport
 }
 
 // runDaemonProcess runs the actual daemon server (called by background process)
 func (c *CLI) runDaemonProcess(sourceType shared.SourceType, sourcePath string) {
+	statePath := server.DefaultStatePath()
+	vocabularyPath := server.DefaultVocabularyPath()
 	server := server.NewServer()
+	server.SetStatePath(statePath)
+	if err := server.SetVocabularyPath(vocabularyPath); err != nil {
+		log.Printf("Warning: genres and labels used before are not available: %v", err)
+	}
+
+	// The HTTP API is off unless a token is set in the environment the
+	// daemon was started from
+	if token := os.Getenv("AUXBOX_HTTP_TOKEN"); token != "" {
+		if err := server.SetHTTP(os.Getenv("AUXBOX_HTTP_ADDR"), token); err != nil {
+			log.Printf("HTTP API disabled: %v", err)
+		}
+	}
+
+	// Likewise the MPD server, for MPD clients such as mpc and ncmpcpp
+	if addr := os.Getenv("AUXBOX_MPD_ADDR"); addr != "" {
+		if err := server.SetMPD(addr, os.Getenv("AUXBOX_MPD_PASSWORD")); err != nil {
+			log.Printf("MPD server disabled: %v", err)
+		}
+	}
+
+	// Media keys and desktop widgets find the daemon over MPRIS
+	server.SetMPRIS(os.Getenv("AUXBOX_MPRIS") != "0")
+
+	// Save the session when the system shuts the daemon down
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		if err := server.SaveState(); err != nil {
+			log.Printf("Failed to save session: %v", err)
+		}
+		server.Stop()
+		os.Exit(0)
+	}()
 
 	// Don't pre-load tracks - let the play command handle loading with proper flags
 	log.Printf("Starting daemon (will load %s: %s on first play command)", sourceType, sourcePath)
@@ -6,6 +6,7 @@ import (
 	"os"
 	"strconv"
 	"testing"
+	"time"
 
 	"github.com/cerberussg/auxbox/internal/shared"
 )
@@ -367,3 +368,50 @@ func TestCLI_CommandRecognition(t *testing.T) {
 		})
 	}
 }
+
+func TestCLI_ParseQueueRange(t *testing.T) {
+	tests := []struct {
+		input    string
+		from, to int
+		wantErr  bool
+	}{
+		{"5", 5, 5, false},
+		{"3-7", 3, 7, false},
+		{"4-4", 4, 4, false},
+		{"0", 0, 0, true},
+		{"7-3", 0, 0, true},
+		{"a-b", 0, 0, true},
+		{"-3", 0, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			from, to, err := parseQueueRange(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseQueueRange(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if from != tt.from || to != tt.to {
+				t.Errorf("parseQueueRange(%q) = %d, %d, want %d, %d", tt.input, from, to, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+func TestCLI_FormatEvent(t *testing.T) {
+	at := time.Date(2024, 5, 1, 21, 4, 5, 0, time.Local)
+
+	track := shared.Event{
+		Type:    shared.EventTrack,
+		Time:    at,
+		Message: "Now playing b.mp3",
+		Track:   &shared.TrackInfo{Filename: "b.mp3", TrackNumber: 2, TotalTracks: 9},
+	}
+	if got, want := formatEvent(track), "21:04:05 Now playing b.mp3 [2/9]"; got != want {
+		t.Errorf("formatEvent() = %q, want %q", got, want)
+	}
+
+	pause := shared.Event{Type: shared.EventPause, Time: at, Message: "Paused", Track: track.Track}
+	if got, want := formatEvent(pause), "21:04:05 Paused"; got != want {
+		t.Errorf("formatEvent() = %q, want %q", got, want)
+	}
+}
@@ -1,39 +1,46 @@
 package decoders
 
 import (
+	"encoding/binary"
 	"fmt"
 	"io"
+	"math"
 
-	"github.com/go-audio/aiff"
-	"github.com/go-audio/audio"
 	"github.com/gopxl/beep/v2"
 )
 
-const (
-	// Buffer size for streaming AIFF data (in samples per channel)
-	// This determines how much data we keep in memory at once
-	aiffBufferSize = 4096 // ~93ms at 44.1kHz, uses ~32KB for stereo float64
-)
+// aiffCompressionTypes maps the AIFF-C compression types we can decode to
+// their sample layout. Plain AIFF files are treated as "NONE".
+var aiffCompressionTypes = map[string]pcmEncoding{
+	"NONE": {},
+	"twos": {},
+	"sowt": {littleEndian: true},
+	"raw ": {bytesPerSample: 1, unsigned: true},
+	"in24": {bytesPerSample: 3},
+	"in32": {bytesPerSample: 4},
+	"42ni": {bytesPerSample: 3, littleEndian: true},
+	"23ni": {bytesPerSample: 4, littleEndian: true},
+	"fl32": {bytesPerSample: 4, float: true},
+	"FL32": {bytesPerSample: 4, float: true},
+	"fl64": {bytesPerSample: 8, float: true},
+	"FL64": {bytesPerSample: 8, float: true},
+}
 
-// aiffStreamer implements beep.StreamSeekCloser for AIFF files
-type aiffStreamer struct {
-	decoder       *aiff.Decoder
-	format        beep.Format
-	reader        io.ReadSeeker
-
-	// Streaming buffers
-	rawBuffer     *audio.IntBuffer // Raw PCM data buffer for chunks
-	sampleBuffer  [][2]float64     // Converted samples ready for playback
-	bufferPos     int              // Current position in sampleBuffer
-	totalSamples  int              // Total samples in the file
-	currentSample int              // Current sample position in file
-
-	// Format info for conversion
-	sourceBitDepth int
-	maxValue       float64
+// aiffInfo holds what the decoder needs from an AIFF file's chunks
+type aiffInfo struct {
+	numChannels int
+	numFrames   int
+	sampleSize  int // Bits per sample as declared in COMM
+	sampleRate  float64
+	compression string
+	dataStart   int64 // Offset of the first sample frame
+	metadata    Metadata
 }
 
-// DecodeAIFF creates a beep-compatible streamer from an AIFF file
+// DecodeAIFF creates a beep-compatible streamer from an AIFF or AIFF-C file.
+// Integer PCM in either byte order and 32/64-bit float samples are supported;
+// compressed codecs such as µ-law or IMA ADPCM are rejected. ID3 tags are
+// available through the streamer's Metadata method.
 func DecodeAIFF(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
 	// AIFF decoder needs ReadSeeker, so we need to convert
 	readSeeker, ok := r.(io.ReadSeeker)
@@ -41,202 +48,155 @@ func DecodeAIFF(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
 		return nil, beep.Format{}, fmt.Errorf("AIFF decoder requires ReadSeeker interface")
 	}
 
-	decoder := aiff.NewDecoder(readSeeker)
-
-	if !decoder.IsValidFile() {
-		return nil, beep.Format{}, fmt.Errorf("invalid AIFF file")
+	info, err := parseAIFF(readSeeker)
+	if err != nil {
+		return nil, beep.Format{}, err
 	}
 
-	decoder.ReadInfo() // ReadInfo doesn't return an error
-
-	format := decoder.Format()
-	if format == nil {
-		return nil, beep.Format{}, fmt.Errorf("could not get AIFF format")
+	encoding, ok := aiffCompressionTypes[info.compression]
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("unsupported AIFF-C compression type: %q", info.compression)
+	}
+	if encoding.bytesPerSample == 0 {
+		if info.sampleSize < 1 || info.sampleSize > 32 {
+			return nil, beep.Format{}, fmt.Errorf("unsupported AIFF bit depth: %d", info.sampleSize)
+		}
+		// Samples are padded to whole bytes and left-justified
+		encoding.bytesPerSample = (info.sampleSize + 7) / 8
+	}
+	if info.numChannels < 1 {
+		return nil, beep.Format{}, fmt.Errorf("invalid AIFF channel count: %d", info.numChannels)
 	}
 
 	// Convert to beep format
 	beepFormat := beep.Format{
-		SampleRate:  beep.SampleRate(format.SampleRate),
-		NumChannels: format.NumChannels,
+		SampleRate:  beep.SampleRate(math.Round(info.sampleRate)),
+		NumChannels: info.numChannels,
 		Precision:   4, // 32-bit samples
 	}
 
-	// Calculate total samples in the file
-	totalSamples := int(decoder.NumSampleFrames)
-
-	// Setup bit depth and normalization values
-	sourceBitDepth := int(decoder.SampleBitDepth())
-	var maxValue float64
-	switch sourceBitDepth {
-	case 8:
-		maxValue = float64(1 << 7)  // 128
-	case 16:
-		maxValue = float64(1 << 15) // 32768
-	case 24:
-		maxValue = float64(1 << 23) // 8388608
-	case 32:
-		maxValue = float64(1 << 31) // 2147483648
-	case 64:
-		maxValue = float64(1 << 63) // For int64
-	default:
-		maxValue = float64(1 << 15) // Default to 16-bit
-	}
-
-	streamer := &aiffStreamer{
-		decoder:        decoder,
-		format:         beepFormat,
-		reader:         readSeeker,
-		sampleBuffer:   make([][2]float64, aiffBufferSize),
-		bufferPos:      0,
-		totalSamples:   totalSamples,
-		currentSample:  0,
-		sourceBitDepth: sourceBitDepth,
-		maxValue:       maxValue,
-	}
-
-	// Load initial buffer
-	if err := streamer.fillBuffer(); err != nil {
-		return nil, beep.Format{}, fmt.Errorf("failed to load initial AIFF buffer: %w", err)
+	frameSize := encoding.bytesPerSample * info.numChannels
+	streamer, err := newPCMStreamer(readSeeker, encoding, info.numChannels, frameSize, info.dataStart, info.numFrames)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to read AIFF file: %w", err)
 	}
+	streamer.metadata = info.metadata
 
 	return streamer, beepFormat, nil
 }
 
-// fillBuffer reads a chunk of audio data from the file and converts it to float64 samples
-func (s *aiffStreamer) fillBuffer() error {
-	if s.currentSample >= s.totalSamples {
-		return fmt.Errorf("end of file reached")
-	}
+// parseAIFF walks the FORM chunks and returns the COMM details, the
+// position of the sound data and any ID3 tags
+func parseAIFF(r io.ReadSeeker) (aiffInfo, error) {
+	var info aiffInfo
 
-	// Calculate how many samples to read (don't exceed file bounds)
-	samplesToRead := aiffBufferSize
-	if s.currentSample+samplesToRead > s.totalSamples {
-		samplesToRead = s.totalSamples - s.currentSample
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || !SniffAIFF(header[:]) {
+		return info, fmt.Errorf("invalid AIFF file")
 	}
+	isAIFC := string(header[8:12]) == "AIFC"
+	info.compression = "NONE"
 
-	// Create a buffer for this chunk
-	s.rawBuffer = &audio.IntBuffer{
-		Data:   make([]int, samplesToRead*s.format.NumChannels),
-		Format: s.decoder.Format(),
-	}
-
-	// Read PCM data for this chunk
-	n, err := s.decoder.PCMBuffer(s.rawBuffer)
+	fileSize, err := r.Seek(0, io.SeekEnd)
 	if err != nil {
-		return fmt.Errorf("failed to read PCM chunk: %w", err)
+		return info, fmt.Errorf("failed to read AIFF file: %w", err)
 	}
-
-	if n == 0 {
-		return fmt.Errorf("no data read from decoder")
+	if _, err := r.Seek(int64(len(header)), io.SeekStart); err != nil {
+		return info, fmt.Errorf("failed to read AIFF file: %w", err)
 	}
 
-	// Convert the raw samples to float64 format
-	actualSamples := n / s.format.NumChannels
-	if actualSamples > len(s.sampleBuffer) {
-		actualSamples = len(s.sampleBuffer)
-	}
+	var foundComm, foundData bool
+	offset := int64(len(header))
 
-	for i := 0; i < actualSamples; i++ {
-		if s.format.NumChannels == 1 {
-			// Mono: duplicate to both channels
-			sample := float64(s.rawBuffer.Data[i]) / s.maxValue
-			s.sampleBuffer[i] = [2]float64{sample, sample}
-		} else if s.format.NumChannels >= 2 {
-			// Stereo or more: take first two channels
-			left := float64(s.rawBuffer.Data[i*s.format.NumChannels]) / s.maxValue
-			right := float64(s.rawBuffer.Data[i*s.format.NumChannels+1]) / s.maxValue
-			s.sampleBuffer[i] = [2]float64{left, right}
+	// Walk every chunk, as ID3 tags usually come after the sound data
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			break
 		}
-	}
-
-	// Reset buffer position and update file position
-	s.bufferPos = 0
-	s.currentSample += actualSamples
-
-	return nil
-}
-
-func (s *aiffStreamer) Stream(samples [][2]float64) (n int, ok bool) {
-	if s.currentSample >= s.totalSamples {
-		return 0, false // End of file
-	}
-
-	totalCopied := 0
-	requestedSamples := len(samples)
-
-	for totalCopied < requestedSamples && s.currentSample < s.totalSamples {
-		// Check if we need to refill the buffer
-		if s.bufferPos >= aiffBufferSize || (s.bufferPos > 0 && s.bufferPos >= s.totalSamples-s.currentSample+s.bufferPos) {
-			if err := s.fillBuffer(); err != nil {
-				// If we can't fill buffer but have copied some samples, return what we have
-				if totalCopied > 0 {
-					return totalCopied, true
-				}
-				return 0, false
+		id := string(chunkHeader[0:4])
+		size := int64(binary.BigEndian.Uint32(chunkHeader[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "COMM":
+			// Check the size against the file before trusting it for an allocation
+			if size < 18 || size > fileSize-body {
+				return info, fmt.Errorf("invalid AIFF COMM chunk")
 			}
-		}
+			comm := make([]byte, size)
+			if _, err := io.ReadFull(r, comm); err != nil {
+				return info, fmt.Errorf("invalid AIFF COMM chunk")
+			}
+			info.numChannels = int(int16(binary.BigEndian.Uint16(comm[0:2])))
+			info.numFrames = int(binary.BigEndian.Uint32(comm[2:6]))
+			info.sampleSize = int(int16(binary.BigEndian.Uint16(comm[6:8])))
+			info.sampleRate = parseExtended(comm[8:18])
+			if isAIFC && size >= 22 {
+				info.compression = string(comm[18:22])
+			}
+			foundComm = true
 
-		// Calculate how many samples to copy from current buffer
-		remainingInRequest := requestedSamples - totalCopied
-		remainingInBuffer := aiffBufferSize - s.bufferPos
-		samplesRemaining := s.totalSamples - (s.currentSample - (aiffBufferSize - s.bufferPos))
+		case "SSND":
+			var ssnd [8]byte
+			if _, err := io.ReadFull(r, ssnd[:]); err != nil {
+				return info, fmt.Errorf("invalid AIFF SSND chunk")
+			}
+			// The data offset lets writers align samples to block boundaries
+			info.dataStart = body + 8 + int64(binary.BigEndian.Uint32(ssnd[0:4]))
+			foundData = true
 
-		toCopy := remainingInRequest
-		if toCopy > remainingInBuffer {
-			toCopy = remainingInBuffer
+		case "ID3 ", "id3 ":
+			info.metadata = readID3Chunk(r, info.metadata)
 		}
-		if toCopy > samplesRemaining {
-			toCopy = samplesRemaining
-		}
-
-		// Copy samples from buffer
-		copy(samples[totalCopied:totalCopied+toCopy], s.sampleBuffer[s.bufferPos:s.bufferPos+toCopy])
 
-		s.bufferPos += toCopy
-		totalCopied += toCopy
+		// Chunks are padded to an even length
+		offset = body + size + size%2
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return info, fmt.Errorf("failed to read AIFF chunks: %w", err)
+		}
 	}
 
-	return totalCopied, totalCopied > 0
-}
-
-func (s *aiffStreamer) Err() error {
-	return nil
-}
+	if !foundComm {
+		return info, fmt.Errorf("invalid AIFF file: missing COMM chunk")
+	}
+	if !foundData && info.numFrames > 0 {
+		return info, fmt.Errorf("invalid AIFF file: missing SSND chunk")
+	}
 
-func (s *aiffStreamer) Len() int {
-	return s.totalSamples
+	return info, nil
 }
 
-func (s *aiffStreamer) Position() int {
-	// Return current position in the file (samples played so far)
-	return s.currentSample - (aiffBufferSize - s.bufferPos)
+// readAIFFTags returns the ID3 tags of an AIFF file
+func readAIFFTags(r io.ReadSeeker) (Metadata, error) {
+	info, err := parseAIFF(r)
+	if err != nil {
+		return nil, err
+	}
+	return info.metadata, nil
 }
 
-func (s *aiffStreamer) Seek(p int) error {
-	if p < 0 || p >= s.totalSamples {
-		return fmt.Errorf("seek position out of range: %d (total: %d)", p, s.totalSamples)
+// parseExtended converts an 80-bit IEEE 754 extended float, as used for the
+// AIFF sample rate, to a float64
+func parseExtended(b []byte) float64 {
+	sign := 1.0
+	if b[0]&0x80 != 0 {
+		sign = -1
 	}
+	exponent := int(binary.BigEndian.Uint16(b[0:2]) & 0x7FFF)
+	mantissa := binary.BigEndian.Uint64(b[2:10])
 
-	// Seek in the underlying reader
-	// Calculate byte position for seeking (this is approximate)
-	bytesPerSample := s.sourceBitDepth / 8
-	bytePos := int64(p * s.format.NumChannels * bytesPerSample)
-
-	if _, err := s.reader.Seek(bytePos, io.SeekStart); err != nil {
-		return fmt.Errorf("failed to seek in AIFF file: %w", err)
+	if exponent == 0 && mantissa == 0 {
+		return 0
 	}
-
-	// Update our position tracking
-	s.currentSample = p
-	s.bufferPos = aiffBufferSize // Force buffer refill on next Stream call
-
-	return nil
+	return sign * math.Ldexp(float64(mantissa), exponent-16383-63)
 }
 
-func (s *aiffStreamer) Close() error {
-	// Clean up buffers to free memory
-	s.rawBuffer = nil
-	s.sampleBuffer = nil
-	// The decoder doesn't need explicit closing in go-audio/aiff
-	return nil
-}
\ No newline at end of file
+// SniffAIFF reports whether header starts an AIFF or AIFF-C file
+func SniffAIFF(header []byte) bool {
+	if len(header) < 12 || string(header[0:4]) != "FORM" {
+		return false
+	}
+	formType := string(header[8:12])
+	return formType == "AIFF" || formType == "AIFC"
+}
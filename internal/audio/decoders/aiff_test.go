@@ -0,0 +1,187 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+// extended44100 is 44100 as an 80-bit IEEE 754 extended float
+var extended44100 = []byte{0x40, 0x0E, 0xAC, 0x44, 0, 0, 0, 0, 0, 0}
+
+// testAIFFValue is the sample written at frame i of channel ch, in [-1, 1)
+func testAIFFValue(i, ch int) float64 {
+	return float64((i%200)-100)/128 + float64(ch)*0.0625
+}
+
+// writeTestAIFF builds an AIFF (compression "") or AIFF-C file with a ramp per channel.
+// encode writes one sample value in the layout under test.
+func writeTestAIFF(compression string, channels, sampleSize, frames int, encode func(*bytes.Buffer, float64)) []byte {
+	var data bytes.Buffer
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < channels; ch++ {
+			encode(&data, testAIFFValue(i, ch))
+		}
+	}
+
+	var comm bytes.Buffer
+	binary.Write(&comm, binary.BigEndian, int16(channels))
+	binary.Write(&comm, binary.BigEndian, uint32(frames))
+	binary.Write(&comm, binary.BigEndian, int16(sampleSize))
+	comm.Write(extended44100)
+	if compression != "" {
+		comm.WriteString(compression)
+		comm.Write([]byte{0, 0}) // Empty pascal-string compression name
+	}
+
+	var body bytes.Buffer
+	if compression == "" {
+		body.WriteString("AIFF")
+	} else {
+		body.WriteString("AIFC")
+		// Skipped chunk with an odd length, to check padding is handled
+		body.WriteString("NAME")
+		binary.Write(&body, binary.BigEndian, uint32(3))
+		body.Write([]byte{'a', 'b', 'c', 0})
+	}
+	body.WriteString("COMM")
+	binary.Write(&body, binary.BigEndian, uint32(comm.Len()))
+	body.Write(comm.Bytes())
+
+	// Use a non-zero data offset, as some writers do for block alignment
+	const dataOffset = 4
+	body.WriteString("SSND")
+	binary.Write(&body, binary.BigEndian, uint32(8+dataOffset+data.Len()))
+	binary.Write(&body, binary.BigEndian, uint32(dataOffset))
+	binary.Write(&body, binary.BigEndian, uint32(0))
+	body.Write(make([]byte, dataOffset))
+	body.Write(data.Bytes())
+
+	var file bytes.Buffer
+	file.WriteString("FORM")
+	binary.Write(&file, binary.BigEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func intEncoder(bytesPerSample int, order binary.ByteOrder) func(*bytes.Buffer, float64) {
+	return func(buf *bytes.Buffer, v float64) {
+		full := uint32(int32(v * float64(1<<31)))
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], full)
+		sample := b[:bytesPerSample]
+		if order == binary.LittleEndian {
+			for i, j := 0, len(sample)-1; i < j; i, j = i+1, j-1 {
+				sample[i], sample[j] = sample[j], sample[i]
+			}
+		}
+		buf.Write(sample)
+	}
+}
+
+func TestDecodeAIFF_Encodings(t *testing.T) {
+	tests := []struct {
+		name        string
+		compression string
+		sampleSize  int
+		channels    int
+		encode      func(*bytes.Buffer, float64)
+		tolerance   float64
+	}{
+		{"16-bit AIFF", "", 16, 2, intEncoder(2, binary.BigEndian), 1.0 / (1 << 15)},
+		{"24-bit AIFF mono", "", 24, 1, intEncoder(3, binary.BigEndian), 1.0 / (1 << 23)},
+		{"8-bit AIFF", "", 8, 2, intEncoder(1, binary.BigEndian), 1.0 / (1 << 7)},
+		{"AIFF-C NONE", "NONE", 16, 2, intEncoder(2, binary.BigEndian), 1.0 / (1 << 15)},
+		{"AIFF-C sowt 16-bit", "sowt", 16, 2, intEncoder(2, binary.LittleEndian), 1.0 / (1 << 15)},
+		{"AIFF-C sowt 24-bit", "sowt", 24, 2, intEncoder(3, binary.LittleEndian), 1.0 / (1 << 23)},
+		{"AIFF-C fl32", "fl32", 32, 2, func(buf *bytes.Buffer, v float64) {
+			binary.Write(buf, binary.BigEndian, float32(v))
+		}, 1e-7},
+		{"AIFF-C fl64", "fl64", 64, 2, func(buf *bytes.Buffer, v float64) {
+			binary.Write(buf, binary.BigEndian, v)
+		}, 1e-12},
+		{"AIFF-C raw unsigned", "raw ", 8, 1, func(buf *bytes.Buffer, v float64) {
+			buf.WriteByte(byte(int(math.Floor(v*128)) + 128))
+		}, 1.0 / (1 << 7)},
+	}
+
+	const frames = pcmBufferSize + 500 // Spans a buffer refill
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeTestAIFF(tt.compression, tt.channels, tt.sampleSize, frames, tt.encode)
+
+			streamer, format, err := DecodeAIFF(nopReadSeekCloser{bytes.NewReader(data)})
+			if err != nil {
+				t.Fatalf("DecodeAIFF() failed: %v", err)
+			}
+
+			if format.SampleRate != 44100 || format.NumChannels != tt.channels {
+				t.Errorf("Format = %+v, want 44100Hz with %d channels", format, tt.channels)
+			}
+			if streamer.Len() != frames {
+				t.Errorf("Len() = %d, want %d", streamer.Len(), frames)
+			}
+
+			rightCh := 1
+			if tt.channels == 1 {
+				rightCh = 0
+			}
+
+			for _, pos := range []int{0, pcmBufferSize - 10, frames - 20} {
+				if err := streamer.Seek(pos); err != nil {
+					t.Fatalf("Seek(%d) failed: %v", pos, err)
+				}
+
+				buf := make([][2]float64, 20)
+				if n, ok := streamer.Stream(buf); !ok || n != 20 {
+					t.Fatalf("Stream() after Seek(%d) = %d, %v", pos, n, ok)
+				}
+				for i, s := range buf {
+					wantL, wantR := testAIFFValue(pos+i, 0), testAIFFValue(pos+i, rightCh)
+					if math.Abs(s[0]-wantL) > tt.tolerance || math.Abs(s[1]-wantR) > tt.tolerance {
+						t.Fatalf("Sample %d = %v, want [%v %v]", pos+i, s, wantL, wantR)
+					}
+				}
+			}
+
+			if streamer.Position() != frames {
+				t.Errorf("Position() = %d, want %d", streamer.Position(), frames)
+			}
+			if n, ok := streamer.Stream(make([][2]float64, 10)); ok || n != 0 {
+				t.Errorf("Stream() at end = %d, %v, want 0, false", n, ok)
+			}
+			if streamer.Err() != nil {
+				t.Errorf("Err() = %v", streamer.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeAIFF_UnsupportedCompression(t *testing.T) {
+	data := writeTestAIFF("ulaw", 1, 16, 10, func(buf *bytes.Buffer, v float64) {
+		buf.WriteByte(0)
+	})
+
+	if _, _, err := DecodeAIFF(nopReadSeekCloser{bytes.NewReader(data)}); err == nil {
+		t.Error("DecodeAIFF() should reject µ-law compression")
+	}
+}
+
+func TestDecodeAIFF_OversizedChunk(t *testing.T) {
+	// A COMM chunk claiming nearly 4GB in a file of a few bytes
+	data := []byte("FORM\x00\x00\x00\x20AIFFCOMM\xFF\xFF\xFF\xF0\x00\x01")
+
+	if _, _, err := DecodeAIFF(nopReadSeekCloser{bytes.NewReader(data)}); err == nil {
+		t.Error("DecodeAIFF() should reject a chunk larger than the file")
+	}
+}
+
+// nopReadSeekCloser adds a no-op Close to an in-memory file
+type nopReadSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopReadSeekCloser) Close() error { return nil }
@@ -0,0 +1,43 @@
+package decoders
+
+// Stereo mix weights for the speaker positions found in multichannel files
+var (
+	speakerLeft     = [2]float64{1, 0}
+	speakerRight    = [2]float64{0, 1}
+	speakerCenter   = [2]float64{0.7071, 0.7071}
+	speakerLFE      = [2]float64{0, 0} // Dropped, as most stereo downmixes do
+	speakerSurLeft  = [2]float64{0.7071, 0}
+	speakerSurRight = [2]float64{0, 0.7071}
+	speakerSurBack  = [2]float64{0.5, 0.5}
+)
+
+// waveChannelWeights maps each channel count to the stereo weights of its channels,
+// following the default WAVE channel order, which FLAC also uses
+var waveChannelWeights = map[int][][2]float64{
+	1: {{1, 1}},
+	2: {speakerLeft, speakerRight},
+	3: {speakerLeft, speakerRight, speakerCenter},
+	4: {speakerLeft, speakerRight, speakerSurLeft, speakerSurRight},
+	5: {speakerLeft, speakerRight, speakerCenter, speakerSurLeft, speakerSurRight},
+	6: {speakerLeft, speakerRight, speakerCenter, speakerLFE, speakerSurLeft, speakerSurRight},
+	7: {speakerLeft, speakerRight, speakerCenter, speakerLFE, speakerSurBack, speakerSurLeft, speakerSurRight},
+	8: {speakerLeft, speakerRight, speakerCenter, speakerLFE, speakerSurLeft, speakerSurRight, speakerSurLeft, speakerSurRight},
+}
+
+// normalizeDownmix scales multichannel weights so a full-scale signal on every
+// channel doesn't clip. Mono and stereo weights are returned unchanged.
+func normalizeDownmix(weights [][2]float64) [][2]float64 {
+	var leftSum float64
+	for _, w := range weights {
+		leftSum += w[0]
+	}
+	if len(weights) <= 2 || leftSum <= 1 {
+		return weights
+	}
+
+	normalised := make([][2]float64, len(weights))
+	for i, w := range weights {
+		normalised[i] = [2]float64{w[0] / leftSum, w[1] / leftSum}
+	}
+	return normalised
+}
@@ -0,0 +1,279 @@
+package decoders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+)
+
+// flacStreamer implements beep.StreamSeekCloser for FLAC files
+type flacStreamer struct {
+	stream *flac.Stream
+	frame  *frame.Frame // Frame currently being streamed, nil at end of stream
+
+	posInFrame   int // Next sample to stream within frame
+	position     int // Absolute position of the next sample
+	totalSamples int
+
+	// Format info for conversion
+	blockSizeMax int
+	scale        float64      // Converts integer samples to [-1, 1]
+	weights      [][2]float64 // Per-channel stereo mix weights
+	err          error
+}
+
+// offsetReadSeeker presents the part of a file from offset on as if it were
+// the whole file
+type offsetReadSeeker struct {
+	io.ReadSeeker
+	offset int64
+}
+
+func (r *offsetReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	if whence == io.SeekStart {
+		offset += r.offset
+	}
+	pos, err := r.ReadSeeker.Seek(offset, whence)
+	return pos - r.offset, err
+}
+
+// DecodeFLAC creates a seekable beep-compatible streamer from a FLAC file.
+// Seeking uses the file's seektable, or builds one on first seek if it has none.
+func DecodeFLAC(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+	// FLAC seeking needs ReadSeeker, same as AIFF
+	readSeeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("FLAC decoder requires ReadSeeker interface")
+	}
+
+	// Skip a leading ID3v2 tag here, as the flac package loses data when it
+	// skips one itself
+	tagSize, err := id3v2Size(readSeeker)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to read FLAC file: %w", err)
+	}
+	if _, err := readSeeker.Seek(tagSize, io.SeekStart); err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to read FLAC file: %w", err)
+	}
+	if tagSize > 0 {
+		readSeeker = &offsetReadSeeker{ReadSeeker: readSeeker, offset: tagSize}
+	}
+
+	stream, err := flac.NewSeek(readSeeker)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to decode FLAC: %w", err)
+	}
+
+	info := stream.Info
+	numChannels := int(info.NChannels)
+	weights, ok := waveChannelWeights[numChannels]
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("unsupported FLAC channel count: %d", numChannels)
+	}
+	if info.BitsPerSample < 4 || info.BitsPerSample > 32 {
+		return nil, beep.Format{}, fmt.Errorf("unsupported FLAC bit depth: %d", info.BitsPerSample)
+	}
+
+	streamer := &flacStreamer{
+		stream:       stream,
+		blockSizeMax: int(info.BlockSizeMax),
+		scale:        1 / float64(uint64(1)<<(info.BitsPerSample-1)),
+		weights:      normalizeDownmix(weights),
+		totalSamples: int(info.NSamples),
+	}
+
+	// STREAMINFO may leave the sample count unset (e.g. files encoded from a pipe)
+	if streamer.totalSamples == 0 {
+		if err := streamer.countSamples(); err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to scan FLAC file: %w", err)
+		}
+	}
+
+	streamer.frame, err = stream.ParseNext()
+	if err != nil && err != io.EOF {
+		return nil, beep.Format{}, fmt.Errorf("failed to read first FLAC frame: %w", err)
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(info.SampleRate),
+		NumChannels: numChannels,
+		Precision:   int((info.BitsPerSample + 7) / 8),
+	}
+
+	return streamer, format, nil
+}
+
+// countSamples walks every frame to find the stream length, then rewinds
+func (s *flacStreamer) countSamples() error {
+	total := 0
+	for {
+		f, err := s.stream.ParseNext()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		total += int(f.BlockSize)
+	}
+
+	s.totalSamples = total
+	s.stream.Info.NSamples = uint64(total)
+
+	if total == 0 {
+		return nil
+	}
+
+	_, err := s.stream.Seek(0)
+	return err
+}
+
+// frameStart returns the absolute position of a frame's first sample.
+// frame.SampleNumber is wrong for the short last frame of a fixed-blocksize
+// stream, so the position is derived from the frame number instead.
+func (s *flacStreamer) frameStart(f *frame.Frame) int {
+	if f.HasFixedBlockSize {
+		return int(f.Num) * s.blockSizeMax
+	}
+	return int(f.Num)
+}
+
+func (s *flacStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.err != nil || s.frame == nil {
+		return 0, false
+	}
+
+	for len(samples) > 0 {
+		remaining := int(s.frame.BlockSize) - s.posInFrame
+		if remaining <= 0 {
+			next, err := s.stream.ParseNext()
+			if err != nil {
+				s.frame = nil
+				if err != io.EOF {
+					s.err = fmt.Errorf("failed to read FLAC frame: %w", err)
+				}
+				return n, n > 0
+			}
+			s.frame = next
+			s.posInFrame = 0
+			continue
+		}
+
+		toCopy := remaining
+		if toCopy > len(samples) {
+			toCopy = len(samples)
+		}
+
+		s.convert(samples[:toCopy])
+		s.posInFrame += toCopy
+		s.position += toCopy
+		n += toCopy
+		samples = samples[toCopy:]
+	}
+
+	return n, true
+}
+
+// convert mixes the next len(out) samples of the current frame down to stereo
+func (s *flacStreamer) convert(out [][2]float64) {
+	subframes := s.frame.Subframes
+	start := s.posInFrame
+
+	if len(subframes) == 1 {
+		src := subframes[0].Samples[start:]
+		for i := range out {
+			v := float64(src[i]) * s.scale
+			out[i] = [2]float64{v, v}
+		}
+		return
+	}
+
+	if len(subframes) == 2 {
+		left := subframes[0].Samples[start:]
+		right := subframes[1].Samples[start:]
+		for i := range out {
+			out[i] = [2]float64{float64(left[i]) * s.scale, float64(right[i]) * s.scale}
+		}
+		return
+	}
+
+	for i := range out {
+		var l, r float64
+		for ch, sub := range subframes {
+			v := float64(sub.Samples[start+i]) * s.scale
+			l += v * s.weights[ch][0]
+			r += v * s.weights[ch][1]
+		}
+		out[i] = [2]float64{l, r}
+	}
+}
+
+func (s *flacStreamer) Err() error {
+	return s.err
+}
+
+func (s *flacStreamer) Len() int {
+	return s.totalSamples
+}
+
+func (s *flacStreamer) Position() int {
+	return s.position
+}
+
+func (s *flacStreamer) Seek(p int) error {
+	if p < 0 || p > s.totalSamples {
+		return fmt.Errorf("seek position out of range: %d (total: %d)", p, s.totalSamples)
+	}
+
+	if p == s.totalSamples {
+		s.frame = nil
+		s.position = p
+		return nil
+	}
+
+	// The library can't locate the last frame of a fixed-blocksize stream,
+	// so aim for an earlier frame and read forward from there
+	target := p
+	if lastFrame := s.totalSamples - s.blockSizeMax; p >= lastFrame {
+		target = lastFrame - 1
+		if target < 0 {
+			target = 0
+		}
+	}
+
+	if _, err := s.stream.Seek(uint64(target)); err != nil {
+		return fmt.Errorf("failed to seek in FLAC file: %w", err)
+	}
+
+	for {
+		f, err := s.stream.ParseNext()
+		if err != nil {
+			return fmt.Errorf("failed to seek in FLAC file: %w", err)
+		}
+
+		start := s.frameStart(f)
+		if start+int(f.BlockSize) > p {
+			s.frame = f
+			s.posInFrame = p - start
+			s.position = p
+			s.err = nil
+			return nil
+		}
+	}
+}
+
+func (s *flacStreamer) Close() error {
+	// The file itself is closed by the player
+	s.frame = nil
+	s.stream = nil
+	return nil
+}
+
+// SniffFLAC reports whether header starts a native FLAC stream
+func SniffFLAC(header []byte) bool {
+	return bytes.HasPrefix(header, []byte("fLaC"))
+}
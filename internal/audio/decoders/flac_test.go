@@ -0,0 +1,218 @@
+package decoders
+
+import (
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// onlyWriter hides Seek so the encoder can't fill in the sample count
+type onlyWriter struct{ io.Writer }
+
+// writeTestFLAC encodes a FLAC file whose samples are a ramp over i, offset per channel
+func writeTestFLAC(t *testing.T, channels, bps, blockSize, total int, knownLength bool) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "test.flac")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	info := &meta.StreamInfo{
+		BlockSizeMin:  uint16(blockSize),
+		BlockSizeMax:  uint16(blockSize),
+		SampleRate:    44100,
+		NChannels:     uint8(channels),
+		BitsPerSample: uint8(bps),
+	}
+
+	var w io.Writer = file
+	if !knownLength {
+		w = onlyWriter{file}
+	}
+
+	enc, err := flac.NewEncoder(w, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for start := 0; start < total; start += blockSize {
+		n := blockSize
+		if start+n > total {
+			n = total - start
+		}
+
+		f := &frame.Frame{
+			Header: frame.Header{
+				HasFixedBlockSize: true,
+				BlockSize:         uint16(n),
+				SampleRate:        44100,
+				Channels:          frame.Channels(channels - 1),
+				BitsPerSample:     uint8(bps),
+			},
+		}
+		for ch := 0; ch < channels; ch++ {
+			samples := make([]int32, n)
+			for i := range samples {
+				samples[i] = testSample(start+i, ch)
+			}
+			f.Subframes = append(f.Subframes, &frame.Subframe{
+				SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+				Samples:   samples,
+				NSamples:  n,
+			})
+		}
+
+		if err := enc.WriteFrame(f); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func testSample(i, ch int) int32 {
+	return int32(i%1000) + int32(ch)*1000
+}
+
+func TestDecodeFLAC_LengthAndSeek(t *testing.T) {
+	tests := []struct {
+		name        string
+		channels    int
+		bps         int
+		knownLength bool
+	}{
+		{"16-bit stereo", 2, 16, true},
+		{"24-bit stereo", 2, 24, true},
+		{"16-bit mono", 1, 16, true},
+		{"unknown length", 2, 16, false},
+	}
+
+	const blockSize = 1024
+	const total = blockSize*5 + 300 // Short last frame
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeTestFLAC(t, tt.channels, tt.bps, blockSize, total, tt.knownLength)
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			streamer, format, err := DecodeFLAC(file)
+			if err != nil {
+				t.Fatalf("DecodeFLAC() failed: %v", err)
+			}
+			defer streamer.Close()
+
+			if format.NumChannels != tt.channels {
+				t.Errorf("NumChannels = %d, want %d", format.NumChannels, tt.channels)
+			}
+			if streamer.Len() != total {
+				t.Errorf("Len() = %d, want %d", streamer.Len(), total)
+			}
+
+			scale := 1 / float64(int(1)<<(tt.bps-1))
+			rightCh := 1
+			if tt.channels == 1 {
+				rightCh = 0
+			}
+
+			// Seek into the middle, into the short last frame, and back to the start
+			for _, pos := range []int{2500, total - 100, 0} {
+				if err := streamer.Seek(pos); err != nil {
+					t.Fatalf("Seek(%d) failed: %v", pos, err)
+				}
+				if streamer.Position() != pos {
+					t.Errorf("Position() after Seek(%d) = %d", pos, streamer.Position())
+				}
+
+				buf := make([][2]float64, 50)
+				n, ok := streamer.Stream(buf)
+				if !ok || n != 50 {
+					t.Fatalf("Stream() after Seek(%d) = %d, %v", pos, n, ok)
+				}
+
+				wantL := float64(testSample(pos, 0)) * scale
+				wantR := float64(testSample(pos, rightCh)) * scale
+				if math.Abs(buf[0][0]-wantL) > 1e-9 || math.Abs(buf[0][1]-wantR) > 1e-9 {
+					t.Errorf("Sample at %d = %v, want [%v %v]", pos, buf[0], wantL, wantR)
+				}
+			}
+
+			// Streaming to the end yields exactly Len() samples
+			streamer.Seek(0)
+			count := 0
+			buf := make([][2]float64, 777)
+			for {
+				n, ok := streamer.Stream(buf)
+				count += n
+				if !ok {
+					break
+				}
+			}
+			if count != total {
+				t.Errorf("Streamed %d samples, want %d", count, total)
+			}
+			if streamer.Err() != nil {
+				t.Errorf("Err() = %v", streamer.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeFLAC_MultichannelDownmix(t *testing.T) {
+	path := writeTestFLAC(t, 6, 16, 512, 2000, true)
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	streamer, format, err := DecodeFLAC(file)
+	if err != nil {
+		t.Fatalf("DecodeFLAC() failed: %v", err)
+	}
+
+	if format.NumChannels != 6 {
+		t.Errorf("NumChannels = %d, want 6", format.NumChannels)
+	}
+
+	buf := make([][2]float64, 10)
+	if n, ok := streamer.Stream(buf); !ok || n != 10 {
+		t.Fatalf("Stream() = %d, %v", n, ok)
+	}
+
+	// Surround content must reach the stereo output, and stay within range
+	for _, s := range buf {
+		if s[0] == 0 || s[1] == 0 || math.Abs(s[0]) > 1 || math.Abs(s[1]) > 1 {
+			t.Fatalf("Unexpected downmixed sample %v", s)
+		}
+	}
+}
+
+func TestFormatRegistry_FLACRegistered(t *testing.T) {
+	registry := NewFormatRegistry()
+
+	if !registry.IsSupported("/music/track.FLAC") {
+		t.Error("FLAC files should be supported")
+	}
+	if registry.IsSupported("/music/notes.txt") {
+		t.Error("Text files should not be supported")
+	}
+}
@@ -0,0 +1,501 @@
+package decoders
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// id3MaxFrameSize caps the frames that are read into memory. Text frames
+// are tiny; anything bigger is artwork or other data we don't use.
+const id3MaxFrameSize = 1 << 20
+
+// id3Frames maps the ID3v2.3/2.4 frames we read, and their three-letter
+// ID3v2.2 equivalents, to metadata field names
+var id3Frames = map[string]string{
+	"TIT2": "TITLE", "TT2": "TITLE",
+	"TPE1": "ARTIST", "TP1": "ARTIST",
+	"TPE2": "ALBUMARTIST", "TP2": "ALBUMARTIST",
+	"TALB": "ALBUM", "TAL": "ALBUM",
+	"TCON": "GENRE", "TCO": "GENRE",
+	"TBPM": "BPM", "TBP": "BPM",
+	"TKEY": "KEY", "TKE": "KEY",
+	"TPUB": "LABEL", "TPB": "LABEL",
+	"TRCK": "TRACKNUMBER", "TRK": "TRACKNUMBER",
+	"TDRC": "DATE", "TYER": "DATE", "TYE": "DATE",
+	"COMM": "COMMENT", "COM": "COMMENT",
+	"POPM": "RATING", "POP": "RATING",
+}
+
+// id3Genres are the numbered genres of ID3v1, including the Winamp
+// extensions, which ID3v2 genres may also refer to as "(17)" or "17"
+var id3Genres = []string{
+	"Blues", "Classic Rock", "Country", "Dance", "Disco", "Funk", "Grunge", "Hip-Hop",
+	"Jazz", "Metal", "New Age", "Oldies", "Other", "Pop", "R&B", "Rap",
+	"Reggae", "Rock", "Techno", "Industrial", "Alternative", "Ska", "Death Metal", "Pranks",
+	"Soundtrack", "Euro-Techno", "Ambient", "Trip-Hop", "Vocal", "Jazz+Funk", "Fusion", "Trance",
+	"Classical", "Instrumental", "Acid", "House", "Game", "Sound Clip", "Gospel", "Noise",
+	"Alternative Rock", "Bass", "Soul", "Punk", "Space", "Meditative", "Instrumental Pop", "Instrumental Rock",
+	"Ethnic", "Gothic", "Darkwave", "Techno-Industrial", "Electronic", "Pop-Folk", "Eurodance", "Dream",
+	"Southern Rock", "Comedy", "Cult", "Gangsta", "Top 40", "Christian Rap", "Pop/Funk", "Jungle",
+	"Native American", "Cabaret", "New Wave", "Psychedelic", "Rave", "Showtunes", "Trailer", "Lo-Fi",
+	"Tribal", "Acid Punk", "Acid Jazz", "Polka", "Retro", "Musical", "Rock & Roll", "Hard Rock",
+	"Folk", "Folk-Rock", "National Folk", "Swing", "Fast Fusion", "Bebop", "Latin", "Revival",
+	"Celtic", "Bluegrass", "Avantgarde", "Gothic Rock", "Progressive Rock", "Psychedelic Rock", "Symphonic Rock", "Slow Rock",
+	"Big Band", "Chorus", "Easy Listening", "Acoustic", "Humour", "Speech", "Chanson", "Opera",
+	"Chamber Music", "Sonata", "Symphony", "Booty Bass", "Primus", "Porn Groove", "Satire", "Slow Jam",
+	"Club", "Tango", "Samba", "Folklore", "Ballad", "Power Ballad", "Rhythmic Soul", "Freestyle",
+	"Duet", "Punk Rock", "Drum Solo", "A Cappella", "Euro-House", "Dance Hall", "Goa", "Drum & Bass",
+	"Club-House", "Hardcore", "Terror", "Indie", "BritPop", "Afro-Punk", "Polsk Punk", "Beat",
+	"Christian Gangsta Rap", "Heavy Metal", "Black Metal", "Crossover", "Contemporary Christian", "Christian Rock", "Merengue", "Salsa",
+	"Thrash Metal", "Anime", "JPop", "Synthpop", "Abstract", "Art Rock", "Baroque", "Bhangra",
+	"Big Beat", "Breakbeat", "Chillout", "Downtempo", "Dub", "EBM", "Eclectic", "Electro",
+	"Electroclash", "Emo", "Experimental", "Garage", "Global", "IDM", "Illbient", "Industro-Goth",
+	"Jam Band", "Krautrock", "Leftfield", "Lounge", "Math Rock", "New Romantic", "Nu-Breakz", "Post-Punk",
+	"Post-Rock", "Psytrance", "Shoegaze", "Space Rock", "Trop Rock", "World Music", "Neoclassical", "Audiobook",
+	"Audio Theatre", "Neue Deutsche Welle", "Podcast", "Indie Rock", "G-Funk", "Dubstep", "Garage Rock", "Psybient",
+}
+
+// ReadID3 reads the tags of an MP3 file: the ID3v2 tag at the start, with
+// any fields it lacks filled in from an ID3v1 tag at the end. Damaged tags
+// are read as far as they make sense; only I/O failures are errors.
+func ReadID3(file io.ReadSeeker) (Metadata, error) {
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	metadata, err := readID3v2(file)
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if size < 128 {
+		return metadata, nil
+	}
+	tail, err := readAt(file, size-128, 128)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range parseID3v1(tail) {
+		if metadata == nil {
+			metadata = make(Metadata)
+		}
+		if _, exists := metadata[key]; !exists {
+			metadata[key] = values
+		}
+	}
+	return metadata, nil
+}
+
+// readID3v2 reads an ID3v2 tag starting at the reader's position, as found
+// at the start of MP3 files and in the ID3 chunks of WAV and AIFF files. It
+// returns nil if there is no tag there. Frames we don't use, such as
+// artwork, are skipped rather than read.
+func readID3v2(r io.ReadSeeker) (Metadata, error) {
+	var header [10]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if string(header[0:3]) != "ID3" {
+		return nil, nil
+	}
+
+	version := header[3]
+	flags := header[5]
+	size := int64(syncsafe(header[6:10]))
+	if version < 2 || version > 4 {
+		return nil, nil
+	}
+	// ID3v2.2 compression was never defined, so such tags can't be read
+	if version == 2 && flags&0x40 != 0 {
+		return nil, nil
+	}
+
+	// A damaged size can claim up to 256MB, so trust it no further than the
+	// bytes left to read
+	here, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	end, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := r.Seek(here, io.SeekStart); err != nil {
+		return nil, err
+	}
+	size = min(size, end-here)
+
+	// Before ID3v2.4, unsynchronisation applies to the whole tag, so undo it
+	// up front. ID3v2.4 marks it on each frame instead.
+	unsync := flags&0x80 != 0
+	if unsync && version < 4 {
+		body := make([]byte, size)
+		n, err := io.ReadFull(r, body)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, err
+		}
+		body = removeUnsync(body[:n])
+		r, size = bytes.NewReader(body), int64(len(body))
+	}
+
+	var pos int64
+	if flags&0x40 != 0 {
+		var ext [4]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, nil
+		}
+		// ID3v2.3 doesn't count the size field itself; ID3v2.4 does and
+		// stores it syncsafe
+		skip := int64(binary.BigEndian.Uint32(ext[:]))
+		if version == 4 {
+			skip = int64(syncsafe(ext[:])) - 4
+		}
+		if skip < 0 || skip > size-4 {
+			return nil, nil
+		}
+		if _, err := r.Seek(skip, io.SeekCurrent); err != nil {
+			return nil, err
+		}
+		pos = 4 + skip
+	}
+
+	headerSize := int64(10)
+	if version == 2 {
+		headerSize = 6
+	}
+
+	metadata := make(Metadata)
+	frameHeader := make([]byte, headerSize)
+	for pos+headerSize <= size {
+		if _, err := io.ReadFull(r, frameHeader); err != nil {
+			break
+		}
+		pos += headerSize
+
+		id, frameSize, frameFlags := parseID3FrameHeader(version, frameHeader)
+		if !validID3FrameID(id) || frameSize > size-pos {
+			break // Padding, or a damaged tag
+		}
+		pos += frameSize
+
+		key, wanted := id3Frames[id]
+		if !wanted || frameSize > id3MaxFrameSize {
+			if _, err := r.Seek(frameSize, io.SeekCurrent); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		data := make([]byte, frameSize)
+		if _, err := io.ReadFull(r, data); err != nil {
+			break
+		}
+		data, ok := id3FrameData(version, frameFlags, unsync, data)
+		if !ok {
+			continue
+		}
+		for _, value := range decodeID3Frame(id, data) {
+			if key == "GENRE" {
+				value = resolveID3Genre(value)
+			}
+			metadata.Add(key, value)
+		}
+	}
+
+	return metadata, nil
+}
+
+// parseID3FrameHeader returns a frame's ID, size and flags. ID3v2.2 frames
+// have three-letter IDs and no flags; ID3v2.4 sizes are syncsafe.
+func parseID3FrameHeader(version byte, header []byte) (string, int64, uint16) {
+	switch version {
+	case 2:
+		size := int64(header[3])<<16 | int64(header[4])<<8 | int64(header[5])
+		return string(header[0:3]), size, 0
+	case 3:
+		return string(header[0:4]), int64(binary.BigEndian.Uint32(header[4:8])), binary.BigEndian.Uint16(header[8:10])
+	default:
+		return string(header[0:4]), int64(syncsafe(header[4:8])), binary.BigEndian.Uint16(header[8:10])
+	}
+}
+
+// validID3FrameID reports whether id is made of upper-case letters and digits
+func validID3FrameID(id string) bool {
+	for i := 0; i < len(id); i++ {
+		if (id[i] < 'A' || id[i] > 'Z') && (id[i] < '0' || id[i] > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// id3FrameData strips the extra header fields that frame flags add and
+// undoes unsynchronisation and compression. It reports false for frames
+// that can't be read, such as encrypted ones.
+func id3FrameData(version byte, flags uint16, tagUnsync bool, data []byte) ([]byte, bool) {
+	var compressed bool
+	switch version {
+	case 3:
+		if flags&0x0040 != 0 {
+			return nil, false // Encrypted
+		}
+		if flags&0x0080 != 0 {
+			// Preceded by the decompressed size
+			if len(data) < 4 {
+				return nil, false
+			}
+			data, compressed = data[4:], true
+		}
+		if flags&0x0020 != 0 {
+			// Preceded by a group ID
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+	case 4:
+		if flags&0x0004 != 0 {
+			return nil, false // Encrypted
+		}
+		if flags&0x0040 != 0 {
+			if len(data) < 1 {
+				return nil, false
+			}
+			data = data[1:]
+		}
+		if flags&0x0001 != 0 {
+			// Preceded by the data length
+			if len(data) < 4 {
+				return nil, false
+			}
+			data = data[4:]
+		}
+		if flags&0x0002 != 0 || tagUnsync {
+			data = removeUnsync(data)
+		}
+		compressed = flags&0x0008 != 0
+	}
+
+	if compressed {
+		reader, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, false
+		}
+		defer reader.Close()
+		data, err = io.ReadAll(io.LimitReader(reader, id3MaxFrameSize))
+		if err != nil {
+			return nil, false
+		}
+	}
+	return data, true
+}
+
+// decodeID3Frame returns the values of a text, comment or popularimeter
+// frame. Comments carry a language and a description before the text;
+// comments with an iTunes description hold encoder data rather than anything
+// written by a person, and are left out. Popularimeter ratings are returned
+// as stars.
+func decodeID3Frame(id string, data []byte) []string {
+	if id == "POPM" || id == "POP" {
+		// An email address identifying the player, then the rating
+		end := bytes.IndexByte(data, 0)
+		if end < 0 || end+1 >= len(data) {
+			return nil
+		}
+		return []string{strconv.Itoa(ratingStars(data[end+1]))}
+	}
+
+	if len(data) < 1 {
+		return nil
+	}
+	encoding, text := data[0], data[1:]
+
+	if id == "COMM" || id == "COM" {
+		if len(text) < 3 {
+			return nil
+		}
+		values := id3Strings(encoding, text[3:], true)
+		if len(values) < 2 || strings.HasPrefix(values[0], "iTun") {
+			return nil
+		}
+		if comment := strings.TrimSpace(values[1]); comment != "" {
+			return []string{comment}
+		}
+		return nil
+	}
+
+	var values []string
+	for _, value := range id3Strings(encoding, text, false) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// id3Strings splits NUL-terminated strings in the given text encoding.
+// ID3v2.4 separates multiple values of a text frame this way. With keepEmpty
+// set, empty strings are kept so that fields stay in position.
+func id3Strings(encoding byte, data []byte, keepEmpty bool) []string {
+	width := 1
+	if encoding == 1 || encoding == 2 {
+		width = 2
+	}
+
+	var values []string
+	for len(data) > 0 {
+		end := len(data)
+		for i := 0; i+width <= len(data); i += width {
+			if data[i] == 0 && (width == 1 || data[i+1] == 0) {
+				end = i
+				break
+			}
+		}
+		value := decodeID3String(encoding, data[:end])
+		if value != "" || keepEmpty {
+			values = append(values, value)
+		}
+		if end+width > len(data) {
+			break
+		}
+		data = data[end+width:]
+	}
+	return values
+}
+
+// decodeID3String converts one string from ISO-8859-1 (0), UTF-16 with a
+// byte order mark (1), UTF-16BE (2) or UTF-8 (3) to UTF-8
+func decodeID3String(encoding byte, b []byte) string {
+	switch encoding {
+	case 1, 2:
+		order := binary.ByteOrder(binary.BigEndian)
+		if len(b) >= 2 {
+			switch {
+			case b[0] == 0xFF && b[1] == 0xFE:
+				order, b = binary.LittleEndian, b[2:]
+			case b[0] == 0xFE && b[1] == 0xFF:
+				b = b[2:]
+			}
+		}
+		units := make([]uint16, len(b)/2)
+		for i := range units {
+			units[i] = order.Uint16(b[2*i:])
+		}
+		return string(utf16.Decode(units))
+	case 3:
+		return strings.ToValidUTF8(string(b), "�")
+	default:
+		return latin1(b)
+	}
+}
+
+// latin1 converts ISO-8859-1 text to UTF-8
+func latin1(b []byte) string {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+// resolveID3Genre turns numbered genres, "17" or "(17)" with an optional
+// refinement after it, into names
+func resolveID3Genre(genre string) string {
+	number, rest := genre, ""
+	if strings.HasPrefix(genre, "(") {
+		end := strings.IndexByte(genre, ')')
+		if end < 0 {
+			return genre
+		}
+		number, rest = genre[1:end], strings.TrimSpace(genre[end+1:])
+		switch number {
+		case "RX":
+			number = "Remix"
+		case "CR":
+			number = "Cover"
+		}
+	}
+	if rest != "" {
+		return rest
+	}
+	if n, err := strconv.Atoi(number); err == nil {
+		if n >= 0 && n < len(id3Genres) {
+			return id3Genres[n]
+		}
+		return genre
+	}
+	return number
+}
+
+// parseID3v1 reads the fixed-size ID3v1 tag from the last 128 bytes of a
+// file, including the ID3v1.1 track number. It returns nil if there is none.
+func parseID3v1(tail []byte) Metadata {
+	if len(tail) != 128 || string(tail[0:3]) != "TAG" {
+		return nil
+	}
+
+	metadata := make(Metadata)
+	field := func(key string, b []byte) {
+		if i := bytes.IndexByte(b, 0); i >= 0 {
+			b = b[:i]
+		}
+		if value := strings.TrimSpace(latin1(b)); value != "" {
+			metadata.Add(key, value)
+		}
+	}
+	field("TITLE", tail[3:33])
+	field("ARTIST", tail[33:63])
+	field("ALBUM", tail[63:93])
+	field("DATE", tail[93:97])
+
+	comment := tail[97:127]
+	if comment[28] == 0 && comment[29] != 0 {
+		metadata.Add("TRACKNUMBER", strconv.Itoa(int(comment[29])))
+		comment = comment[:28]
+	}
+	field("COMMENT", comment)
+
+	if genre := int(tail[127]); genre < len(id3Genres) {
+		metadata.Add("GENRE", id3Genres[genre])
+	}
+	return metadata
+}
+
+// removeUnsync undoes ID3 unsynchronisation, which inserts a zero byte after
+// every 0xFF so tag data can't be mistaken for an MPEG frame sync
+func removeUnsync(b []byte) []byte {
+	out := make([]byte, 0, len(b))
+	for i := 0; i < len(b); i++ {
+		out = append(out, b[i])
+		if b[i] == 0xFF && i+1 < len(b) && b[i+1] == 0 {
+			i++
+		}
+	}
+	return out
+}
+
+// syncsafe decodes a 28-bit integer stored seven bits per byte
+func syncsafe(b []byte) uint32 {
+	return uint32(b[0]&0x7F)<<21 | uint32(b[1]&0x7F)<<14 | uint32(b[2]&0x7F)<<7 | uint32(b[3]&0x7F)
+}
+
+// readID3Chunk adds the tags from the ID3v2 tag that fills a WAV or AIFF
+// chunk. A damaged tag is ignored, as tags never stop a file from playing.
+func readID3Chunk(r io.ReadSeeker, metadata Metadata) Metadata {
+	tags, err := readID3v2(r)
+	if err != nil || len(tags) == 0 {
+		return metadata
+	}
+	return mergeMetadata(metadata, tags)
+}
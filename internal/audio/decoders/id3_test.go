@@ -0,0 +1,297 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"unicode/utf16"
+)
+
+// testID3Frame builds a frame for the given tag version
+func testID3Frame(version byte, id string, flags uint16, data []byte) []byte {
+	var frame bytes.Buffer
+	frame.WriteString(id)
+	switch version {
+	case 2:
+		frame.Write([]byte{byte(len(data) >> 16), byte(len(data) >> 8), byte(len(data))})
+	case 3:
+		binary.Write(&frame, binary.BigEndian, uint32(len(data)))
+		binary.Write(&frame, binary.BigEndian, flags)
+	default:
+		frame.Write(syncsafeBytes(len(data)))
+		binary.Write(&frame, binary.BigEndian, flags)
+	}
+	frame.Write(data)
+	return frame.Bytes()
+}
+
+// testID3Tag wraps a tag body in an ID3v2 header
+func testID3Tag(version, flags byte, body []byte) []byte {
+	tag := append([]byte{'I', 'D', '3', version, 0, flags}, syncsafeBytes(len(body))...)
+	return append(tag, body...)
+}
+
+// latin1Text is a text frame body in ISO-8859-1
+func latin1Text(text string) []byte {
+	return append([]byte{0}, text...)
+}
+
+// utf16Text is a text frame body in UTF-16 with a little-endian byte order mark
+func utf16Text(values ...string) []byte {
+	data := []byte{1}
+	for i, value := range values {
+		if i > 0 {
+			data = append(data, 0, 0)
+		}
+		data = append(data, 0xFF, 0xFE)
+		for _, unit := range utf16.Encode([]rune(value)) {
+			data = binary.LittleEndian.AppendUint16(data, unit)
+		}
+	}
+	return data
+}
+
+// addUnsync inserts a zero byte after every 0xFF
+func addUnsync(b []byte) []byte {
+	var out []byte
+	for _, c := range b {
+		out = append(out, c)
+		if c == 0xFF {
+			out = append(out, 0)
+		}
+	}
+	return out
+}
+
+func TestReadID3_Versions(t *testing.T) {
+	// ID3v2.2: three-letter frames, numbered genre
+	v22 := testID3Tag(2, 0, bytes.Join([][]byte{
+		testID3Frame(2, "TT2", 0, latin1Text("Night Drive")),
+		testID3Frame(2, "TP1", 0, latin1Text("Kavinsky")),
+		testID3Frame(2, "TCO", 0, latin1Text("(52)")),
+		make([]byte, 16), // Padding
+	}, nil))
+
+	// ID3v2.3: UTF-16 text and a comment behind an extended header, with the
+	// whole tag unsynchronised
+	v23Body := bytes.Join([][]byte{
+		{0, 0, 0, 6, 0, 0, 0, 0, 0, 0}, // Extended header
+		testID3Frame(3, "TIT2", 0, utf16Text("Café del Mar")),
+		testID3Frame(3, "TPE1", 0, utf16Text("Energy 52")),
+		testID3Frame(3, "TBPM", 0, latin1Text("136")),
+		testID3Frame(3, "TKEY", 0, latin1Text("Am")),
+		testID3Frame(3, "TPUB", 0, latin1Text("Eye Q")),
+		testID3Frame(3, "COMM", 0, append([]byte{1, 'e', 'n', 'g'}, utf16Text("", "Three 'N One remix")[1:]...)),
+		testID3Frame(3, "COMM", 0, append([]byte{0, 'e', 'n', 'g'}, "iTunNORM\x00 000001F4"...)),
+	}, nil)
+	v23 := testID3Tag(3, 0xC0, addUnsync(v23Body))
+
+	// ID3v2.4: UTF-8 multi-value text, a frame with its own unsynchronisation
+	// and data length, and artwork that is skipped
+	title := []byte("\x00Sandstorm \xFF\xFE edit")
+	v24 := testID3Tag(4, 0x40, bytes.Join([][]byte{
+		{0, 0, 0, 6, 1, 0}, // Extended header
+		testID3Frame(4, "APIC", 0, make([]byte, 2048)),
+		testID3Frame(4, "TIT2", 0x0003, append(syncsafeBytes(len(title)), addUnsync(title)...)),
+		testID3Frame(4, "TPE1", 0, []byte("\x03Darude\x00JS16\x00")),
+		testID3Frame(4, "TCON", 0, []byte("\x03Trance")),
+		testID3Frame(4, "TALB", 0, []byte("\x03Before the Storm")),
+	}, nil))
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want Metadata
+	}{
+		{"ID3v2.2", v22, Metadata{
+			"TITLE":  {"Night Drive"},
+			"ARTIST": {"Kavinsky"},
+			"GENRE":  {"Electronic"},
+		}},
+		{"ID3v2.3", v23, Metadata{
+			"TITLE":   {"Café del Mar"},
+			"ARTIST":  {"Energy 52"},
+			"BPM":     {"136"},
+			"KEY":     {"Am"},
+			"LABEL":   {"Eye Q"},
+			"COMMENT": {"Three 'N One remix"},
+		}},
+		{"ID3v2.4", v24, Metadata{
+			"TITLE":  {"Sandstorm ÿþ edit"},
+			"ARTIST": {"Darude", "JS16"},
+			"GENRE":  {"Trance"},
+			"ALBUM":  {"Before the Storm"},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Trailing bytes stand in for the audio
+			file := append(append([]byte{}, tt.tag...), 0xFF, 0xFB, 0x90, 0x00)
+			got, err := ReadID3(bytes.NewReader(file))
+			if err != nil {
+				t.Fatalf("ReadID3() failed: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadID3() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadID3_OversizedTag(t *testing.T) {
+	// An unsynchronised ID3v2.3 tag whose header claims the largest size
+	tag := testID3Tag(3, 0x80, testID3Frame(3, "TIT2", 0, latin1Text("Pulse")))
+	copy(tag[6:10], []byte{0x7F, 0x7F, 0x7F, 0x7F})
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	got, err := readID3v2(bytes.NewReader(tag))
+	runtime.ReadMemStats(&after)
+
+	if err != nil {
+		t.Fatalf("readID3v2() failed: %v", err)
+	}
+	if want := (Metadata{"TITLE": {"Pulse"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("tags = %q, want %q", got, want)
+	}
+	if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+		t.Errorf("readID3v2() allocated %d bytes for a %d byte tag", allocated, len(tag))
+	}
+}
+
+func TestReadID3_V1(t *testing.T) {
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Old Title")
+	copy(v1[33:], "Daft Punk")
+	copy(v1[63:], "Discovery")
+	copy(v1[93:], "2001")
+	copy(v1[97:], "Ripped in 2003")
+	v1[126] = 1  // ID3v1.1 track number
+	v1[127] = 35 // House
+
+	v2 := testID3Tag(3, 0, testID3Frame(3, "TIT2", 0, latin1Text("One More Time")))
+	file := bytes.Join([][]byte{v2, make([]byte, 1000), v1}, nil)
+
+	got, err := ReadID3(bytes.NewReader(file))
+	if err != nil {
+		t.Fatalf("ReadID3() failed: %v", err)
+	}
+	want := Metadata{
+		"TITLE":       {"One More Time"}, // ID3v2 wins over ID3v1
+		"ARTIST":      {"Daft Punk"},
+		"ALBUM":       {"Discovery"},
+		"DATE":        {"2001"},
+		"COMMENT":     {"Ripped in 2003"},
+		"TRACKNUMBER": {"1"},
+		"GENRE":       {"House"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadID3() = %q, want %q", got, want)
+	}
+
+	if got, err := ReadID3(bytes.NewReader(make([]byte, 1000))); err != nil || len(got) != 0 {
+		t.Errorf("ReadID3() of an untagged file = %v, %v, want no tags", got, err)
+	}
+}
+
+func TestResolveID3Genre(t *testing.T) {
+	tests := map[string]string{
+		"17":          "Rock",
+		"(31)":        "Trance",
+		"(31)Uplift":  "Uplift",
+		"(RX)":        "Remix",
+		"Tech House":  "Tech House",
+		"(999)":       "(999)",
+		"189":         "Dubstep",
+		"Drum & Bass": "Drum & Bass",
+	}
+	for genre, want := range tests {
+		if got := resolveID3Genre(genre); got != want {
+			t.Errorf("resolveID3Genre(%q) = %q, want %q", genre, got, want)
+		}
+	}
+}
+
+func TestReadTags_Chunks(t *testing.T) {
+	tag := testID3Tag(4, 0, bytes.Join([][]byte{
+		testID3Frame(4, "TIT2", 0, []byte("\x03Strings of Life")),
+		testID3Frame(4, "TPE1", 0, []byte("\x03Rhythim Is Rhythim")),
+	}, nil))
+	want := Metadata{"TITLE": {"Strings of Life"}, "ARTIST": {"Rhythim Is Rhythim"}}
+
+	// The tags come after the audio, as most writers put them
+	chunk := func(id string, order binary.ByteOrder) []byte {
+		var b bytes.Buffer
+		b.WriteString(id)
+		binary.Write(&b, order, uint32(len(tag)))
+		b.Write(tag)
+		if len(tag)%2 == 1 {
+			b.WriteByte(0)
+		}
+		return b.Bytes()
+	}
+	wavSpec := testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 2, container: 2, encode: intEncoder(2, binary.LittleEndian)}
+	files := map[string][]byte{
+		"track.wav":  append(writeTestWAVFile(wavSpec, 101), chunk("id3 ", binary.LittleEndian)...),
+		"track.aiff": append(writeTestAIFF("", 2, 16, 100, intEncoder(2, binary.BigEndian)), chunk("ID3 ", binary.BigEndian)...),
+		"track.mp3":  append(append([]byte{}, tag...), 0xFF, 0xFB, 0x90, 0x00),
+	}
+
+	registry := NewFormatRegistry()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		got, err := registry.ReadTags(path)
+		if err != nil {
+			t.Errorf("ReadTags(%s) failed: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadTags(%s) = %q, want %q", name, got, want)
+		}
+
+		// ProbeTags gives the format along with the same tags
+		format, got, err := registry.ProbeTags(path)
+		if err != nil || format != registry.Probe(path) || !reflect.DeepEqual(got, want) {
+			t.Errorf("ProbeTags(%s) = %q, %q, %v, want %q and the tags", name, format, got, err, registry.Probe(path))
+		}
+	}
+
+	// Files that aren't audio have no format
+	notes := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(notes, []byte("tracklist"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if format, tags, err := registry.ProbeTags(notes); format != "" || tags != nil || err != nil {
+		t.Errorf("ProbeTags(notes.txt) = %q, %q, %v, want no format", format, tags, err)
+	}
+
+	// The decoders see the same tags, and still find the audio
+	streamer, _, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(files["track.wav"])})
+	if err != nil {
+		t.Fatalf("DecodeWAV() failed: %v", err)
+	}
+	if streamer.Len() != 101 {
+		t.Errorf("Len() = %d, want 101", streamer.Len())
+	}
+	if got := streamer.(MetadataStreamer).Metadata(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeWAV() metadata = %q, want %q", got, want)
+	}
+
+	streamer, _, err = DecodeAIFF(nopReadSeekCloser{bytes.NewReader(files["track.aiff"])})
+	if err != nil {
+		t.Fatalf("DecodeAIFF() failed: %v", err)
+	}
+	if got := streamer.(MetadataStreamer).Metadata(); !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodeAIFF() metadata = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,37 @@
+package decoders
+
+import "strings"
+
+// Metadata holds tag fields read from an audio file, keyed by upper-case
+// field name. A field may appear more than once (e.g. several ARTIST tags).
+type Metadata map[string][]string
+
+// Get returns the first value for a field, or "" if it is not present
+func (m Metadata) Get(key string) string {
+	if values := m[strings.ToUpper(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// Add appends a value for a field
+func (m Metadata) Add(key, value string) {
+	key = strings.ToUpper(key)
+	m[key] = append(m[key], value)
+}
+
+// MetadataStreamer is implemented by streamers that carry tag metadata
+type MetadataStreamer interface {
+	Metadata() Metadata
+}
+
+// mergeMetadata adds the fields of other to m, creating m if it is nil
+func mergeMetadata(m, other Metadata) Metadata {
+	if m == nil {
+		m = make(Metadata)
+	}
+	for key, values := range other {
+		m[key] = append(m[key], values...)
+	}
+	return m
+}
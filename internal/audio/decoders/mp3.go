@@ -1,6 +1,7 @@
 package decoders
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 
@@ -8,12 +9,283 @@ import (
 	"github.com/gopxl/beep/v2/mp3"
 )
 
-// DecodeMP3 decodes MP3 files using beep's MP3 decoder
+// mp3ScanSize is how much data is searched for the first frame after the
+// ID3v2 tag, and for a frame header after a seek
+const mp3ScanSize = 16 * 1024
+
+// mp3Streamer implements beep.StreamSeekCloser for MP3 files that start with
+// a Xing/Info or VBRI header. The header's frame count gives the exact length
+// and its table of contents the seek points, so the file never has to be
+// scanned frame by frame.
+type mp3Streamer struct {
+	file    io.ReadSeeker
+	decoder beep.StreamSeekCloser // beep decoder reading from the last seek point
+	info    *mp3VBRInfo
+	header  mpegFrameHeader // First frame's header, to recognise frames when resyncing
+
+	infoOffset int64 // File offset of the info frame
+	frameLen   int   // Samples per frame
+	skip       int   // Encoder and decoder delay trimmed from the start
+	total      int   // Playable samples, without delay and padding
+	position   int
+	discard    int // Decoded samples still to drop before position
+	metadata   Metadata
+	err        error
+}
+
+// taggedStreamer adds ID3 tags to beep's MP3 decoder
+type taggedStreamer struct {
+	beep.StreamSeekCloser
+	metadata Metadata
+}
+
+// Metadata returns the file's ID3 tags
+func (s *taggedStreamer) Metadata() Metadata {
+	return s.metadata
+}
+
+// readerOnly hides Seek from go-mp3, which otherwise reads every frame
+// header in the file up front to build its own seek index
+type readerOnly struct {
+	io.Reader
+}
+
+// DecodeMP3 decodes MP3 files. When the first frame is a Xing/Info or VBRI
+// header, the length and seek points come from it and the LAME encoder delay
+// and padding are trimmed; other files use beep's MP3 decoder as-is. ID3
+// tags are available through the streamer's Metadata method.
 func DecodeMP3(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+	var metadata Metadata
+	if file, ok := r.(io.ReadSeeker); ok {
+		// Tags are optional, so a file whose tags can't be read still plays
+		metadata, _ = ReadID3(file)
+
+		streamer, format, err := decodeVBRMP3(file)
+		if err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to decode MP3: %w", err)
+		}
+		if streamer != nil {
+			streamer.metadata = metadata
+			return streamer, format, nil
+		}
+		if _, err := file.Seek(0, io.SeekStart); err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to decode MP3: %w", err)
+		}
+	}
+
 	streamer, format, err := mp3.Decode(r)
 	if err != nil {
 		return nil, beep.Format{}, fmt.Errorf("failed to decode MP3: %w", err)
 	}
+	if metadata != nil {
+		return &taggedStreamer{StreamSeekCloser: streamer, metadata: metadata}, format, nil
+	}
 
 	return streamer, format, nil
-}
\ No newline at end of file
+}
+
+// decodeVBRMP3 returns a streamer driven by the file's info frame, or nil if
+// the first frame is a plain audio frame
+func decodeVBRMP3(file io.ReadSeeker) (*mp3Streamer, beep.Format, error) {
+	start, err := id3v2Size(file)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	buf, err := readAt(file, start, mp3ScanSize)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	i := findMPEGFrame(buf, nil)
+	if i < 0 {
+		return nil, beep.Format{}, nil
+	}
+	header, _ := parseMPEGFrameHeader(buf[i:])
+	info := parseMP3VBRInfo(buf[i:], header)
+	if info == nil || info.frames == 0 {
+		return nil, beep.Format{}, nil
+	}
+
+	if info.bytes == 0 {
+		size, err := file.Seek(0, io.SeekEnd)
+		if err != nil {
+			return nil, beep.Format{}, err
+		}
+		info.bytes = int(size - start - int64(i))
+	}
+
+	s := &mp3Streamer{
+		file:       file,
+		info:       info,
+		header:     header,
+		infoOffset: start + int64(i),
+		frameLen:   header.samplesPerFrame(),
+	}
+	s.total = info.frames * s.frameLen
+	if info.gapless {
+		s.skip = info.encoderDelay + mp3DecoderDelay
+		s.total -= info.encoderDelay + info.encoderPadding
+	}
+	if s.total <= 0 {
+		return nil, beep.Format{}, nil
+	}
+
+	format, err := s.seekTo(0)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+	return s, format, nil
+}
+
+// id3v2Size returns the size of the ID3v2 tag at the start of the file, or 0
+func id3v2Size(file io.ReadSeeker) (int64, error) {
+	tag, err := readAt(file, 0, 10)
+	if err != nil {
+		return 0, err
+	}
+	if len(tag) < 10 || !bytes.HasPrefix(tag, []byte("ID3")) {
+		return 0, nil
+	}
+
+	size := int64(tag[6])<<21 | int64(tag[7])<<14 | int64(tag[8])<<7 | int64(tag[9])
+	size += 10
+	if tag[5]&0x10 != 0 {
+		size += 10 // Footer
+	}
+	return size, nil
+}
+
+// readAt reads up to n bytes from offset
+func readAt(file io.ReadSeeker, offset int64, n int) ([]byte, error) {
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, n)
+	read, err := io.ReadFull(file, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+// seekTo restarts decoding so the next sample streamed is sample p
+func (s *mp3Streamer) seekTo(p int) (beep.Format, error) {
+	s.position = p
+	s.decoder = nil
+	if p >= s.total {
+		return beep.Format{}, nil
+	}
+
+	// Start a frame early: the bit reservoir lets a frame borrow data from
+	// the ones before it, so the first frame after a jump decodes badly
+	target := p + s.skip
+	frame := max(target/s.frameLen-1, 0)
+	offset, landed := s.info.seekPoint(frame)
+	offset = max(offset, int64(s.info.frameSize))
+
+	// Table offsets are estimates, so find the next real frame header
+	pos := s.infoOffset + offset
+	buf, err := readAt(s.file, pos, mp3ScanSize)
+	if err != nil {
+		return beep.Format{}, err
+	}
+	if i := findMPEGFrame(buf, &s.header); i > 0 {
+		pos += int64(i)
+	}
+
+	if _, err := s.file.Seek(pos, io.SeekStart); err != nil {
+		return beep.Format{}, err
+	}
+	decoder, format, err := mp3.Decode(io.NopCloser(readerOnly{s.file}))
+	if err != nil {
+		return beep.Format{}, err
+	}
+
+	s.decoder = decoder
+	s.discard = target - landed*s.frameLen
+	return format, nil
+}
+
+func (s *mp3Streamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.err != nil || s.decoder == nil {
+		return 0, false
+	}
+
+	// Drop the encoder delay, or the lead-in decoded after a seek
+	for s.discard > 0 {
+		dropped, ok := s.decoder.Stream(samples[:min(len(samples), s.discard)])
+		s.discard -= dropped
+		if !ok {
+			s.err = s.decoder.Err()
+			return 0, false
+		}
+	}
+
+	// Stop before the encoder padding
+	samples = samples[:min(len(samples), s.total-s.position)]
+	if len(samples) == 0 {
+		return 0, false
+	}
+
+	n, ok = s.decoder.Stream(samples)
+	s.position += n
+	if !ok {
+		s.err = s.decoder.Err()
+	}
+	return n, ok
+}
+
+func (s *mp3Streamer) Err() error {
+	return s.err
+}
+
+func (s *mp3Streamer) Len() int {
+	return s.total
+}
+
+func (s *mp3Streamer) Position() int {
+	return s.position
+}
+
+func (s *mp3Streamer) Seek(p int) error {
+	if p < 0 || p > s.total {
+		return fmt.Errorf("seek position out of range: %d (total: %d)", p, s.total)
+	}
+
+	if _, err := s.seekTo(p); err != nil {
+		return fmt.Errorf("failed to seek in MP3 file: %w", err)
+	}
+	s.err = nil
+	return nil
+}
+
+// Metadata returns the file's ID3 tags, if any
+func (s *mp3Streamer) Metadata() Metadata {
+	return s.metadata
+}
+
+func (s *mp3Streamer) Close() error {
+	// The file itself is closed by the player
+	return nil
+}
+
+// SniffMP3 reports whether header starts with an ID3v2 tag or an MPEG audio frame
+func SniffMP3(header []byte) bool {
+	if bytes.HasPrefix(header, []byte("ID3")) {
+		return true
+	}
+	if len(header) < 4 {
+		return false
+	}
+
+	// 11-bit frame sync, then reject the reserved version, layer, bitrate
+	// and sample rate values so arbitrary binary data isn't mistaken for MP3
+	if header[0] != 0xFF || header[1]&0xE0 != 0xE0 {
+		return false
+	}
+	version := (header[1] >> 3) & 0x03
+	layer := (header[1] >> 1) & 0x03
+	bitrate := header[2] >> 4
+	sampleRate := (header[2] >> 2) & 0x03
+	return version != 1 && layer != 0 && bitrate != 0x0F && sampleRate != 0x03
+}
@@ -0,0 +1,192 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/mp3"
+)
+
+// streamAll drains a streamer and returns every sample it produced
+func streamAll(s beep.Streamer) [][2]float64 {
+	var out [][2]float64
+	buf := make([][2]float64, 512)
+	for {
+		n, ok := s.Stream(buf)
+		out = append(out, buf[:n]...)
+		if !ok {
+			return out
+		}
+	}
+}
+
+func TestDecodeMP3_GaplessLength(t *testing.T) {
+	// Half a second at 44.1kHz, encoded with an Info frame and a Lavc
+	// extension carrying 576 samples of delay and 1566 of padding
+	file, err := os.Open("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	streamer, format, err := NewFormatRegistry().Decode("testdata/test.mp3", file)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	defer streamer.Close()
+
+	if format.SampleRate != 44100 {
+		t.Errorf("SampleRate = %d, want 44100", format.SampleRate)
+	}
+	if streamer.Len() != 22050 {
+		t.Errorf("Len() = %d, want 22050", streamer.Len())
+	}
+
+	samples := streamAll(streamer)
+	if len(samples) != 22050 {
+		t.Fatalf("Streamed %d samples, want 22050", len(samples))
+	}
+
+	// The output must be the raw decode minus the info frame, the encoder
+	// delay and the decoder delay
+	raw, err := os.Open("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	rawStreamer, _, err := mp3.Decode(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawSamples := streamAll(rawStreamer)
+
+	offset := 1152 + 576 + mp3DecoderDelay
+	for i, sample := range samples {
+		if sample != rawSamples[offset+i] {
+			t.Fatalf("Sample %d = %v, want %v", i, sample, rawSamples[offset+i])
+		}
+	}
+}
+
+func TestDecodeMP3_Seek(t *testing.T) {
+	file, err := os.Open("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	streamer, _, err := DecodeMP3(file)
+	if err != nil {
+		t.Fatalf("DecodeMP3() failed: %v", err)
+	}
+
+	if err := streamer.Seek(10000); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	if streamer.Position() != 10000 {
+		t.Errorf("Position() after Seek = %d, want 10000", streamer.Position())
+	}
+
+	// TOC seek points are approximate, so allow a frame either way
+	count := len(streamAll(streamer))
+	if want := 22050 - 10000; count < want-1152 || count > want+1152 {
+		t.Errorf("Streamed %d samples after seek, want about %d", count, want)
+	}
+	if streamer.Err() != nil {
+		t.Errorf("Err() = %v", streamer.Err())
+	}
+
+	if err := streamer.Seek(0); err != nil {
+		t.Fatalf("Seek(0) failed: %v", err)
+	}
+	if count := len(streamAll(streamer)); count != 22050 {
+		t.Errorf("Streamed %d samples after rewinding, want 22050", count)
+	}
+
+	if err := streamer.Seek(22051); err == nil {
+		t.Error("Seek() past the end should fail")
+	}
+}
+
+func TestParseMP3VBRInfo_Xing(t *testing.T) {
+	// MPEG-1 Layer III, 128 kbit/s, 44.1kHz, joint stereo
+	header := []byte{0xFF, 0xFB, 0x90, 0x64}
+	h, ok := parseMPEGFrameHeader(header)
+	if !ok {
+		t.Fatal("parseMPEGFrameHeader() rejected a valid header")
+	}
+	if h.frameSize() != 417 || h.samplesPerFrame() != 1152 {
+		t.Errorf("frameSize() = %d, samplesPerFrame() = %d, want 417 and 1152", h.frameSize(), h.samplesPerFrame())
+	}
+
+	frame := make([]byte, h.frameSize())
+	copy(frame, header)
+	xing := frame[4+32:]
+	copy(xing, "Xing")
+	binary.BigEndian.PutUint32(xing[4:], 0x07) // Frames, bytes and TOC
+	binary.BigEndian.PutUint32(xing[8:], 1000)
+	binary.BigEndian.PutUint32(xing[12:], 400000)
+	for i := 0; i < 100; i++ {
+		xing[16+i] = byte(i * 256 / 100)
+	}
+	lame := xing[116:]
+	copy(lame, "LAME3.100")
+	lame[21], lame[22], lame[23] = 0x24, 0x00, 0x7C // Delay 576, padding 124
+
+	info := parseMP3VBRInfo(frame, h)
+	if info == nil {
+		t.Fatal("parseMP3VBRInfo() found no Xing header")
+	}
+	if info.frames != 1000 || info.bytes != 400000 {
+		t.Errorf("frames = %d, bytes = %d, want 1000 and 400000", info.frames, info.bytes)
+	}
+	if !info.gapless || info.encoderDelay != 576 || info.encoderPadding != 124 {
+		t.Errorf("gapless = %v, delay = %d, padding = %d, want true, 576, 124", info.gapless, info.encoderDelay, info.encoderPadding)
+	}
+
+	// Halfway through the frames is halfway through the bytes
+	offset, landed := info.seekPoint(500)
+	if landed != 500 || offset < 199000 || offset > 201000 {
+		t.Errorf("seekPoint(500) = %d, %d, want about 200000, 500", offset, landed)
+	}
+
+	// A plain audio frame has no info
+	if parseMP3VBRInfo(append(header, make([]byte, 413)...), h) != nil {
+		t.Error("parseMP3VBRInfo() should return nil for an audio frame")
+	}
+}
+
+func TestParseMP3VBRInfo_VBRI(t *testing.T) {
+	header := []byte{0xFF, 0xFB, 0x90, 0x64}
+	h, _ := parseMPEGFrameHeader(header)
+
+	frame := make([]byte, h.frameSize())
+	copy(frame, header)
+	vbri := frame[36:]
+	copy(vbri, "VBRI")
+	binary.BigEndian.PutUint32(vbri[10:], 30000) // Bytes
+	binary.BigEndian.PutUint32(vbri[14:], 100)   // Frames
+	binary.BigEndian.PutUint16(vbri[18:], 4)     // TOC entries
+	binary.BigEndian.PutUint16(vbri[20:], 2)     // Scale
+	binary.BigEndian.PutUint16(vbri[22:], 2)     // Entry size
+	binary.BigEndian.PutUint16(vbri[24:], 25)    // Frames per entry
+	for i, size := range []uint16{3000, 4000, 3500, 4500} {
+		binary.BigEndian.PutUint16(vbri[26+i*2:], size)
+	}
+
+	info := parseMP3VBRInfo(frame, h)
+	if info == nil {
+		t.Fatal("parseMP3VBRInfo() found no VBRI header")
+	}
+	if info.frames != 100 || info.gapless {
+		t.Errorf("frames = %d, gapless = %v, want 100 and false", info.frames, info.gapless)
+	}
+
+	// Frame 60 is in the third run of 25 frames, after 7000*2 bytes
+	offset, landed := info.seekPoint(60)
+	if landed != 50 || offset != int64(h.frameSize())+14000 {
+		t.Errorf("seekPoint(60) = %d, %d, want %d, 50", offset, landed, h.frameSize()+14000)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !unix
+
+package decoders
+
+import "os"
+
+// fileOwner reports no owner on platforms without Unix file ownership
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
@@ -0,0 +1,220 @@
+package decoders
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+const (
+	// Buffer size for streaming uncompressed data (in samples per channel)
+	// This determines how much data we keep in memory at once
+	pcmBufferSize = 4096 // ~93ms at 44.1kHz, uses ~64KB for stereo float64
+)
+
+// pcmEncoding describes how uncompressed samples are stored
+type pcmEncoding struct {
+	bytesPerSample int
+	littleEndian   bool
+	float          bool
+	unsigned       bool
+}
+
+// decode converts the sample at the start of b to the range [-1, 1]
+func (e pcmEncoding) decode(b []byte) float64 {
+	size := e.bytesPerSample
+
+	if e.float {
+		var order binary.ByteOrder = binary.BigEndian
+		if e.littleEndian {
+			order = binary.LittleEndian
+		}
+		if size == 4 {
+			return float64(math.Float32frombits(order.Uint32(b)))
+		}
+		return math.Float64frombits(order.Uint64(b))
+	}
+
+	// Assemble the integer most significant byte first
+	var v uint32
+	for i := 0; i < size; i++ {
+		idx := i
+		if e.littleEndian {
+			idx = size - 1 - i
+		}
+		v = v<<8 | uint32(b[idx])
+	}
+
+	bits := uint(size * 8)
+	if e.unsigned {
+		half := float64(uint32(1) << (bits - 1))
+		return (float64(v) - half) / half
+	}
+
+	// Sign-extend by shifting into the top of an int32. Samples narrower than
+	// their container are left-justified, so this also scales them correctly.
+	signed := int32(v << (32 - bits))
+	return float64(signed) / float64(1<<31)
+}
+
+// pcmStreamer implements beep.StreamSeekCloser for uncompressed sample frames
+// stored contiguously in a file. The WAV and AIFF decoders only differ in how
+// they locate the data, so both stream through this.
+type pcmStreamer struct {
+	reader      io.ReadSeeker
+	encoding    pcmEncoding
+	numChannels int
+	weights     [][2]float64 // Stereo mix weights; nil takes the first two channels
+	metadata    Metadata
+
+	dataStart int64 // Offset of the first sample frame
+	numFrames int
+	frameSize int // Bytes per sample frame (all channels)
+
+	// Streaming buffers
+	rawBuffer    []byte       // Undecoded sample frames read from the file
+	sampleBuffer [][2]float64 // Converted samples ready for playback
+	bufferPos    int          // Next sample to stream from sampleBuffer
+	bufferLen    int          // Number of valid samples in sampleBuffer
+	position     int          // Absolute position of the next sample
+	err          error
+}
+
+// newPCMStreamer creates a streamer for numFrames frames starting at dataStart.
+// frameSize may exceed the packed sample size when frames are padded.
+func newPCMStreamer(r io.ReadSeeker, encoding pcmEncoding, numChannels, frameSize int, dataStart int64, numFrames int) (*pcmStreamer, error) {
+	if frameSize < encoding.bytesPerSample*numChannels {
+		return nil, fmt.Errorf("invalid frame size %d for %d channels", frameSize, numChannels)
+	}
+
+	if _, err := r.Seek(dataStart, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to seek to sample data: %w", err)
+	}
+
+	return &pcmStreamer{
+		reader:       r,
+		encoding:     encoding,
+		numChannels:  numChannels,
+		dataStart:    dataStart,
+		numFrames:    numFrames,
+		frameSize:    frameSize,
+		rawBuffer:    make([]byte, pcmBufferSize*frameSize),
+		sampleBuffer: make([][2]float64, pcmBufferSize),
+	}, nil
+}
+
+// fillBuffer reads a chunk of audio data from the file and converts it to float64 samples
+func (s *pcmStreamer) fillBuffer() error {
+	framesToRead := s.numFrames - s.position
+	if framesToRead > pcmBufferSize {
+		framesToRead = pcmBufferSize
+	}
+	if framesToRead <= 0 {
+		return io.EOF
+	}
+
+	raw := s.rawBuffer[:framesToRead*s.frameSize]
+	n, err := io.ReadFull(s.reader, raw)
+	frames := n / s.frameSize
+	if frames == 0 {
+		if err == nil || err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+		return err
+	}
+
+	size := s.encoding.bytesPerSample
+	for i := 0; i < frames; i++ {
+		frame := raw[i*s.frameSize:]
+
+		if s.weights != nil {
+			var l, r float64
+			for ch, w := range s.weights {
+				v := s.encoding.decode(frame[ch*size:])
+				l += v * w[0]
+				r += v * w[1]
+			}
+			s.sampleBuffer[i] = [2]float64{l, r}
+			continue
+		}
+
+		left := s.encoding.decode(frame)
+		right := left
+		if s.numChannels >= 2 {
+			right = s.encoding.decode(frame[size:])
+		}
+		s.sampleBuffer[i] = [2]float64{left, right}
+	}
+
+	s.bufferPos = 0
+	s.bufferLen = frames
+	return nil
+}
+
+func (s *pcmStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.err != nil {
+		return 0, false
+	}
+
+	for n < len(samples) {
+		if s.bufferPos >= s.bufferLen {
+			if err := s.fillBuffer(); err != nil {
+				if err != io.EOF {
+					s.err = fmt.Errorf("failed to read sample data: %w", err)
+				}
+				break
+			}
+		}
+
+		toCopy := copy(samples[n:], s.sampleBuffer[s.bufferPos:s.bufferLen])
+		s.bufferPos += toCopy
+		s.position += toCopy
+		n += toCopy
+	}
+
+	return n, n > 0
+}
+
+func (s *pcmStreamer) Err() error {
+	return s.err
+}
+
+func (s *pcmStreamer) Len() int {
+	return s.numFrames
+}
+
+func (s *pcmStreamer) Position() int {
+	return s.position
+}
+
+func (s *pcmStreamer) Seek(p int) error {
+	if p < 0 || p > s.numFrames {
+		return fmt.Errorf("seek position out of range: %d (total: %d)", p, s.numFrames)
+	}
+
+	bytePos := s.dataStart + int64(p)*int64(s.frameSize)
+	if _, err := s.reader.Seek(bytePos, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek in audio file: %w", err)
+	}
+
+	// Force buffer refill on next Stream call
+	s.position = p
+	s.bufferPos = 0
+	s.bufferLen = 0
+	s.err = nil
+
+	return nil
+}
+
+// Metadata returns tags read from the file's chunks, if any
+func (s *pcmStreamer) Metadata() Metadata {
+	return s.metadata
+}
+
+func (s *pcmStreamer) Close() error {
+	// Clean up buffers to free memory; the file itself is closed by the player
+	s.rawBuffer = nil
+	s.sampleBuffer = nil
+	return nil
+}
@@ -3,60 +3,310 @@ package decoders
 import (
 	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/gopxl/beep/v2"
 )
 
+// sniffSize is how much of the file is inspected for content detection
+const sniffSize = 512
+
 // DecoderFunc represents a function that can decode audio files
 type DecoderFunc func(io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error)
 
+// SniffFunc reports whether the start of a file looks like a given format
+type SniffFunc func(header []byte) bool
+
+// TagFunc reads the tags of a file without decoding its audio
+type TagFunc func(io.ReadSeeker) (Metadata, error)
+
+// audioFormat describes one registered audio format
+type audioFormat struct {
+	name      string
+	decoder   DecoderFunc
+	sniff     SniffFunc
+	tags      TagFunc
+	writeTags TagWriteFunc
+}
+
 // FormatRegistry manages audio format decoders
 type FormatRegistry struct {
-	decoders map[string]DecoderFunc
+	formats    []*audioFormat
+	extensions map[string]*audioFormat
 }
 
 // NewFormatRegistry creates a new format registry with default decoders
 func NewFormatRegistry() *FormatRegistry {
 	registry := &FormatRegistry{
-		decoders: make(map[string]DecoderFunc),
+		extensions: make(map[string]*audioFormat),
 	}
 
-	// Register default decoders
-	registry.RegisterDecoder(".mp3", DecodeMP3)
-	registry.RegisterDecoder(".wav", DecodeWAV)
-	registry.RegisterDecoder(".aiff", DecodeAIFF)
-	registry.RegisterDecoder(".aif", DecodeAIFF)
+	// Register default decoders. Order matters for sniffing: container
+	// signatures are checked before MP3, whose frame sync is the loosest match.
+	registry.RegisterFormat("wav", []string{".wav"}, SniffWAV, DecodeWAV)
+	registry.RegisterFormat("aiff", []string{".aiff", ".aif"}, SniffAIFF, DecodeAIFF)
+	registry.RegisterFormat("flac", []string{".flac"}, SniffFLAC, DecodeFLAC)
+	registry.RegisterFormat("vorbis", []string{".ogg", ".oga"}, SniffVorbis, DecodeVorbis)
+	registry.RegisterFormat("mp3", []string{".mp3"}, SniffMP3, DecodeMP3)
+
+	registry.RegisterTagReader("wav", readWAVTags)
+	registry.RegisterTagReader("aiff", readAIFFTags)
+	registry.RegisterTagReader("mp3", ReadID3)
+
+	registry.RegisterTagWriter("wav", writeWAVTags)
+	registry.RegisterTagWriter("aiff", writeAIFFTags)
+	registry.RegisterTagWriter("mp3", writeMP3Tags)
 
 	return registry
 }
 
-// RegisterDecoder registers a decoder for a file extension
+// RegisterFormat registers a decoder for an audio format with its file extensions
+// and content signature. sniff may be nil for formats that can't be detected by content.
+func (r *FormatRegistry) RegisterFormat(name string, exts []string, sniff SniffFunc, decoder DecoderFunc) {
+	format := &audioFormat{
+		name:    name,
+		decoder: decoder,
+		sniff:   sniff,
+	}
+
+	r.formats = append(r.formats, format)
+	for _, ext := range exts {
+		r.extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// RegisterDecoder registers a decoder for a file extension, without a content signature
 func (r *FormatRegistry) RegisterDecoder(ext string, decoder DecoderFunc) {
-	r.decoders[ext] = decoder
+	r.RegisterFormat(strings.TrimPrefix(strings.ToLower(ext), "."), []string{ext}, nil, decoder)
+}
+
+// RegisterTagReader sets how tags are read for a registered format
+func (r *FormatRegistry) RegisterTagReader(name string, tags TagFunc) {
+	for _, format := range r.formats {
+		if format.name == name {
+			format.tags = tags
+		}
+	}
+}
+
+// RegisterTagWriter sets how tags are written for a registered format
+func (r *FormatRegistry) RegisterTagWriter(name string, write TagWriteFunc) {
+	for _, format := range r.formats {
+		if format.name == name {
+			format.writeTags = write
+		}
+	}
 }
 
-// Decode decodes an audio file based on its extension
+// Decode decodes an audio file, detecting its format from content and
+// falling back to the extension
 func (r *FormatRegistry) Decode(filePath string, file io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
-	ext := getFileExtension(filePath)
+	var header []byte
 
-	decoder, exists := r.decoders[ext]
-	if !exists {
-		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", ext)
+	// Decoders read from the start, so only sniff files that can be rewound
+	if seeker, ok := file.(io.ReadSeeker); ok {
+		var err error
+		header, err = readHeader(seeker)
+		if err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to read audio file: %w", err)
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return nil, beep.Format{}, fmt.Errorf("failed to read audio file: %w", err)
+		}
+	}
+
+	format := r.detect(filePath, header)
+	if format == nil {
+		return nil, beep.Format{}, fmt.Errorf("unsupported audio format: %s", filepath.Base(filePath))
+	}
+
+	return format.decoder(file)
+}
+
+// Probe opens a file and returns the name of its audio format, or an empty
+// string if it can't be read or isn't a supported format
+func (r *FormatRegistry) Probe(filePath string) string {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer file.Close()
+
+	header, err := readHeader(file)
+	if err != nil {
+		return ""
 	}
 
-	return decoder(file)
+	return r.Detect(filePath, header)
+}
+
+// ReadTags returns the tags of an audio file, detecting its format the same
+// way Decode does. It returns nil if the format has no tag reader or the
+// file has no tags.
+func (r *FormatRegistry) ReadTags(filePath string) (Metadata, error) {
+	_, tags, err := r.ProbeTags(filePath)
+	return tags, err
+}
+
+// ProbeTags returns a file's format name, as Probe does, and its tags, as
+// ReadTags does, opening the file once. The format is empty if the file
+// isn't a supported format.
+func (r *FormatRegistry) ProbeTags(filePath string) (string, Metadata, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return "", nil, err
+	}
+	defer file.Close()
+
+	header, err := readHeader(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	format := r.detect(filePath, header)
+	if format == nil {
+		return "", nil, nil
+	}
+	if format.tags == nil {
+		return format.name, nil, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return format.name, nil, fmt.Errorf("failed to read audio file: %w", err)
+	}
+	tags, err := format.tags(file)
+	return format.name, tags, err
+}
+
+// WriteTags changes the tags of an audio file. The new file is written next
+// to the old one and renamed over it, so the file is never left half
+// written, and a player that has the old file open carries on reading it.
+func (r *FormatRegistry) WriteTags(filePath string, changes TagChanges) error {
+	// Replace the file a symlink points to, not the link itself
+	filePath, err := filepath.EvalSymlinks(filePath)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	header, err := readHeader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read audio file: %w", err)
+	}
+	format := r.detect(filePath, header)
+	if format == nil {
+		return fmt.Errorf("unsupported audio format: %s", filepath.Base(filePath))
+	}
+	if format.writeTags == nil {
+		return fmt.Errorf("writing tags to %s files is not supported", format.name)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read audio file: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filePath), "."+filepath.Base(filePath)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if err := format.writeTags(file, tmp, changes); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, stat.Mode().Perm()); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	// Keeping the owner needs privileges unless it is already us, so a
+	// failure leaves the copy owned by the daemon's user
+	if uid, gid, ok := fileOwner(stat); ok {
+		os.Chown(tmpPath, uid, gid)
+	}
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// Detect returns the name of the audio format for the given header and
+// file path, or an empty string if the format is not recognised
+func (r *FormatRegistry) Detect(filePath string, header []byte) string {
+	if format := r.detect(filePath, header); format != nil {
+		return format.name
+	}
+	return ""
+}
+
+func (r *FormatRegistry) detect(filePath string, header []byte) *audioFormat {
+	for _, f := range r.formats {
+		if f.sniff != nil && f.sniff(header) {
+			return f
+		}
+	}
+	return r.extensions[getFileExtension(filePath)]
+}
+
+// IsSupported reports whether a decoder is registered for the file's extension.
+// Use Probe to also recognise files by content.
+func (r *FormatRegistry) IsSupported(filePath string) bool {
+	_, exists := r.extensions[getFileExtension(filePath)]
+	return exists
 }
 
 // GetSupportedExtensions returns a list of supported file extensions
 func (r *FormatRegistry) GetSupportedExtensions() []string {
-	extensions := make([]string, 0, len(r.decoders))
-	for ext := range r.decoders {
+	extensions := make([]string, 0, len(r.extensions))
+	for ext := range r.extensions {
 		extensions = append(extensions, ext)
 	}
 	return extensions
 }
 
+// readHeader reads up to sniffSize bytes from the start of a file. A
+// leading ID3v2 tag is skipped, as FLAC and other files sometimes carry one,
+// so the bytes sniffed are those of the format's own header. MP3 files are
+// still recognised by the frame that follows the tag.
+func readHeader(r io.ReadSeeker) ([]byte, error) {
+	header, err := readAt(r, 0, sniffSize)
+	if err != nil {
+		return nil, err
+	}
+
+	tagSize, err := id3v2Size(r)
+	if err != nil || tagSize == 0 {
+		return header, err
+	}
+	after, err := readAt(r, tagSize, sniffSize)
+	if err != nil || len(after) == 0 {
+		// Nothing follows the tag, so it is all there is to sniff
+		return header, err
+	}
+	return after, nil
+}
+
 // getFileExtension returns the lowercase file extension
 func getFileExtension(filePath string) string {
 	for i := len(filePath) - 1; i >= 0; i-- {
@@ -68,4 +318,4 @@ func getFileExtension(filePath string) string {
 		}
 	}
 	return ""
-}
\ No newline at end of file
+}
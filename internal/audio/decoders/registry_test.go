@@ -0,0 +1,155 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testWAV builds a 16-bit stereo PCM WAV file holding the given number of silent samples
+func testWAV(samples int) []byte {
+	dataSize := samples * 4
+
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	binary.Write(&buf, binary.LittleEndian, uint32(36+dataSize))
+	buf.WriteString("WAVEfmt ")
+	binary.Write(&buf, binary.LittleEndian, uint32(16))
+	binary.Write(&buf, binary.LittleEndian, uint16(1))      // PCM
+	binary.Write(&buf, binary.LittleEndian, uint16(2))      // Channels
+	binary.Write(&buf, binary.LittleEndian, uint32(44100))  // Sample rate
+	binary.Write(&buf, binary.LittleEndian, uint32(176400)) // Byte rate
+	binary.Write(&buf, binary.LittleEndian, uint16(4))      // Block align
+	binary.Write(&buf, binary.LittleEndian, uint16(16))     // Bits per sample
+	buf.WriteString("data")
+	binary.Write(&buf, binary.LittleEndian, uint32(dataSize))
+	buf.Write(make([]byte, dataSize))
+	return buf.Bytes()
+}
+
+func TestFormatRegistry_Detect(t *testing.T) {
+	registry := NewFormatRegistry()
+
+	oggVorbis := append([]byte("OggS\x00\x02"), make([]byte, 20)...)
+	oggVorbis = append(oggVorbis, 1, 30) // One 30-byte segment
+	oggVorbis = append(oggVorbis, "\x01vorbis"...)
+	oggOpus := append(append([]byte{}, oggVorbis[:28]...), "OpusHead"...)
+
+	tests := []struct {
+		name     string
+		path     string
+		header   []byte
+		expected string
+	}{
+		{"wav", "track.wav", testWAV(1), "wav"},
+		{"mislabeled wav", "track.mp3", testWAV(1), "wav"},
+		{"aiff without extension", "track", []byte("FORM\x00\x00\x00\x00AIFF"), "aiff"},
+		{"aiff-c", "track.aif", []byte("FORM\x00\x00\x00\x00AIFC"), "aiff"},
+		{"flac", "track.bin", []byte("fLaC\x00\x00\x00\x22"), "flac"},
+		{"ogg vorbis", "track", oggVorbis, "vorbis"},
+		{"ogg opus falls back to extension", "track.ogg", oggOpus, "vorbis"},
+		{"ogg opus without extension", "track", oggOpus, ""},
+		{"id3 tagged mp3", "track", []byte("ID3\x04\x00\x00"), "mp3"},
+		{"mpeg frame sync", "track", []byte{0xFF, 0xFB, 0x90, 0x64}, "mp3"},
+		{"jpeg is not mp3", "cover", []byte{0xFF, 0xD8, 0xFF, 0xE0}, ""},
+		{"empty file by extension", "track.flac", nil, "flac"},
+		{"unknown", "notes.txt", []byte("hello"), ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.Detect(tt.path, tt.header); got != tt.expected {
+				t.Errorf("Detect(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatRegistry_DecodeMislabeledFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "really-a-wav.mp3")
+	if err := os.WriteFile(path, testWAV(1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewFormatRegistry()
+	if got := registry.Probe(path); got != "wav" {
+		t.Errorf("Probe() = %q, want %q", got, "wav")
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	streamer, format, err := registry.Decode(path, file)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	defer streamer.Close()
+
+	// The header read for sniffing must not be lost to the decoder
+	if format.SampleRate != 44100 || streamer.Len() != 1000 {
+		t.Errorf("Decoded %d samples at %d Hz, want 1000 at 44100 Hz", streamer.Len(), format.SampleRate)
+	}
+}
+
+func TestFormatRegistry_ProbeID3Prefixed(t *testing.T) {
+	flacData, err := os.ReadFile(writeTestFLAC(t, 2, 16, 1024, 4096, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mp3Data, err := os.ReadFile("testdata/test.mp3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A tag longer than the sniffed header, as cover art makes it
+	tag := append([]byte("ID3\x04\x00\x00"), syncsafeBytes(2000)...)
+	tag = append(tag, make([]byte, 2000)...)
+
+	dir := t.TempDir()
+	registry := NewFormatRegistry()
+	tests := []struct {
+		name     string
+		data     []byte
+		expected string
+	}{
+		{"flac", append(tag, flacData...), "flac"},
+		{"mp3", mp3Data, "mp3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// No extension, so only the content decides
+			path := filepath.Join(dir, tt.name)
+			if err := os.WriteFile(path, tt.data, 0644); err != nil {
+				t.Fatal(err)
+			}
+			if got := registry.Probe(path); got != tt.expected {
+				t.Errorf("Probe() = %q, want %q", got, tt.expected)
+			}
+
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			streamer, _, err := registry.Decode(path, file)
+			if err != nil {
+				t.Fatalf("Decode() failed: %v", err)
+			}
+			streamer.Close()
+		})
+	}
+}
+
+func TestFormatRegistry_DecodeUnsupported(t *testing.T) {
+	_, _, err := NewFormatRegistry().Decode("notes.txt", io.NopCloser(bytes.NewReader([]byte("hello"))))
+	if err == nil {
+		t.Error("Decode() should fail for an unrecognised file")
+	}
+}
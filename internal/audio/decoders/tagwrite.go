@@ -0,0 +1,564 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// TagChanges describes edits to a file's tags. Nil fields are left as they are.
+type TagChanges struct {
+	Rating *int // Stars, from 0 (unrated) to 5
+
+	// Text sets text fields by metadata key, e.g. GENRE or LABEL, to one or
+	// more values. An empty list removes the field.
+	Text map[string][]string
+}
+
+// TagWriteFunc copies a file from src to dst with its tags changed
+type TagWriteFunc func(src io.ReadSeeker, dst io.Writer, changes TagChanges) error
+
+// id3TextFrames are the frames written for the text fields TagChanges can
+// set, in ID3v2.2 and in ID3v2.3/2.4
+var id3TextFrames = map[string][2]string{
+	"GENRE": {"TCO", "TCON"},
+	"LABEL": {"TPB", "TPUB"},
+}
+
+// rekordboxRatings are the POPM rating bytes rekordbox uses for 0-5 stars
+var rekordboxRatings = [6]byte{0, 51, 102, 153, 204, 255}
+
+// ratingStars converts a POPM rating byte to stars. Players space the bytes
+// differently (Windows Media Player uses 1, 64, 128, 196 and 255), so the
+// nearest star wins and any rating at all is at least one star.
+func ratingStars(b byte) int {
+	if b == 0 {
+		return 0
+	}
+	return max(1, (int(b)+25)/51)
+}
+
+// id3Frame is a frame of an ID3v2 tag, kept as raw bytes so that frames we
+// don't change are written back exactly as they were
+type id3Frame struct {
+	id    string
+	flags uint16
+	data  []byte
+}
+
+// editID3v2 applies changes to an ID3v2 tag and returns the new tag. An
+// existing tag keeps its version and every frame that isn't changed; an
+// empty one becomes an ID3v2.3 tag, the version players read most widely.
+func editID3v2(tag []byte, changes TagChanges) ([]byte, error) {
+	version, frames, err := parseID3v2Frames(tag)
+	if err != nil {
+		return nil, err
+	}
+
+	if changes.Rating != nil {
+		stars := *changes.Rating
+		if stars < 0 || stars > 5 {
+			return nil, fmt.Errorf("invalid rating %d: must be 0-5 stars", stars)
+		}
+		frames = setID3Rating(version, frames, stars)
+	}
+
+	// In a fixed order, so the same edit always writes the same tag
+	for _, key := range slices.Sorted(maps.Keys(changes.Text)) {
+		values := changes.Text[key]
+		ids, ok := id3TextFrames[key]
+		if !ok {
+			return nil, fmt.Errorf("writing %s tags is not supported", strings.ToLower(key))
+		}
+		id := ids[1]
+		if version == 2 {
+			id = ids[0]
+		}
+		var frame *id3Frame
+		if len(values) > 0 {
+			frame = &id3Frame{id: id, data: encodeID3Text(version, values)}
+		}
+		frames = replaceID3Frames(frames, id, frame)
+	}
+
+	return buildID3v2(version, frames)
+}
+
+// parseID3v2Frames splits an ID3v2 tag into its version and frames. Tag-wide
+// unsynchronisation and the extended header are dropped, as the tag is
+// written back without them.
+func parseID3v2Frames(tag []byte) (byte, []id3Frame, error) {
+	if len(tag) == 0 {
+		return 3, nil, nil
+	}
+	if len(tag) < 10 || string(tag[0:3]) != "ID3" {
+		return 0, nil, fmt.Errorf("invalid ID3 tag")
+	}
+
+	version, flags := tag[3], tag[5]
+	if version < 2 || version > 4 {
+		return 0, nil, fmt.Errorf("unsupported ID3v2.%d tag", version)
+	}
+	if version == 2 && flags&0x40 != 0 {
+		return 0, nil, fmt.Errorf("compressed ID3v2.2 tags can't be edited")
+	}
+
+	body := tag[10:]
+	if size := int(syncsafe(tag[6:10])); size < len(body) {
+		body = body[:size]
+	}
+	unsync := flags&0x80 != 0
+	if unsync && version < 4 {
+		body = removeUnsync(body)
+	}
+	if flags&0x40 != 0 {
+		if len(body) < 4 {
+			return 0, nil, fmt.Errorf("invalid ID3 extended header")
+		}
+		skip := 4 + int(binary.BigEndian.Uint32(body))
+		if version == 4 {
+			skip = int(syncsafe(body))
+		}
+		if skip < 4 || skip > len(body) {
+			return 0, nil, fmt.Errorf("invalid ID3 extended header")
+		}
+		body = body[skip:]
+	}
+
+	headerSize := 10
+	if version == 2 {
+		headerSize = 6
+	}
+
+	var frames []id3Frame
+	for len(body) >= headerSize {
+		id, size, frameFlags := parseID3FrameHeader(version, body[:headerSize])
+		if !validID3FrameID(id) || size > int64(len(body)-headerSize) {
+			// Padding is zeros. Anything else is a frame that can't be read,
+			// such as one with a size some writers get wrong, and writing the
+			// tag back would lose it and every frame after it.
+			if slices.ContainsFunc(body, func(b byte) bool { return b != 0 }) {
+				return 0, nil, fmt.Errorf("ID3 tag has a frame that can't be read, so it can't be edited without losing data")
+			}
+			break
+		}
+		data := body[headerSize : headerSize+int(size)]
+		body = body[headerSize+int(size):]
+
+		// Frames marked to be discarded when the tag changes
+		if (version == 3 && frameFlags&0x8000 != 0) || (version == 4 && frameFlags&0x4000 != 0) {
+			continue
+		}
+		// ID3v2.4 unsynchronises frame by frame, so a tag-wide flag moves to
+		// the frames it covered
+		if unsync && version == 4 {
+			frameFlags |= 0x0002
+		}
+		frames = append(frames, id3Frame{id: id, flags: frameFlags, data: data})
+	}
+
+	return version, frames, nil
+}
+
+// buildID3v2 writes an ID3v2 tag of the given version holding frames
+func buildID3v2(version byte, frames []id3Frame) ([]byte, error) {
+	var body bytes.Buffer
+	for _, frame := range frames {
+		size := len(frame.data)
+		body.WriteString(frame.id)
+		switch version {
+		case 2:
+			if size >= 1<<24 {
+				return nil, fmt.Errorf("ID3 frame %s is too large", frame.id)
+			}
+			body.Write([]byte{byte(size >> 16), byte(size >> 8), byte(size)})
+		case 3:
+			binary.Write(&body, binary.BigEndian, uint32(size))
+			binary.Write(&body, binary.BigEndian, frame.flags)
+		default:
+			if size >= 1<<28 {
+				return nil, fmt.Errorf("ID3 frame %s is too large", frame.id)
+			}
+			body.Write(syncsafeBytes(size))
+			binary.Write(&body, binary.BigEndian, frame.flags)
+		}
+		body.Write(frame.data)
+	}
+	if body.Len() >= 1<<28 {
+		return nil, fmt.Errorf("ID3 tag is too large")
+	}
+
+	tag := append([]byte{'I', 'D', '3', version, 0, 0}, syncsafeBytes(body.Len())...)
+	return append(tag, body.Bytes()...), nil
+}
+
+// setID3Rating replaces the tag's popularimeter (POPM) frames with one
+// holding the rekordbox rating byte for stars. A play count is carried over;
+// without one, an unrated track has no frame at all.
+func setID3Rating(version byte, frames []id3Frame, stars int) []id3Frame {
+	id := "POPM"
+	if version == 2 {
+		id = "POP"
+	}
+
+	var counter []byte
+	for _, frame := range frames {
+		if frame.id != id {
+			continue
+		}
+		// An email address identifying the player, the rating, then the
+		// optional play count
+		data, ok := id3FrameData(version, frame.flags, false, frame.data)
+		if end := bytes.IndexByte(data, 0); ok && end >= 0 && end+2 <= len(data) {
+			counter = data[end+2:]
+			break
+		}
+	}
+	if stars == 0 && len(counter) == 0 {
+		return replaceID3Frames(frames, id, nil)
+	}
+
+	// No email address, so the rating isn't tied to one player
+	data := append([]byte{0, rekordboxRatings[stars]}, counter...)
+	return replaceID3Frames(frames, id, &id3Frame{id: id, data: data})
+}
+
+// replaceID3Frames puts frame in place of the first frame called id and
+// drops any others, or adds it at the end if there are none. A nil frame
+// removes them all.
+func replaceID3Frames(frames []id3Frame, id string, frame *id3Frame) []id3Frame {
+	replaced := make([]id3Frame, 0, len(frames)+1)
+	for _, f := range frames {
+		if f.id != id {
+			replaced = append(replaced, f)
+		} else if frame != nil {
+			replaced = append(replaced, *frame)
+			frame = nil
+		}
+	}
+	if frame != nil {
+		replaced = append(replaced, *frame)
+	}
+	return replaced
+}
+
+// encodeID3Text builds the body of a text frame. ID3v2.4 stores several
+// values as NUL-separated UTF-8; earlier versions have no way to, so the
+// values are joined with commas, in ISO-8859-1 if they fit and UTF-16
+// otherwise.
+func encodeID3Text(version byte, values []string) []byte {
+	if version == 4 {
+		return append([]byte{3}, strings.Join(values, "\x00")...)
+	}
+
+	text := strings.Join(values, ", ")
+	if data, ok := encodeLatin1(text); ok {
+		return append([]byte{0}, data...)
+	}
+	data := []byte{1, 0xFF, 0xFE}
+	for _, unit := range utf16.Encode([]rune(text)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return data
+}
+
+// encodeLatin1 converts text to ISO-8859-1, reporting false if it has
+// characters outside it
+func encodeLatin1(text string) ([]byte, bool) {
+	data := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r > 0xFF {
+			return nil, false
+		}
+		data = append(data, byte(r))
+	}
+	return data, true
+}
+
+// id3v1Genre returns the ID3v1 genre number for genre, or 255 (none) if it
+// isn't one of the numbered genres
+func id3v1Genre(genre string) byte {
+	for i, name := range id3Genres {
+		if strings.EqualFold(name, genre) {
+			return byte(i)
+		}
+	}
+	return 255
+}
+
+// syncsafeBytes encodes n as a 28-bit integer stored seven bits per byte
+func syncsafeBytes(n int) []byte {
+	return []byte{byte(n >> 21 & 0x7F), byte(n >> 14 & 0x7F), byte(n >> 7 & 0x7F), byte(n & 0x7F)}
+}
+
+// writeMP3Tags replaces the ID3v2 tag at the start of an MP3 file. The audio
+// and any ID3v1 tag at the end are copied as they are, except that the
+// ID3v1 genre follows a new genre, as players fall back to it.
+func writeMP3Tags(src io.ReadSeeker, dst io.Writer, changes TagChanges) error {
+	size, err := id3v2Size(src)
+	if err != nil {
+		return err
+	}
+	tag, err := readAt(src, 0, int(size))
+	if err != nil {
+		return err
+	}
+	if int64(len(tag)) < size {
+		return fmt.Errorf("invalid ID3 tag: truncated")
+	}
+
+	tag, err = editID3v2(tag, changes)
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(tag); err != nil {
+		return err
+	}
+
+	genres, setGenre := changes.Text["GENRE"]
+	fileSize, err := src.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	var v1 []byte
+	if setGenre && fileSize-size >= 128 {
+		tail, err := readAt(src, fileSize-128, 128)
+		if err != nil {
+			return err
+		}
+		if parseID3v1(tail) != nil {
+			v1 = tail
+			v1[127] = 255
+			if len(genres) > 0 {
+				v1[127] = id3v1Genre(genres[0])
+			}
+		}
+	}
+
+	if _, err := src.Seek(size, io.SeekStart); err != nil {
+		return err
+	}
+	if v1 == nil {
+		_, err = io.Copy(dst, src)
+		return err
+	}
+	if _, err := io.CopyN(dst, src, fileSize-size-128); err != nil {
+		return err
+	}
+	_, err = dst.Write(v1)
+	return err
+}
+
+// writeWAVTags copies a WAV file with its ID3 chunk replaced by one at the
+// end, where most writers put it. RF64 files keep their real sizes in the
+// ds64 chunk, which is updated to match.
+func writeWAVTags(src io.ReadSeeker, dst io.Writer, changes TagChanges) error {
+	info, err := parseWAV(src)
+	if err != nil {
+		return err
+	}
+	header, err := readAt(src, 0, 12)
+	if err != nil {
+		return err
+	}
+
+	// The data chunk's own size can be a marker or wrong; parseWAV has
+	// already worked out the real one
+	chunks, err := listChunks(src, binary.LittleEndian, func(c fileChunk) int64 {
+		if c.offset == info.dataStart {
+			return info.dataSize
+		}
+		return c.size
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read WAV chunks: %w", err)
+	}
+	chunks, err = replaceID3Chunk(src, chunks, "id3 ", changes)
+	if err != nil {
+		return err
+	}
+
+	rf64 := string(header[0:4]) != "RIFF"
+	riffSize := formSize(chunks)
+	if !rf64 && riffSize > math.MaxUint32 {
+		return fmt.Errorf("WAV file would be over 4GB with its new tags")
+	}
+
+	if rf64 {
+		binary.LittleEndian.PutUint32(header[4:8], rf64SizeMarker)
+		for i, c := range chunks {
+			if c.id != "ds64" {
+				continue
+			}
+			ds64, err := readAt(src, c.offset, int(c.size))
+			if err != nil {
+				return err
+			}
+			if len(ds64) < 16 {
+				return fmt.Errorf("invalid RF64 ds64 chunk")
+			}
+			binary.LittleEndian.PutUint64(ds64[0:8], uint64(riffSize))
+			binary.LittleEndian.PutUint64(ds64[8:16], uint64(info.dataSize))
+			chunks[i].data = ds64
+		}
+	} else {
+		binary.LittleEndian.PutUint32(header[4:8], uint32(riffSize))
+	}
+
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	return writeChunks(dst, src, binary.LittleEndian, chunks, func(c fileChunk) uint32 {
+		if rf64 && c.data == nil && c.offset == info.dataStart {
+			return rf64SizeMarker
+		}
+		return uint32(c.size)
+	})
+}
+
+// writeAIFFTags copies an AIFF file with its ID3 chunk replaced by one at
+// the end
+func writeAIFFTags(src io.ReadSeeker, dst io.Writer, changes TagChanges) error {
+	if _, err := parseAIFF(src); err != nil {
+		return err
+	}
+	header, err := readAt(src, 0, 12)
+	if err != nil {
+		return err
+	}
+
+	chunks, err := listChunks(src, binary.BigEndian, nil)
+	if err != nil {
+		return fmt.Errorf("failed to read AIFF chunks: %w", err)
+	}
+	chunks, err = replaceID3Chunk(src, chunks, "ID3 ", changes)
+	if err != nil {
+		return err
+	}
+
+	size := formSize(chunks)
+	if size > math.MaxUint32 {
+		return fmt.Errorf("AIFF file would be over 4GB with its new tags")
+	}
+	binary.BigEndian.PutUint32(header[4:8], uint32(size))
+
+	if _, err := dst.Write(header); err != nil {
+		return err
+	}
+	return writeChunks(dst, src, binary.BigEndian, chunks, nil)
+}
+
+// fileChunk is a chunk of a WAV or AIFF file being copied to a new file
+type fileChunk struct {
+	id     string
+	offset int64  // Of the chunk's body in the source file
+	size   int64  // Of the body, without the padding byte
+	data   []byte // The new body, if it isn't copied from the source
+}
+
+// listChunks returns the chunks after the 12-byte RIFF or FORM header.
+// bodySize, if set, gives the real size of each chunk. A partial chunk
+// header at the end of the file is dropped.
+func listChunks(r io.ReadSeeker, order binary.ByteOrder, bodySize func(fileChunk) int64) ([]fileChunk, error) {
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []fileChunk
+	for offset := int64(12); offset+8 <= fileSize; {
+		header, err := readAt(r, offset, 8)
+		if err != nil {
+			return nil, err
+		}
+		c := fileChunk{id: string(header[0:4]), offset: offset + 8, size: int64(order.Uint32(header[4:8]))}
+		if bodySize != nil {
+			c.size = bodySize(c)
+		}
+		if c.offset+c.size > fileSize {
+			return nil, fmt.Errorf("%q chunk runs past the end of the file", c.id)
+		}
+		chunks = append(chunks, c)
+		offset = c.offset + c.size + c.size%2
+	}
+	return chunks, nil
+}
+
+// replaceID3Chunk edits the tag in the first ID3 chunk, or a new tag if
+// there is none, and returns the other chunks followed by a chunk called id
+// holding the result
+func replaceID3Chunk(src io.ReadSeeker, chunks []fileChunk, id string, changes TagChanges) ([]fileChunk, error) {
+	var tag []byte
+	kept := make([]fileChunk, 0, len(chunks)+1)
+	for _, c := range chunks {
+		if c.id != "id3 " && c.id != "ID3 " {
+			kept = append(kept, c)
+			continue
+		}
+		if tag == nil {
+			var err error
+			if tag, err = readAt(src, c.offset, int(c.size)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	tag, err := editID3v2(tag, changes)
+	if err != nil {
+		return nil, err
+	}
+	return append(kept, fileChunk{id: id, size: int64(len(tag)), data: tag}), nil
+}
+
+// formSize returns the RIFF or FORM size for chunks: the form type and every
+// chunk with its header and padding
+func formSize(chunks []fileChunk) int64 {
+	size := int64(4)
+	for _, c := range chunks {
+		size += 8 + c.size + c.size%2
+	}
+	return size
+}
+
+// writeChunks writes chunks, copying bodies that aren't held in memory from
+// src. sizeField, if set, gives the size written in each chunk header.
+func writeChunks(dst io.Writer, src io.ReadSeeker, order binary.ByteOrder, chunks []fileChunk, sizeField func(fileChunk) uint32) error {
+	for _, c := range chunks {
+		header := make([]byte, 8)
+		copy(header, c.id)
+		if sizeField != nil {
+			order.PutUint32(header[4:], sizeField(c))
+		} else {
+			order.PutUint32(header[4:], uint32(c.size))
+		}
+		if _, err := dst.Write(header); err != nil {
+			return err
+		}
+
+		if c.data != nil {
+			if _, err := dst.Write(c.data); err != nil {
+				return err
+			}
+		} else {
+			if _, err := src.Seek(c.offset, io.SeekStart); err != nil {
+				return err
+			}
+			if _, err := io.CopyN(dst, src, c.size); err != nil {
+				return err
+			}
+		}
+
+		if c.size%2 == 1 {
+			if _, err := dst.Write([]byte{0}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,348 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestRatingStars(t *testing.T) {
+	tests := map[byte]int{
+		0: 0, 1: 1, 51: 1, 64: 1, 102: 2, 128: 3, 153: 3, 196: 4, 204: 4, 255: 5,
+	}
+	for b, want := range tests {
+		if got := ratingStars(b); got != want {
+			t.Errorf("ratingStars(%d) = %d, want %d", b, got, want)
+		}
+	}
+	for stars, b := range rekordboxRatings {
+		if got := ratingStars(b); got != stars {
+			t.Errorf("ratingStars(%d) = %d, want %d", b, got, stars)
+		}
+	}
+}
+
+func TestEditID3v2_Rating(t *testing.T) {
+	// An ID3v2.4 tag unsynchronised as a whole, with another player's rating
+	// and play count
+	popm := append([]byte("Windows Media Player 9 Series\x00\xC4"), 0, 0, 0, 42)
+	tag := testID3Tag(4, 0x80, bytes.Join([][]byte{
+		testID3Frame(4, "TIT2", 0, addUnsync([]byte("\x00\xFF Pulse"))),
+		testID3Frame(4, "POPM", 0, popm),
+		testID3Frame(4, "TXXX", 0x4000, []byte("\x03discard me\x00")),
+	}, nil))
+
+	five := 5
+	edited, err := editID3v2(tag, TagChanges{Rating: &five})
+	if err != nil {
+		t.Fatalf("editID3v2() failed: %v", err)
+	}
+	version, frames, err := parseID3v2Frames(edited)
+	if err != nil {
+		t.Fatalf("parseID3v2Frames() failed: %v", err)
+	}
+	if version != 4 || len(frames) != 2 {
+		t.Fatalf("edited tag = ID3v2.%d with %d frames, want ID3v2.4 with TIT2 and POPM", version, len(frames))
+	}
+	if want := []byte{0, 255, 0, 0, 0, 42}; frames[1].id != "POPM" || !bytes.Equal(frames[1].data, want) {
+		t.Errorf("POPM frame = %s %v, want %v", frames[1].id, frames[1].data, want)
+	}
+
+	got, err := readID3v2(bytes.NewReader(edited))
+	if err != nil {
+		t.Fatalf("readID3v2() failed: %v", err)
+	}
+	if want := (Metadata{"TITLE": {"ÿ Pulse"}, "RATING": {"5"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("edited tags = %q, want %q", got, want)
+	}
+
+	// ID3v2.2 tags keep their version and use its frame ID
+	two := 2
+	edited, err = editID3v2(testID3Tag(2, 0, testID3Frame(2, "TT2", 0, latin1Text("Acid Tracks"))), TagChanges{Rating: &two})
+	if err != nil {
+		t.Fatalf("editID3v2() of an ID3v2.2 tag failed: %v", err)
+	}
+	if _, frames, _ := parseID3v2Frames(edited); len(frames) != 2 || frames[1].id != "POP" {
+		t.Errorf("ID3v2.2 frames = %v, want TT2 and POP", frames)
+	}
+
+	bad := 6
+	if _, err := editID3v2(nil, TagChanges{Rating: &bad}); err == nil {
+		t.Error("editID3v2() should reject 6 stars")
+	}
+
+	// An ID3v2.4 frame size that isn't syncsafe, as some older writers store
+	// it, hides where the next frame starts, so the tag is left alone
+	apic := append([]byte("APIC\x00\x00\x01\x00\x00\x00"), bytes.Repeat([]byte("x"), 256)...)
+	damaged := testID3Tag(4, 0, append(testID3Frame(4, "TIT2", 0, []byte("\x03Pulse")), apic...))
+	if _, err := editID3v2(damaged, TagChanges{Rating: &five}); err == nil {
+		t.Error("editID3v2() should refuse a tag with a frame it can't read")
+	}
+}
+
+func TestEditID3v2_Text(t *testing.T) {
+	// ID3v2.4 keeps several genres as separate values
+	v24 := testID3Tag(4, 0, bytes.Join([][]byte{
+		testID3Frame(4, "TCON", 0, []byte("\x03House")),
+		testID3Frame(4, "TIT2", 0, []byte("\x03Space Date")),
+		testID3Frame(4, "TPUB", 0, []byte("\x03Cocoon")),
+	}, nil))
+	edited, err := editID3v2(v24, TagChanges{Text: map[string][]string{
+		"GENRE": {"Deep House", "Tech House"},
+		"LABEL": nil,
+	}})
+	if err != nil {
+		t.Fatalf("editID3v2() failed: %v", err)
+	}
+	if _, frames, _ := parseID3v2Frames(edited); len(frames) != 2 || frames[0].id != "TCON" {
+		t.Errorf("frames = %v, want TCON replaced in place and TPUB removed", frames)
+	}
+	got, _ := readID3v2(bytes.NewReader(edited))
+	if want := (Metadata{"TITLE": {"Space Date"}, "GENRE": {"Deep House", "Tech House"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("ID3v2.4 tags = %q, want %q", got, want)
+	}
+
+	// Earlier versions join them, in UTF-16 when ISO-8859-1 won't do
+	edited, err = editID3v2(nil, TagChanges{Text: map[string][]string{
+		"GENRE": {"Techno", "Детройт"},
+		"LABEL": {"Kompakt"},
+	}})
+	if err != nil {
+		t.Fatalf("editID3v2() of a new tag failed: %v", err)
+	}
+	got, _ = readID3v2(bytes.NewReader(edited))
+	if want := (Metadata{"GENRE": {"Techno, Детройт"}, "LABEL": {"Kompakt"}}); edited[3] != 3 || !reflect.DeepEqual(got, want) {
+		t.Errorf("new ID3v2.%d tags = %q, want ID3v2.3 with %q", edited[3], got, want)
+	}
+
+	if _, err := editID3v2(nil, TagChanges{Text: map[string][]string{"MOOD": {"Dark"}}}); err == nil {
+		t.Error("editID3v2() should reject fields it can't write")
+	}
+}
+
+func TestWriteTags_ID3v1Genre(t *testing.T) {
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	copy(v1[3:], "Old Title")
+	v1[127] = 0 // Blues
+	path := filepath.Join(t.TempDir(), "track.mp3")
+	if err := os.WriteFile(path, append(bytes.Repeat([]byte{0xFF, 0xFB, 0x90, 0x00}, 100), v1...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewFormatRegistry()
+	steps := []struct {
+		genres []string
+		want   byte
+	}{
+		{[]string{"house", "Garage"}, 35},
+		{[]string{"Tech House"}, 255},
+		{nil, 255},
+	}
+	for _, step := range steps {
+		if err := registry.WriteTags(path, TagChanges{Text: map[string][]string{"GENRE": step.genres}}); err != nil {
+			t.Fatalf("WriteTags(%q) failed: %v", step.genres, err)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := data[len(data)-1]; got != step.want || string(data[len(data)-128:len(data)-125]) != "TAG" {
+			t.Errorf("ID3v1 genre after %q = %d, want %d", step.genres, got, step.want)
+		}
+	}
+
+	// With the genre cleared, the ID3v1 tag doesn't bring one back
+	if got, _ := registry.ReadTags(path); got.Get("GENRE") != "" || got.Get("TITLE") != "Old Title" {
+		t.Errorf("ReadTags() after clearing the genre = %q", got)
+	}
+}
+
+func TestWriteTags(t *testing.T) {
+	tag := testID3Tag(3, 0, testID3Frame(3, "TIT2", 0, latin1Text("Strings of Life")))
+	chunk := func(id string, order binary.ByteOrder) []byte {
+		var b bytes.Buffer
+		b.WriteString(id)
+		binary.Write(&b, order, uint32(len(tag)))
+		b.Write(tag)
+		if len(tag)%2 == 1 {
+			b.WriteByte(0)
+		}
+		return b.Bytes()
+	}
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	audio := append(bytes.Repeat([]byte{0xFF, 0xFB, 0x90, 0x00}, 100), v1...)
+
+	// Tagged files, and untagged ones that get a new tag
+	wavSpec := testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 2, container: 2, encode: intEncoder(2, binary.LittleEndian)}
+	rf64Spec := wavSpec
+	rf64Spec.riffID = "RF64"
+	files := map[string][]byte{
+		"tagged.wav":    append(writeTestWAVFile(wavSpec, 101), chunk("id3 ", binary.LittleEndian)...),
+		"untagged.wav":  writeTestWAVFile(wavSpec, 101),
+		"rf64.wav":      writeTestWAVFile(rf64Spec, 101),
+		"tagged.aiff":   append(writeTestAIFF("", 2, 16, 100, intEncoder(2, binary.BigEndian)), chunk("ID3 ", binary.BigEndian)...),
+		"untagged.aiff": writeTestAIFF("", 2, 16, 100, intEncoder(2, binary.BigEndian)),
+		"tagged.mp3":    append(append([]byte{}, tag...), audio...),
+		"untagged.mp3":  audio,
+	}
+
+	registry := NewFormatRegistry()
+	dir := t.TempDir()
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		before, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, err := registry.ReadTags(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		four := 4
+		if err := registry.WriteTags(path, TagChanges{Rating: &four}); err != nil {
+			t.Errorf("WriteTags(%s) failed: %v", name, err)
+			continue
+		}
+		got, err := registry.ReadTags(path)
+		if err != nil {
+			t.Errorf("ReadTags(%s) after rating failed: %v", name, err)
+			continue
+		}
+		want = mergeMetadata(want, Metadata{"RATING": {"4"}})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadTags(%s) after rating = %q, want %q", name, got, want)
+		}
+
+		after, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if after.Mode() != before.Mode() {
+			t.Errorf("%s mode = %v, want %v", name, after.Mode(), before.Mode())
+		}
+		if os.SameFile(before, after) {
+			t.Errorf("%s was rewritten in place, want a new file renamed over it", name)
+		}
+
+		// The audio is untouched
+		rewritten, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch filepath.Ext(name) {
+		case ".mp3":
+			if !bytes.HasSuffix(rewritten, audio) {
+				t.Errorf("%s audio changed", name)
+			}
+		case ".wav":
+			checkWAVRewrite(t, name, data, rewritten)
+		case ".aiff":
+			original, _ := parseAIFF(bytes.NewReader(data))
+			info, err := parseAIFF(bytes.NewReader(rewritten))
+			if err != nil {
+				t.Errorf("parseAIFF(%s) failed: %v", name, err)
+				continue
+			}
+			if size := int64(binary.BigEndian.Uint32(rewritten[4:8])); size != int64(len(rewritten))-8 {
+				t.Errorf("%s FORM size = %d, want %d", name, size, len(rewritten)-8)
+			}
+			if info.numFrames != original.numFrames || !bytes.Equal(rewritten[info.dataStart:info.dataStart+400], data[original.dataStart:original.dataStart+400]) {
+				t.Errorf("%s sound data changed", name)
+			}
+		}
+
+		// Clearing the rating removes the frame
+		zero := 0
+		if err := registry.WriteTags(path, TagChanges{Rating: &zero}); err != nil {
+			t.Errorf("WriteTags(%s) clearing the rating failed: %v", name, err)
+			continue
+		}
+		if got, _ := registry.ReadTags(path); got.Get("RATING") != "" {
+			t.Errorf("ReadTags(%s) after clearing = %q, want no rating", name, got)
+		}
+	}
+
+	// Only the rated files are left, with no temporary files next to them
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != len(files) {
+		t.Errorf("%d files in the directory, want %d", len(entries), len(files))
+	}
+
+	// A symlink stays a symlink; the file it points to is replaced, keeping
+	// its owner
+	target := filepath.Join(dir, "tagged.mp3")
+	before, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(t.TempDir(), "link"+filepath.Ext(target))
+	if err := os.Symlink(target, link); err != nil {
+		t.Fatal(err)
+	}
+	three := 3
+	if err := registry.WriteTags(link, TagChanges{Rating: &three}); err != nil {
+		t.Fatalf("WriteTags() through a symlink failed: %v", err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("WriteTags() replaced the symlink: %v, %v", info, err)
+	}
+	if got, _ := registry.ReadTags(target); got.Get("RATING") != "3" {
+		t.Errorf("ReadTags() of the symlink's target = %q, want RATING 3", got)
+	}
+	after, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uid, gid, ok := fileOwner(before); ok {
+		if u, g, _ := fileOwner(after); u != uid || g != gid {
+			t.Errorf("Owner after rewrite = %d:%d, want %d:%d", u, g, uid, gid)
+		}
+	}
+
+	flac := filepath.Join(dir, "track.flac")
+	if err := os.WriteFile(flac, []byte("fLaC"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	one := 1
+	if err := registry.WriteTags(flac, TagChanges{Rating: &one}); err == nil {
+		t.Error("WriteTags() should fail for formats without a tag writer")
+	}
+}
+
+// checkWAVRewrite checks that a rewritten WAV file has the same samples and
+// consistent RIFF sizes
+func checkWAVRewrite(t *testing.T, name string, original, rewritten []byte) {
+	t.Helper()
+	before, _ := parseWAV(bytes.NewReader(original))
+	after, err := parseWAV(bytes.NewReader(rewritten))
+	if err != nil {
+		t.Errorf("parseWAV(%s) failed: %v", name, err)
+		return
+	}
+	if after.dataSize != before.dataSize || !bytes.Equal(
+		rewritten[after.dataStart:after.dataStart+after.dataSize],
+		original[before.dataStart:before.dataStart+before.dataSize]) {
+		t.Errorf("%s sample data changed", name)
+	}
+
+	riffSize := int64(binary.LittleEndian.Uint32(rewritten[4:8]))
+	if string(rewritten[0:4]) == "RF64" {
+		// ds64 is the first chunk, and holds the real RIFF size
+		riffSize = int64(binary.LittleEndian.Uint64(rewritten[20:28]))
+	}
+	if riffSize != int64(len(rewritten))-8 {
+		t.Errorf("%s RIFF size = %d, want %d", name, riffSize, len(rewritten)-8)
+	}
+}
@@ -0,0 +1,185 @@
+package decoders
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/jfreymuth/oggvorbis"
+)
+
+// vorbisChannelWeights maps each channel count to the stereo weights of its channels,
+// following the channel order defined by the Vorbis specification
+var vorbisChannelWeights = map[int][][2]float64{
+	1: {{1, 1}},
+	2: {speakerLeft, speakerRight},
+	3: {speakerLeft, speakerCenter, speakerRight},
+	4: {speakerLeft, speakerRight, speakerSurLeft, speakerSurRight},
+	5: {speakerLeft, speakerCenter, speakerRight, speakerSurLeft, speakerSurRight},
+	6: {speakerLeft, speakerCenter, speakerRight, speakerSurLeft, speakerSurRight, speakerLFE},
+	7: {speakerLeft, speakerCenter, speakerRight, speakerSurLeft, speakerSurRight, speakerSurBack, speakerLFE},
+	8: {speakerLeft, speakerCenter, speakerRight, speakerSurLeft, speakerSurRight, speakerSurLeft, speakerSurRight, speakerLFE},
+}
+
+// vorbisStreamer implements beep.StreamSeekCloser for Ogg Vorbis files
+type vorbisStreamer struct {
+	reader   *oggvorbis.Reader
+	channels int
+	weights  [][2]float64 // Per-channel stereo mix weights
+	buffer   []float32    // Interleaved samples from the decoder
+	metadata Metadata
+	err      error
+}
+
+// DecodeVorbis creates a seekable beep-compatible streamer from an Ogg Vorbis file.
+// Vorbis comments are available through the streamer's Metadata method.
+func DecodeVorbis(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
+	// Length and seeking need ReadSeeker, same as AIFF
+	if _, ok := r.(io.ReadSeeker); !ok {
+		return nil, beep.Format{}, fmt.Errorf("Vorbis decoder requires ReadSeeker interface")
+	}
+
+	reader, err := oggvorbis.NewReader(r)
+	if err != nil {
+		return nil, beep.Format{}, fmt.Errorf("failed to decode Ogg Vorbis: %w", err)
+	}
+
+	numChannels := reader.Channels()
+	weights, ok := vorbisChannelWeights[numChannels]
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("unsupported Vorbis channel count: %d", numChannels)
+	}
+
+	streamer := &vorbisStreamer{
+		reader:   reader,
+		channels: numChannels,
+		weights:  normalizeDownmix(weights),
+		metadata: parseVorbisComments(reader.CommentHeader().Comments),
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(reader.SampleRate()),
+		NumChannels: numChannels,
+		Precision:   2, // Vorbis has no bit depth; decoded as float
+	}
+
+	return streamer, format, nil
+}
+
+// parseVorbisComments splits "FIELD=value" comments into metadata.
+// Field names are case-insensitive; comments without '=' are ignored.
+func parseVorbisComments(comments []string) Metadata {
+	metadata := make(Metadata)
+	for _, comment := range comments {
+		key, value, ok := strings.Cut(comment, "=")
+		if !ok || key == "" {
+			continue
+		}
+		metadata.Add(key, value)
+	}
+	return metadata
+}
+
+func (s *vorbisStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	if s.err != nil {
+		return 0, false
+	}
+
+	if need := len(samples) * s.channels; len(s.buffer) < need {
+		s.buffer = make([]float32, need)
+	}
+
+	for n < len(samples) {
+		read, err := s.reader.Read(s.buffer[:(len(samples)-n)*s.channels])
+		frames := read / s.channels
+		s.convert(samples[n:n+frames], s.buffer[:frames*s.channels])
+		n += frames
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.err = fmt.Errorf("failed to read Vorbis data: %w", err)
+			break
+		}
+		if read == 0 {
+			break
+		}
+	}
+
+	return n, n > 0
+}
+
+// convert mixes interleaved decoder output down to stereo
+func (s *vorbisStreamer) convert(out [][2]float64, in []float32) {
+	switch s.channels {
+	case 1:
+		for i := range out {
+			v := float64(in[i])
+			out[i] = [2]float64{v, v}
+		}
+	case 2:
+		for i := range out {
+			out[i] = [2]float64{float64(in[i*2]), float64(in[i*2+1])}
+		}
+	default:
+		for i := range out {
+			var l, r float64
+			frame := in[i*s.channels : (i+1)*s.channels]
+			for ch, v := range frame {
+				l += float64(v) * s.weights[ch][0]
+				r += float64(v) * s.weights[ch][1]
+			}
+			out[i] = [2]float64{l, r}
+		}
+	}
+}
+
+func (s *vorbisStreamer) Err() error {
+	return s.err
+}
+
+func (s *vorbisStreamer) Len() int {
+	return int(s.reader.Length())
+}
+
+func (s *vorbisStreamer) Position() int {
+	return int(s.reader.Position())
+}
+
+func (s *vorbisStreamer) Seek(p int) error {
+	if p < 0 || p > s.Len() {
+		return fmt.Errorf("seek position out of range: %d (total: %d)", p, s.Len())
+	}
+
+	if err := s.reader.SetPosition(int64(p)); err != nil {
+		return fmt.Errorf("failed to seek in Vorbis file: %w", err)
+	}
+	s.err = nil
+	return nil
+}
+
+// Metadata returns the file's Vorbis comments
+func (s *vorbisStreamer) Metadata() Metadata {
+	return s.metadata
+}
+
+func (s *vorbisStreamer) Close() error {
+	// The file itself is closed by the player
+	return nil
+}
+
+// SniffVorbis reports whether header starts an Ogg stream carrying Vorbis audio.
+// Other Ogg codecs (Opus, Ogg FLAC) share the OggS signature, so the first
+// packet is checked for the Vorbis identification header.
+func SniffVorbis(header []byte) bool {
+	if !bytes.HasPrefix(header, []byte("OggS")) || len(header) < 27 {
+		return false
+	}
+
+	// The first packet follows the 27-byte page header and its segment table
+	packetStart := 27 + int(header[26])
+	return bytes.HasPrefix(header[min(packetStart, len(header)):], []byte("\x01vorbis"))
+}
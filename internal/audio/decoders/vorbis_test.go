@@ -0,0 +1,82 @@
+package decoders
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDecodeVorbis_LengthAndSeek(t *testing.T) {
+	// One second of mono audio at 44.1kHz
+	file, err := os.Open("testdata/test.ogg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	streamer, format, err := NewFormatRegistry().Decode("testdata/test.ogg", file)
+	if err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	defer streamer.Close()
+
+	if format.SampleRate != 44100 || format.NumChannels != 1 {
+		t.Errorf("Format = %+v, want 44100Hz mono", format)
+	}
+	if streamer.Len() != 44100 {
+		t.Errorf("Len() = %d, want 44100", streamer.Len())
+	}
+
+	if err := streamer.Seek(30000); err != nil {
+		t.Fatalf("Seek() failed: %v", err)
+	}
+	if streamer.Position() != 30000 {
+		t.Errorf("Position() after Seek = %d, want 30000", streamer.Position())
+	}
+
+	count := 0
+	buf := make([][2]float64, 1000)
+	for {
+		n, ok := streamer.Stream(buf)
+		count += n
+		if !ok {
+			break
+		}
+	}
+	if count != 44100-30000 {
+		t.Errorf("Streamed %d samples after seek, want %d", count, 44100-30000)
+	}
+	if streamer.Err() != nil {
+		t.Errorf("Err() = %v", streamer.Err())
+	}
+
+	if err := streamer.Seek(44101); err == nil {
+		t.Error("Seek() past the end should fail")
+	}
+}
+
+func TestParseVorbisComments(t *testing.T) {
+	metadata := parseVorbisComments([]string{
+		"TITLE=Night Drive",
+		"artist=First",
+		"ARTIST=Second",
+		"DESCRIPTION=a=b",
+		"no separator",
+		"=empty key",
+	})
+
+	if got := metadata.Get("title"); got != "Night Drive" {
+		t.Errorf("Get(title) = %q, want %q", got, "Night Drive")
+	}
+	if got := metadata["ARTIST"]; len(got) != 2 || got[0] != "First" || got[1] != "Second" {
+		t.Errorf("ARTIST = %v, want [First Second]", got)
+	}
+	if got := metadata.Get("DESCRIPTION"); got != "a=b" {
+		t.Errorf("Get(DESCRIPTION) = %q, want %q", got, "a=b")
+	}
+	if got := metadata.Get("ALBUM"); got != "" {
+		t.Errorf("Get(ALBUM) = %q, want empty", got)
+	}
+	if len(metadata) != 3 {
+		t.Errorf("Expected 3 fields, got %d", len(metadata))
+	}
+}
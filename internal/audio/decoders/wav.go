@@ -1,19 +1,319 @@
 package decoders
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"io"
+	"strconv"
+	"strings"
 
 	"github.com/gopxl/beep/v2"
-	"github.com/gopxl/beep/v2/wav"
 )
 
-// DecodeWAV decodes WAV files using beep's WAV decoder
+// WAVE format tags
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatFloat      = 0x0003
+	wavFormatExtensible = 0xFFFE
+)
+
+// rf64SizeMarker replaces 32-bit sizes that are stored in the ds64 chunk instead
+const rf64SizeMarker = 0xFFFFFFFF
+
+// wavSpeakerWeights gives the stereo weight of each WAVE_FORMAT_EXTENSIBLE
+// channel mask bit, in bit order
+var wavSpeakerWeights = [][2]float64{
+	speakerLeft,     // Front left
+	speakerRight,    // Front right
+	speakerCenter,   // Front center
+	speakerLFE,      // Low frequency
+	speakerSurLeft,  // Back left
+	speakerSurRight, // Back right
+	speakerLeft,     // Front left of center
+	speakerRight,    // Front right of center
+	speakerSurBack,  // Back center
+	speakerSurLeft,  // Side left
+	speakerSurRight, // Side right
+	speakerCenter,   // Top center
+	speakerLeft,     // Top front left
+	speakerCenter,   // Top front center
+	speakerRight,    // Top front right
+	speakerSurLeft,  // Top back left
+	speakerSurBack,  // Top back center
+	speakerSurRight, // Top back right
+}
+
+// wavInfo holds what the decoder needs from a WAV file's chunks
+type wavInfo struct {
+	formatTag     uint16 // Sample format, resolved from the sub-format for extensible files
+	numChannels   int
+	sampleRate    int
+	blockAlign    int
+	bitsPerSample int
+	channelMask   uint32
+	dataStart     int64
+	dataSize      int64
+	metadata      Metadata
+}
+
+// DecodeWAV creates a seekable beep-compatible streamer from a WAV file.
+// PCM and float samples are supported, including WAVE_FORMAT_EXTENSIBLE and
+// RF64/BW64 files over 4GB. Broadcast WAV (bext) fields and ID3 tags are
+// available through the streamer's Metadata method.
 func DecodeWAV(r io.ReadCloser) (beep.StreamSeekCloser, beep.Format, error) {
-	streamer, format, err := wav.Decode(r)
+	// WAV seeking needs ReadSeeker, same as AIFF
+	readSeeker, ok := r.(io.ReadSeeker)
+	if !ok {
+		return nil, beep.Format{}, fmt.Errorf("WAV decoder requires ReadSeeker interface")
+	}
+
+	info, err := parseWAV(readSeeker)
+	if err != nil {
+		return nil, beep.Format{}, err
+	}
+
+	if info.numChannels < 1 || info.blockAlign < 1 {
+		return nil, beep.Format{}, fmt.Errorf("invalid WAV format: %d channels, block align %d", info.numChannels, info.blockAlign)
+	}
+
+	// The container size per sample; valid bits are left-justified within it
+	bytesPerSample := info.blockAlign / info.numChannels
+	encoding := pcmEncoding{bytesPerSample: bytesPerSample, littleEndian: true}
+
+	switch info.formatTag {
+	case wavFormatPCM:
+		if bytesPerSample < 1 || bytesPerSample > 4 {
+			return nil, beep.Format{}, fmt.Errorf("unsupported WAV bit depth: %d", info.bitsPerSample)
+		}
+		// 8-bit WAV samples are unsigned
+		encoding.unsigned = bytesPerSample == 1
+	case wavFormatFloat:
+		if bytesPerSample != 4 && bytesPerSample != 8 {
+			return nil, beep.Format{}, fmt.Errorf("unsupported WAV float bit depth: %d", info.bitsPerSample)
+		}
+		encoding.float = true
+	default:
+		return nil, beep.Format{}, fmt.Errorf("unsupported WAV format tag: 0x%04X", info.formatTag)
+	}
+
+	numFrames := int(info.dataSize / int64(info.blockAlign))
+	streamer, err := newPCMStreamer(readSeeker, encoding, info.numChannels, info.blockAlign, info.dataStart, numFrames)
 	if err != nil {
-		return nil, beep.Format{}, fmt.Errorf("failed to decode WAV: %w", err)
+		return nil, beep.Format{}, fmt.Errorf("failed to read WAV file: %w", err)
+	}
+	streamer.metadata = info.metadata
+
+	if info.numChannels > 2 {
+		weights, ok := wavMaskWeights(info.channelMask, info.numChannels)
+		if !ok {
+			weights, ok = waveChannelWeights[info.numChannels]
+		}
+		if ok {
+			streamer.weights = normalizeDownmix(weights)
+		}
+	}
+
+	format := beep.Format{
+		SampleRate:  beep.SampleRate(info.sampleRate),
+		NumChannels: info.numChannels,
+		Precision:   min(bytesPerSample, 4),
 	}
 
 	return streamer, format, nil
-}
\ No newline at end of file
+}
+
+// parseWAV walks the RIFF chunks and returns the fmt details, the position of
+// the sample data and any bext or ID3 metadata
+func parseWAV(r io.ReadSeeker) (wavInfo, error) {
+	var info wavInfo
+
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil || !SniffWAV(header[:]) {
+		return info, fmt.Errorf("invalid WAV file")
+	}
+
+	fileSize, err := r.Seek(0, io.SeekEnd)
+	if err != nil {
+		return info, fmt.Errorf("failed to read WAV file: %w", err)
+	}
+	if _, err := r.Seek(int64(len(header)), io.SeekStart); err != nil {
+		return info, fmt.Errorf("failed to read WAV file: %w", err)
+	}
+
+	var rf64DataSize int64 = -1
+	var foundFmt, foundData bool
+	offset := int64(len(header))
+
+	// Keep going after the data chunk, as ID3 tags usually follow it
+	for {
+		var chunkHeader [8]byte
+		if _, err := io.ReadFull(r, chunkHeader[:]); err != nil {
+			break
+		}
+		id := string(chunkHeader[0:4])
+		size := int64(binary.LittleEndian.Uint32(chunkHeader[4:8]))
+		body := offset + 8
+
+		switch id {
+		case "ds64":
+			// RF64 keeps the real 64-bit RIFF and data sizes here
+			var ds64 [16]byte
+			if _, err := io.ReadFull(r, ds64[:]); err != nil {
+				return info, fmt.Errorf("invalid RF64 ds64 chunk")
+			}
+			rf64DataSize = int64(binary.LittleEndian.Uint64(ds64[8:16]))
+
+		case "fmt ":
+			// Check sizes against the file before trusting them for an allocation
+			if size < 16 || size > fileSize-body {
+				return info, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return info, fmt.Errorf("invalid WAV fmt chunk")
+			}
+			parseWAVFormat(chunk, &info)
+			foundFmt = true
+
+		case "bext":
+			if size > fileSize-body {
+				return info, fmt.Errorf("invalid WAV bext chunk")
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return info, fmt.Errorf("invalid WAV bext chunk")
+			}
+			info.metadata = mergeMetadata(info.metadata, parseBext(chunk))
+
+		case "id3 ", "ID3 ":
+			info.metadata = readID3Chunk(r, info.metadata)
+
+		case "data":
+			info.dataStart = body
+			info.dataSize = size
+			if size == rf64SizeMarker && rf64DataSize >= 0 {
+				info.dataSize = rf64DataSize
+			}
+			// Recordings that were cut short can claim more data than the file
+			// holds, or leave the size unset
+			if remaining := fileSize - body; info.dataSize > remaining || info.dataSize == 0 {
+				info.dataSize = remaining
+			}
+			foundData = true
+			size = info.dataSize
+		}
+
+		// Chunks are padded to an even length
+		offset = body + size + size%2
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return info, fmt.Errorf("failed to read WAV chunks: %w", err)
+		}
+	}
+
+	if !foundFmt {
+		return info, fmt.Errorf("invalid WAV file: missing fmt chunk")
+	}
+	if !foundData {
+		return info, fmt.Errorf("invalid WAV file: missing data chunk")
+	}
+
+	return info, nil
+}
+
+// readWAVTags returns the bext and ID3 metadata of a WAV file
+func readWAVTags(r io.ReadSeeker) (Metadata, error) {
+	info, err := parseWAV(r)
+	if err != nil {
+		return nil, err
+	}
+	return info.metadata, nil
+}
+
+// parseWAVFormat reads a fmt chunk, resolving WAVE_FORMAT_EXTENSIBLE to its sub-format
+func parseWAVFormat(chunk []byte, info *wavInfo) {
+	info.formatTag = binary.LittleEndian.Uint16(chunk[0:2])
+	info.numChannels = int(binary.LittleEndian.Uint16(chunk[2:4]))
+	info.sampleRate = int(binary.LittleEndian.Uint32(chunk[4:8]))
+	info.blockAlign = int(binary.LittleEndian.Uint16(chunk[12:14]))
+	info.bitsPerSample = int(binary.LittleEndian.Uint16(chunk[14:16]))
+
+	// cbSize, valid bits, channel mask and the sub-format GUID, whose first
+	// two bytes are the actual format tag
+	if info.formatTag == wavFormatExtensible && len(chunk) >= 40 {
+		info.channelMask = binary.LittleEndian.Uint32(chunk[20:24])
+		info.formatTag = binary.LittleEndian.Uint16(chunk[24:26])
+	}
+}
+
+// wavMaskWeights builds per-channel mix weights from an extensible channel mask.
+// It reports false when the mask doesn't describe every channel.
+func wavMaskWeights(mask uint32, numChannels int) ([][2]float64, bool) {
+	var weights [][2]float64
+	for bit := 0; bit < len(wavSpeakerWeights) && len(weights) < numChannels; bit++ {
+		if mask&(1<<bit) != 0 {
+			weights = append(weights, wavSpeakerWeights[bit])
+		}
+	}
+	return weights, len(weights) == numChannels
+}
+
+// parseBext reads the Broadcast WAV extension fields that identify a recording
+func parseBext(chunk []byte) Metadata {
+	metadata := make(Metadata)
+
+	// Fixed-size text fields, NUL-padded
+	fields := []struct {
+		key    string
+		offset int
+		size   int
+	}{
+		{"DESCRIPTION", 0, 256},
+		{"ORIGINATOR", 256, 32},
+		{"ORIGINATOR_REFERENCE", 288, 32},
+		{"ORIGINATION_DATE", 320, 10},
+		{"ORIGINATION_TIME", 330, 8},
+	}
+	for _, f := range fields {
+		if len(chunk) < f.offset+f.size {
+			break
+		}
+		if value := bextString(chunk[f.offset : f.offset+f.size]); value != "" {
+			metadata.Add(f.key, value)
+		}
+	}
+
+	// Sample count since midnight of the first sample, for lining up recordings
+	if len(chunk) >= 346 {
+		timeReference := binary.LittleEndian.Uint64(chunk[338:346])
+		metadata.Add("TIME_REFERENCE", strconv.FormatUint(timeReference, 10))
+	}
+
+	if len(chunk) > 602 {
+		if history := bextString(chunk[602:]); history != "" {
+			metadata.Add("CODING_HISTORY", history)
+		}
+	}
+
+	return metadata
+}
+
+// bextString trims a NUL-padded bext text field
+func bextString(b []byte) string {
+	if i := bytes.IndexByte(b, 0); i >= 0 {
+		b = b[:i]
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// SniffWAV reports whether header starts a RIFF, RF64 or BW64 WAVE file
+func SniffWAV(header []byte) bool {
+	if len(header) < 12 || string(header[8:12]) != "WAVE" {
+		return false
+	}
+	switch string(header[0:4]) {
+	case "RIFF", "RF64", "BW64":
+		return true
+	}
+	return false
+}
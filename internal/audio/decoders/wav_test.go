@@ -0,0 +1,250 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+// testWAVFile describes a WAV file for writeTestWAVFile
+type testWAVFile struct {
+	riffID      string // "RIFF" or "RF64"
+	formatTag   uint16
+	subFormat   uint16 // Set for WAVE_FORMAT_EXTENSIBLE
+	channels    int
+	container   int // Bytes per sample
+	validBits   int
+	channelMask uint32
+	bext        []byte
+	encode      func(*bytes.Buffer, float64)
+}
+
+// testWAVValue is the sample written at frame i of channel ch, in [-1, 1)
+func testWAVValue(i, ch int) float64 {
+	return float64((i%200)-100)/128 + float64(ch)*0.0625
+}
+
+func writeTestWAVFile(spec testWAVFile, frames int) []byte {
+	var data bytes.Buffer
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < spec.channels; ch++ {
+			spec.encode(&data, testWAVValue(i, ch))
+		}
+	}
+
+	var fmtChunk bytes.Buffer
+	blockAlign := spec.container * spec.channels
+	binary.Write(&fmtChunk, binary.LittleEndian, spec.formatTag)
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(spec.channels))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(48000))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(48000*blockAlign))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(spec.container*8))
+	if spec.formatTag == wavFormatExtensible {
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(22))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(spec.validBits))
+		binary.Write(&fmtChunk, binary.LittleEndian, spec.channelMask)
+		binary.Write(&fmtChunk, binary.LittleEndian, spec.subFormat)
+		fmtChunk.Write([]byte("\x00\x00\x00\x00\x10\x00\x80\x00\x00\xAA\x00\x38\x9B\x71"))
+	}
+
+	var body bytes.Buffer
+	body.WriteString("WAVE")
+	writeChunk := func(id string, payload []byte) {
+		body.WriteString(id)
+		binary.Write(&body, binary.LittleEndian, uint32(len(payload)))
+		body.Write(payload)
+		if len(payload)%2 == 1 {
+			body.WriteByte(0)
+		}
+	}
+
+	if spec.riffID == "RF64" {
+		var ds64 bytes.Buffer
+		binary.Write(&ds64, binary.LittleEndian, uint64(0)) // RIFF size, unused by the decoder
+		binary.Write(&ds64, binary.LittleEndian, uint64(data.Len()))
+		binary.Write(&ds64, binary.LittleEndian, uint64(frames))
+		binary.Write(&ds64, binary.LittleEndian, uint32(0))
+		writeChunk("ds64", ds64.Bytes())
+	}
+	writeChunk("fmt ", fmtChunk.Bytes())
+	if spec.bext != nil {
+		writeChunk("bext", spec.bext)
+	}
+	writeChunk("JUNK", []byte{1, 2, 3}) // Odd-sized chunk to check padding
+
+	body.WriteString("data")
+	if spec.riffID == "RF64" {
+		binary.Write(&body, binary.LittleEndian, uint32(rf64SizeMarker))
+	} else {
+		binary.Write(&body, binary.LittleEndian, uint32(data.Len()))
+	}
+	body.Write(data.Bytes())
+
+	var file bytes.Buffer
+	file.WriteString(spec.riffID)
+	binary.Write(&file, binary.LittleEndian, uint32(body.Len()))
+	file.Write(body.Bytes())
+	return file.Bytes()
+}
+
+func TestDecodeWAV_Variants(t *testing.T) {
+	int16LE := intEncoder(2, binary.LittleEndian)
+	int24LE := intEncoder(3, binary.LittleEndian)
+	float32LE := func(buf *bytes.Buffer, v float64) { binary.Write(buf, binary.LittleEndian, float32(v)) }
+
+	tests := []struct {
+		name      string
+		spec      testWAVFile
+		tolerance float64
+	}{
+		{"16-bit PCM", testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 2, container: 2, encode: int16LE}, 1.0 / (1 << 15)},
+		{"24-bit PCM mono", testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 1, container: 3, encode: int24LE}, 1.0 / (1 << 23)},
+		{"8-bit unsigned PCM", testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 2, container: 1, encode: func(buf *bytes.Buffer, v float64) {
+			buf.WriteByte(byte(int(math.Floor(v*128)) + 128))
+		}}, 1.0 / (1 << 7)},
+		{"32-bit float", testWAVFile{riffID: "RIFF", formatTag: wavFormatFloat, channels: 2, container: 4, encode: float32LE}, 1e-7},
+		{"64-bit float", testWAVFile{riffID: "RIFF", formatTag: wavFormatFloat, channels: 2, container: 8, encode: func(buf *bytes.Buffer, v float64) {
+			binary.Write(buf, binary.LittleEndian, v)
+		}}, 1e-12},
+		{"extensible 24-bit in 32-bit container", testWAVFile{riffID: "RIFF", formatTag: wavFormatExtensible, subFormat: wavFormatPCM, channels: 2, container: 4, validBits: 24, channelMask: 0x3, encode: func(buf *bytes.Buffer, v float64) {
+			// Valid bits are left-justified with the low byte zeroed
+			binary.Write(buf, binary.LittleEndian, int32(v*(1<<23))<<8)
+		}}, 1.0 / (1 << 23)},
+		{"extensible float", testWAVFile{riffID: "RIFF", formatTag: wavFormatExtensible, subFormat: wavFormatFloat, channels: 2, container: 4, validBits: 32, channelMask: 0x3, encode: float32LE}, 1e-7},
+		{"RF64", testWAVFile{riffID: "RF64", formatTag: wavFormatPCM, channels: 2, container: 2, encode: int16LE}, 1.0 / (1 << 15)},
+	}
+
+	const frames = pcmBufferSize + 500 // Spans a buffer refill
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := writeTestWAVFile(tt.spec, frames)
+
+			streamer, format, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(data)})
+			if err != nil {
+				t.Fatalf("DecodeWAV() failed: %v", err)
+			}
+
+			if format.SampleRate != 48000 || format.NumChannels != tt.spec.channels {
+				t.Errorf("Format = %+v, want 48000Hz with %d channels", format, tt.spec.channels)
+			}
+			if streamer.Len() != frames {
+				t.Errorf("Len() = %d, want %d", streamer.Len(), frames)
+			}
+
+			rightCh := 1
+			if tt.spec.channels == 1 {
+				rightCh = 0
+			}
+
+			for _, pos := range []int{0, pcmBufferSize - 10, frames - 20} {
+				if err := streamer.Seek(pos); err != nil {
+					t.Fatalf("Seek(%d) failed: %v", pos, err)
+				}
+
+				buf := make([][2]float64, 20)
+				if n, ok := streamer.Stream(buf); !ok || n != 20 {
+					t.Fatalf("Stream() after Seek(%d) = %d, %v", pos, n, ok)
+				}
+				for i, s := range buf {
+					wantL, wantR := testWAVValue(pos+i, 0), testWAVValue(pos+i, rightCh)
+					if math.Abs(s[0]-wantL) > tt.tolerance || math.Abs(s[1]-wantR) > tt.tolerance {
+						t.Fatalf("Sample %d = %v, want [%v %v]", pos+i, s, wantL, wantR)
+					}
+				}
+			}
+
+			if n, ok := streamer.Stream(make([][2]float64, 10)); ok || n != 0 {
+				t.Errorf("Stream() at end = %d, %v, want 0, false", n, ok)
+			}
+			if streamer.Err() != nil {
+				t.Errorf("Err() = %v", streamer.Err())
+			}
+		})
+	}
+}
+
+func TestDecodeWAV_BroadcastExtension(t *testing.T) {
+	bext := make([]byte, 602+len("A=PCM,F=48000\r\n"))
+	copy(bext[0:], "Friday night mix")
+	copy(bext[256:], "auxbox recorder")
+	copy(bext[320:], "2026-03-14")
+	copy(bext[330:], "22:15:00")
+	binary.LittleEndian.PutUint64(bext[338:], 48000*3600*22)
+	copy(bext[602:], "A=PCM,F=48000\r\n")
+
+	spec := testWAVFile{riffID: "RIFF", formatTag: wavFormatPCM, channels: 2, container: 2, bext: bext, encode: intEncoder(2, binary.LittleEndian)}
+	streamer, _, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(writeTestWAVFile(spec, 100))})
+	if err != nil {
+		t.Fatalf("DecodeWAV() failed: %v", err)
+	}
+
+	metadata := streamer.(MetadataStreamer).Metadata()
+	expected := map[string]string{
+		"DESCRIPTION":      "Friday night mix",
+		"ORIGINATOR":       "auxbox recorder",
+		"ORIGINATION_DATE": "2026-03-14",
+		"ORIGINATION_TIME": "22:15:00",
+		"TIME_REFERENCE":   "3801600000",
+		"CODING_HISTORY":   "A=PCM,F=48000",
+	}
+	for key, want := range expected {
+		if got := metadata.Get(key); got != want {
+			t.Errorf("Get(%s) = %q, want %q", key, got, want)
+		}
+	}
+	if _, exists := metadata["ORIGINATOR_REFERENCE"]; exists {
+		t.Error("Empty bext fields should be omitted")
+	}
+}
+
+func TestDecodeWAV_TruncatedAndSurround(t *testing.T) {
+	spec := testWAVFile{riffID: "RIFF", formatTag: wavFormatExtensible, subFormat: wavFormatPCM, channels: 6, container: 2, validBits: 16, channelMask: 0x3F, encode: intEncoder(2, binary.LittleEndian)}
+	data := writeTestWAVFile(spec, 1000)
+
+	// Drop the last 100 frames, as an interrupted recording would
+	data = data[:len(data)-100*12]
+
+	streamer, _, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(data)})
+	if err != nil {
+		t.Fatalf("DecodeWAV() failed: %v", err)
+	}
+	if streamer.Len() != 900 {
+		t.Errorf("Len() = %d, want 900", streamer.Len())
+	}
+
+	buf := make([][2]float64, 10)
+	if n, ok := streamer.Stream(buf); !ok || n != 10 {
+		t.Fatalf("Stream() = %d, %v", n, ok)
+	}
+
+	// Surround content must reach the stereo output, and stay within range
+	for _, s := range buf {
+		if s[0] == 0 || s[1] == 0 || math.Abs(s[0]) > 1 || math.Abs(s[1]) > 1 {
+			t.Fatalf("Unexpected downmixed sample %v", s)
+		}
+	}
+}
+
+func TestDecodeWAV_UnsupportedFormat(t *testing.T) {
+	// IMA ADPCM
+	spec := testWAVFile{riffID: "RIFF", formatTag: 0x0011, channels: 1, container: 1, encode: func(buf *bytes.Buffer, v float64) {
+		buf.WriteByte(0)
+	}}
+	if _, _, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(writeTestWAVFile(spec, 10))}); err == nil {
+		t.Error("DecodeWAV() should reject compressed formats")
+	}
+}
+
+func TestDecodeWAV_OversizedChunk(t *testing.T) {
+	for _, id := range []string{"fmt ", "bext"} {
+		// A chunk claiming nearly 4GB in a file of a few bytes
+		data := []byte("RIFF\x00\x00\x00\x20WAVE" + id + "\xF0\xFF\xFF\xFF\x01\x00")
+
+		if _, _, err := DecodeWAV(nopReadSeekCloser{bytes.NewReader(data)}); err == nil {
+			t.Errorf("DecodeWAV() should reject a %q chunk larger than the file", id)
+		}
+	}
+}
@@ -0,0 +1,292 @@
+package decoders
+
+import (
+	"bytes"
+	"encoding/binary"
+)
+
+// mp3DecoderDelay is the delay added by the MP3 synthesis filterbank.
+// Gapless players skip it along with the encoder delay from the LAME tag.
+const mp3DecoderDelay = 529
+
+// mpegBitrates holds bitrates in kbit/s, indexed by [MPEG-1?][layer-1][index]
+var mpegBitrates = [2][3][15]int{
+	{ // MPEG-2 and 2.5
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 144, 160, 176, 192, 224, 256},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+		{0, 8, 16, 24, 32, 40, 48, 56, 64, 80, 96, 112, 128, 144, 160},
+	},
+	{ // MPEG-1
+		{0, 32, 64, 96, 128, 160, 192, 224, 256, 288, 320, 352, 384, 416, 448},
+		{0, 32, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320, 384},
+		{0, 32, 40, 48, 56, 64, 80, 96, 112, 128, 160, 192, 224, 256, 320},
+	},
+}
+
+// mpegSampleRates holds sample rates indexed by the version bits and rate index
+var mpegSampleRates = map[byte][3]int{
+	0: {11025, 12000, 8000},  // MPEG-2.5
+	2: {22050, 24000, 16000}, // MPEG-2
+	3: {44100, 48000, 32000}, // MPEG-1
+}
+
+// mpegFrameHeader is a parsed 4-byte MPEG audio frame header
+type mpegFrameHeader struct {
+	version    byte // Raw version bits: 0 = 2.5, 2 = 2, 3 = 1
+	layer      int  // 1, 2 or 3
+	bitrate    int  // Bits per second
+	sampleRate int
+	padding    bool
+	mono       bool
+}
+
+// parseMPEGFrameHeader parses a frame header, rejecting reserved and
+// free-format values
+func parseMPEGFrameHeader(b []byte) (mpegFrameHeader, bool) {
+	if len(b) < 4 || b[0] != 0xFF || b[1]&0xE0 != 0xE0 {
+		return mpegFrameHeader{}, false
+	}
+
+	version := (b[1] >> 3) & 0x03
+	layerBits := (b[1] >> 1) & 0x03
+	bitrateIndex := b[2] >> 4
+	rateIndex := (b[2] >> 2) & 0x03
+	if version == 1 || layerBits == 0 || bitrateIndex == 0 || bitrateIndex == 0x0F || rateIndex == 0x03 {
+		return mpegFrameHeader{}, false
+	}
+
+	h := mpegFrameHeader{
+		version:    version,
+		layer:      4 - int(layerBits),
+		sampleRate: mpegSampleRates[version][rateIndex],
+		padding:    b[2]&0x02 != 0,
+		mono:       b[3]>>6 == 0x03,
+	}
+	mpeg1 := 0
+	if version == 3 {
+		mpeg1 = 1
+	}
+	h.bitrate = mpegBitrates[mpeg1][h.layer-1][bitrateIndex] * 1000
+	return h, true
+}
+
+// samplesPerFrame returns the number of samples per channel in one frame
+func (h mpegFrameHeader) samplesPerFrame() int {
+	switch {
+	case h.layer == 1:
+		return 384
+	case h.layer == 3 && h.version != 3:
+		return 576
+	default:
+		return 1152
+	}
+}
+
+// frameSize returns the length of the frame in bytes, including the header
+func (h mpegFrameHeader) frameSize() int {
+	padding := 0
+	if h.padding {
+		padding = 1
+	}
+	if h.layer == 1 {
+		return (12*h.bitrate/h.sampleRate + padding) * 4
+	}
+	return h.samplesPerFrame()/8*h.bitrate/h.sampleRate + padding
+}
+
+// sideInfoSize returns the size of the Layer III side information, which
+// sits between the header and the Xing tag
+func (h mpegFrameHeader) sideInfoSize() int {
+	switch {
+	case h.version == 3 && h.mono:
+		return 17
+	case h.version == 3:
+		return 32
+	case h.mono:
+		return 9
+	default:
+		return 17
+	}
+}
+
+// sameStream reports whether two headers belong to the same stream, so a
+// resync doesn't lock onto random bytes that happen to look like a header
+func (h mpegFrameHeader) sameStream(other mpegFrameHeader) bool {
+	return h.version == other.version && h.layer == other.layer && h.sampleRate == other.sampleRate
+}
+
+// findMPEGFrame returns the offset of the first frame header in buf that
+// matches template and is followed by another matching header, or -1.
+// A header at the very end of buf is accepted without the second check.
+func findMPEGFrame(buf []byte, template *mpegFrameHeader) int {
+	for i := 0; i+4 <= len(buf); i++ {
+		h, ok := parseMPEGFrameHeader(buf[i:])
+		if !ok || (template != nil && !h.sameStream(*template)) {
+			continue
+		}
+		next := i + h.frameSize()
+		if next+4 > len(buf) {
+			return i
+		}
+		if nh, ok := parseMPEGFrameHeader(buf[next:]); ok && nh.sameStream(h) {
+			return i
+		}
+	}
+	return -1
+}
+
+// mp3VBRInfo holds what the Xing/Info or VBRI header in the first frame
+// says about the rest of the file
+type mp3VBRInfo struct {
+	frames    int // Audio frames, excluding the info frame itself
+	bytes     int // Stream size in bytes from the info frame on, 0 if unknown
+	frameSize int // Size of the info frame
+
+	// Xing seek table: toc[i] is the byte position of i% of the duration,
+	// as a fraction of bytes scaled to 0-255
+	toc []byte
+
+	// VBRI seek table: byte size of each run of vbriFramesPerEntry frames
+	vbriTOC            []int
+	vbriFramesPerEntry int
+
+	// LAME gapless info, in samples
+	encoderDelay   int
+	encoderPadding int
+	gapless        bool
+}
+
+// parseMP3VBRInfo reads a Xing/Info or VBRI header from the first frame,
+// given its header h. It returns nil if the frame is a normal audio frame.
+func parseMP3VBRInfo(frame []byte, h mpegFrameHeader) *mp3VBRInfo {
+	if h.layer != 3 {
+		return nil
+	}
+
+	var info *mp3VBRInfo
+	xingOffset := 4 + h.sideInfoSize()
+	if len(frame) >= xingOffset+8 {
+		tag := frame[xingOffset : xingOffset+4]
+		if bytes.Equal(tag, []byte("Xing")) || bytes.Equal(tag, []byte("Info")) {
+			info = parseXing(frame[xingOffset:])
+		}
+	}
+
+	// VBRI always follows 32 bytes of side info, whatever the channel mode
+	if info == nil && len(frame) >= 36+26 && bytes.Equal(frame[36:40], []byte("VBRI")) {
+		info = parseVBRI(frame[36:])
+	}
+
+	if info != nil {
+		info.frameSize = h.frameSize()
+	}
+	return info
+}
+
+// parseXing parses a Xing/Info tag and the LAME extension that may follow it
+func parseXing(b []byte) *mp3VBRInfo {
+	info := &mp3VBRInfo{}
+	flags := binary.BigEndian.Uint32(b[4:8])
+	pos := 8
+
+	if flags&0x01 != 0 {
+		if len(b) < pos+4 {
+			return nil
+		}
+		info.frames = int(binary.BigEndian.Uint32(b[pos:]))
+		pos += 4
+	}
+	if flags&0x02 != 0 {
+		if len(b) < pos+4 {
+			return nil
+		}
+		info.bytes = int(binary.BigEndian.Uint32(b[pos:]))
+		pos += 4
+	}
+	if flags&0x04 != 0 {
+		if len(b) < pos+100 {
+			return nil
+		}
+		info.toc = b[pos : pos+100]
+		pos += 100
+	}
+	if flags&0x08 != 0 {
+		pos += 4 // VBR quality
+	}
+
+	// LAME extension: 9-byte encoder string, then 12 bytes of settings, then
+	// encoder delay and padding packed as two 12-bit values. Lavc writes the
+	// same layout.
+	if len(b) >= pos+24 {
+		encoder := b[pos : pos+4]
+		if bytes.Equal(encoder, []byte("LAME")) || bytes.Equal(encoder, []byte("Lavc")) || bytes.Equal(encoder, []byte("Lavf")) {
+			packed := b[pos+21 : pos+24]
+			info.encoderDelay = int(packed[0])<<4 | int(packed[1])>>4
+			info.encoderPadding = int(packed[1]&0x0F)<<8 | int(packed[2])
+			info.gapless = true
+		}
+	}
+
+	return info
+}
+
+// parseVBRI parses a Fraunhofer VBRI tag. Its delay field isn't reliable
+// enough for gapless trimming, so it is ignored.
+func parseVBRI(b []byte) *mp3VBRInfo {
+	info := &mp3VBRInfo{
+		bytes:  int(binary.BigEndian.Uint32(b[10:14])),
+		frames: int(binary.BigEndian.Uint32(b[14:18])),
+	}
+
+	entries := int(binary.BigEndian.Uint16(b[18:20]))
+	scale := int(binary.BigEndian.Uint16(b[20:22]))
+	entrySize := int(binary.BigEndian.Uint16(b[22:24]))
+	info.vbriFramesPerEntry = int(binary.BigEndian.Uint16(b[24:26]))
+
+	if entrySize < 1 || entrySize > 4 || len(b) < 26+entries*entrySize {
+		return info
+	}
+	for i := 0; i < entries; i++ {
+		entry := 0
+		for _, c := range b[26+i*entrySize : 26+(i+1)*entrySize] {
+			entry = entry<<8 | int(c)
+		}
+		info.vbriTOC = append(info.vbriTOC, entry*scale)
+	}
+	return info
+}
+
+// seekPoint returns the byte offset, relative to the info frame, of an
+// audio frame at or before the given one, and the index of that frame.
+// Xing tables only have 1% resolution, so for them the offset is an
+// estimate to be refined by resyncing on the next frame header.
+func (info *mp3VBRInfo) seekPoint(frame int) (offset int64, landed int) {
+	if frame <= 0 || info.frames == 0 || info.bytes == 0 {
+		return int64(info.frameSize), 0
+	}
+
+	// VBRI entries are measured from the first audio frame
+	if len(info.vbriTOC) > 0 && info.vbriFramesPerEntry > 0 {
+		offset = int64(info.frameSize)
+		entry := min(frame/info.vbriFramesPerEntry, len(info.vbriTOC))
+		for _, size := range info.vbriTOC[:entry] {
+			offset += int64(size)
+		}
+		return offset, entry * info.vbriFramesPerEntry
+	}
+
+	percent := float64(frame) * 100 / float64(info.frames)
+	if len(info.toc) < 100 {
+		return int64(percent / 100 * float64(info.bytes)), frame
+	}
+
+	// Interpolate between TOC entries
+	i := min(int(percent), 99)
+	lower := float64(info.toc[i])
+	upper := 256.0
+	if i < 99 {
+		upper = float64(info.toc[i+1])
+	}
+	fraction := lower + (upper-lower)*(percent-float64(i))
+	return int64(fraction / 256 * float64(info.bytes)), frame
+}
@@ -0,0 +1,232 @@
+package audio
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/audio/decoders"
+	"github.com/cerberussg/auxbox/internal/shared"
+	"github.com/gopxl/beep/v2"
+)
+
+// MaxCrossfade is the longest supported crossfade between tracks
+const MaxCrossfade = 30 * time.Second
+
+// loadedTrack is an opened and decoded track, ready to be streamed
+type loadedTrack struct {
+	track      *shared.Track
+	streamer   beep.StreamSeekCloser
+	format     beep.Format
+	file       io.ReadCloser
+	metadata   decoders.Metadata
+	playback   beep.Streamer   // streamer converted to the speaker's sample rate
+	outputRate beep.SampleRate // Rate playback streams at
+
+	closeOnce sync.Once
+}
+
+// close releases the track's decoder and file. It is safe to call more than once.
+func (t *loadedTrack) close() {
+	t.closeOnce.Do(func() {
+		if t.streamer != nil {
+			t.streamer.Close()
+		}
+		if t.file != nil {
+			t.file.Close()
+		}
+	})
+}
+
+// samplesLeft returns how many output samples remain in the track, or -1 if
+// the decoder doesn't know its length
+func (t *loadedTrack) samplesLeft() int {
+	length := t.streamer.Len()
+	if length <= 0 || t.format.SampleRate == 0 {
+		return -1
+	}
+	left := length - t.streamer.Position()
+	return int(float64(left) * float64(t.outputRate) / float64(t.format.SampleRate))
+}
+
+// gaplessStreamer plays the current track and moves on to the queued next
+// track within the same buffer, so the speaker never sees a gap at the
+// boundary. With a crossfade length set, the next track starts that many
+// samples before the current one ends and the two are mixed.
+type gaplessStreamer struct {
+	mu      sync.Mutex
+	current *loadedTrack
+	next    *loadedTrack
+
+	// Crossfade state: outgoing is faded out under current
+	crossfade int // Length of automatic crossfades in output samples, 0 for none
+	outgoing  *loadedTrack
+	fadePos   int
+	fadeLen   int
+	fadeBuf   [][2]float64
+
+	onSwitch func(*loadedTrack) // Called in its own goroutine when next becomes current
+	onRetire func(*loadedTrack) // Called in its own goroutine when a track is no longer streamed
+}
+
+// newGaplessStreamer creates a gapless streamer starting with current
+func newGaplessStreamer(current *loadedTrack, onSwitch, onRetire func(*loadedTrack)) *gaplessStreamer {
+	return &gaplessStreamer{
+		current:  current,
+		onSwitch: onSwitch,
+		onRetire: onRetire,
+	}
+}
+
+// SetNext sets the track to continue with when the current one ends
+func (g *gaplessStreamer) SetNext(next *loadedTrack) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.next = next
+}
+
+// ClearNext removes expected as the next track. It returns false if the
+// streamer has already switched to it, or if another track is queued.
+func (g *gaplessStreamer) ClearNext(expected *loadedTrack) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.next != expected {
+		return false
+	}
+	g.next = nil
+	return true
+}
+
+// SetCrossfade sets the length of automatic crossfades in output samples
+func (g *gaplessStreamer) SetCrossfade(samples int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.crossfade = max(samples, 0)
+}
+
+// FadeTo makes track current right away, fading the old current track out
+// over the given number of samples
+func (g *gaplessStreamer) FadeTo(track *loadedTrack, samples int) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.outgoing != nil {
+		g.retire(g.outgoing)
+		g.outgoing = nil
+	}
+	if g.current == nil {
+		g.current = track
+		return
+	}
+	g.startFade(track, samples)
+}
+
+// TakeOutgoing returns the track being faded out, if any, and forgets it
+func (g *gaplessStreamer) TakeOutgoing() *loadedTrack {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	outgoing := g.outgoing
+	g.outgoing = nil
+	return outgoing
+}
+
+func (g *gaplessStreamer) Stream(samples [][2]float64) (n int, ok bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for n < len(samples) && g.current != nil {
+		if g.outgoing != nil {
+			n += g.streamFade(samples[n:])
+			continue
+		}
+
+		// Start the crossfade once the current track is within range of its end
+		chunk := samples[n:]
+		if g.next != nil && g.crossfade > 0 {
+			if left := g.current.samplesLeft(); left >= 0 && left <= g.crossfade {
+				next := g.next
+				g.next = nil
+				g.startFade(next, left)
+				g.notify(g.onSwitch, next)
+				continue
+			} else if left > g.crossfade {
+				chunk = chunk[:min(len(chunk), left-g.crossfade)]
+			}
+		}
+
+		streamed, ok := g.current.playback.Stream(chunk)
+		n += streamed
+		if ok && streamed > 0 {
+			continue
+		}
+
+		// Current track is done: switch at this exact sample, or stop
+		g.retire(g.current)
+		g.current = g.next
+		g.next = nil
+		if g.current != nil {
+			g.notify(g.onSwitch, g.current)
+		}
+	}
+
+	return n, n > 0
+}
+
+func (g *gaplessStreamer) Err() error {
+	return nil
+}
+
+// startFade makes track current and fades the old current track out over
+// the given number of samples (assumes lock is held)
+func (g *gaplessStreamer) startFade(track *loadedTrack, samples int) {
+	g.outgoing = g.current
+	g.current = track
+	g.fadePos = 0
+	g.fadeLen = max(samples, 1)
+}
+
+// streamFade mixes the incoming and outgoing tracks with equal-power gains,
+// so the loudness stays level through the fade (assumes lock is held)
+func (g *gaplessStreamer) streamFade(samples [][2]float64) int {
+	count := min(len(samples), g.fadeLen-g.fadePos)
+	in := samples[:count]
+	if len(g.fadeBuf) < count {
+		g.fadeBuf = make([][2]float64, count)
+	}
+	out := g.fadeBuf[:count]
+
+	// Either track may run out before the fade ends; treat it as silence
+	got, _ := g.current.playback.Stream(in)
+	clear(in[got:])
+	got, _ = g.outgoing.playback.Stream(out)
+	clear(out[got:])
+
+	for i := range in {
+		t := float64(g.fadePos+i) / float64(g.fadeLen) * math.Pi / 2
+		gainIn, gainOut := math.Sin(t), math.Cos(t)
+		in[i][0] = in[i][0]*gainIn + out[i][0]*gainOut
+		in[i][1] = in[i][1]*gainIn + out[i][1]*gainOut
+	}
+
+	g.fadePos += count
+	if g.fadePos >= g.fadeLen {
+		g.retire(g.outgoing)
+		g.outgoing = nil
+	}
+	return count
+}
+
+// retire reports that a track is no longer streamed (assumes lock is held)
+func (g *gaplessStreamer) retire(track *loadedTrack) {
+	g.notify(g.onRetire, track)
+}
+
+// notify runs a callback without blocking the speaker
+func (g *gaplessStreamer) notify(callback func(*loadedTrack), track *loadedTrack) {
+	if callback != nil && track != nil {
+		go callback(track)
+	}
+}
@@ -0,0 +1,187 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// rampStreamer streams count samples whose values are start, start+1, ...
+func rampStreamer(start, count int) beep.Streamer {
+	i := 0
+	return beep.StreamerFunc(func(samples [][2]float64) (n int, ok bool) {
+		for n < len(samples) && i < count {
+			v := float64(start + i)
+			samples[n] = [2]float64{v, v}
+			n++
+			i++
+		}
+		return n, n > 0
+	})
+}
+
+// rampTrack wraps a rampStreamer as a track without a decoder
+func rampTrack(start, count int) *loadedTrack {
+	return &loadedTrack{playback: rampStreamer(start, count)}
+}
+
+// constDecoder is a seekable stream of count samples that all equal value
+type constDecoder struct {
+	value      float64
+	pos, count int
+}
+
+func (d *constDecoder) Stream(samples [][2]float64) (n int, ok bool) {
+	for n < len(samples) && d.pos < d.count {
+		samples[n] = [2]float64{d.value, d.value}
+		n++
+		d.pos++
+	}
+	return n, n > 0
+}
+
+func (d *constDecoder) Err() error       { return nil }
+func (d *constDecoder) Len() int         { return d.count }
+func (d *constDecoder) Position() int    { return d.pos }
+func (d *constDecoder) Seek(p int) error { d.pos = p; return nil }
+func (d *constDecoder) Close() error     { return nil }
+
+// constTrack returns a track of count samples that all equal value
+func constTrack(value float64, count int) *loadedTrack {
+	d := &constDecoder{value: value, count: count}
+	return &loadedTrack{
+		streamer:   d,
+		playback:   d,
+		format:     beep.Format{SampleRate: 44100, NumChannels: 2, Precision: 2},
+		outputRate: 44100,
+	}
+}
+
+func TestGaplessStreamer_SwitchesAtBoundary(t *testing.T) {
+	switched := make(chan *loadedTrack, 1)
+	queue := newGaplessStreamer(rampTrack(0, 100), func(next *loadedTrack) {
+		switched <- next
+	}, nil)
+
+	next := rampTrack(100, 50)
+	queue.SetNext(next)
+
+	// One buffer spans the boundary and must be filled without a gap
+	buf := make([][2]float64, 128)
+	n, ok := queue.Stream(buf)
+	if n != 128 || !ok {
+		t.Fatalf("Stream() = %d, %v, want 128, true", n, ok)
+	}
+	for i, sample := range buf {
+		if sample[0] != float64(i) {
+			t.Fatalf("Sample %d = %v, want %d", i, sample[0], i)
+		}
+	}
+
+	select {
+	case got := <-switched:
+		if got != next {
+			t.Error("onSwitch called with the wrong track")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("onSwitch was not called")
+	}
+
+	// The rest of the next track, then the end of the stream
+	n, _ = queue.Stream(buf)
+	if n != 22 {
+		t.Errorf("Stream() after switch = %d, want 22", n)
+	}
+	if n, ok := queue.Stream(buf); n != 0 || ok {
+		t.Errorf("Stream() at end = %d, %v, want 0, false", n, ok)
+	}
+}
+
+func TestGaplessStreamer_ClearNext(t *testing.T) {
+	queue := newGaplessStreamer(rampTrack(0, 10), nil, nil)
+	next := rampTrack(10, 10)
+	queue.SetNext(next)
+
+	if !queue.ClearNext(next) {
+		t.Fatal("ClearNext() should remove the queued track")
+	}
+
+	// Without a next track the stream ends with the current one
+	buf := make([][2]float64, 32)
+	if n, _ := queue.Stream(buf); n != 10 {
+		t.Errorf("Stream() = %d, want 10", n)
+	}
+
+	// Once the stream has switched, the track can no longer be cleared
+	queue = newGaplessStreamer(rampTrack(0, 10), nil, nil)
+	queue.SetNext(next)
+	queue.Stream(buf)
+	if queue.ClearNext(next) {
+		t.Error("ClearNext() should fail after the stream switched to the track")
+	}
+}
+
+func TestGaplessStreamer_Crossfade(t *testing.T) {
+	current := constTrack(1, 100)
+	retired := make(chan *loadedTrack, 1)
+	queue := newGaplessStreamer(current, nil, func(track *loadedTrack) {
+		retired <- track
+	})
+	queue.SetCrossfade(20)
+	queue.SetNext(constTrack(0, 100))
+
+	buf := make([][2]float64, 128)
+	if n, ok := queue.Stream(buf); n != 128 || !ok {
+		t.Fatalf("Stream() = %d, %v, want 128, true", n, ok)
+	}
+
+	// The current track plays alone until 20 samples before its end, then
+	// fades out with a cosine gain while the silent next track fades in
+	for i, sample := range buf {
+		want := 1.0
+		switch {
+		case i >= 100:
+			want = 0
+		case i >= 80:
+			want = math.Cos(float64(i-80) / 20 * math.Pi / 2)
+		}
+		if math.Abs(sample[0]-want) > 1e-9 {
+			t.Fatalf("Sample %d = %v, want %v", i, sample[0], want)
+		}
+	}
+
+	select {
+	case got := <-retired:
+		if got != current {
+			t.Error("onRetire called with the wrong track")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("onRetire was not called after the fade")
+	}
+
+	// The next track started during the fade, so 52 of its samples are left
+	if n, _ := queue.Stream(buf); n != 52 {
+		t.Errorf("Stream() after fade = %d, want 52", n)
+	}
+}
+
+func TestGaplessStreamer_FadeTo(t *testing.T) {
+	queue := newGaplessStreamer(constTrack(1, 1000), nil, nil)
+	queue.FadeTo(constTrack(1, 1000), 10)
+
+	// Equal-power gains keep the level up through the fade: two equal
+	// signals sum to sqrt(2) at the midpoint, not 1
+	buf := make([][2]float64, 20)
+	queue.Stream(buf)
+	if got := buf[5][0]; math.Abs(got-math.Sqrt2) > 1e-9 {
+		t.Errorf("Midpoint sample = %v, want %v", got, math.Sqrt2)
+	}
+	if got := buf[15][0]; got != 1 {
+		t.Errorf("Sample after fade = %v, want 1", got)
+	}
+	if queue.TakeOutgoing() != nil {
+		t.Error("Outgoing track should be dropped once the fade ends")
+	}
+}
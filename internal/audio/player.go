@@ -2,7 +2,6 @@ package audio
 
 import (
 	"fmt"
-	"io"
 	"os"
 	"sync"
 	"time"
@@ -22,11 +21,31 @@ type Player struct {
 	// Core audio components
 	streamer beep.StreamSeekCloser
 	format   beep.Format
-	file     io.ReadCloser
+	current  *loadedTrack      // Owns the decoder and file of the current track
+	metadata decoders.Metadata // Tags read by the decoder, if the format has any
+
+	// Quality used when a track has to be resampled to the speaker's rate
+	resampleQuality int
+
+	// Gapless playback: the next track is decoded ahead of time and queued
+	// on the current stream so the speaker switches without a gap
+	gapless      bool
+	gaplessQueue *gaplessStreamer
+	preloaded    *loadedTrack
+	nextTrack    func() *shared.Track      // Supplies the track to preload
+	onAdvance    func(track *shared.Track) // Called after a gapless switch
+
+	// Crossfade: tracks overlap by this long on auto-advance, and also on
+	// skip/back when crossfadeManual is set. Implies preloading.
+	crossfade       time.Duration
+	crossfadeManual bool
 
 	// Callback for track completion
 	onTrackComplete func()
 
+	// Called in its own goroutine when a track fails to load
+	onError func(track *shared.Track, err error)
+
 	// Extracted components
 	audioSystem     *AudioSystem
 	volumeControl   *VolumeControl
@@ -48,6 +67,7 @@ func NewPlayer() *Player {
 		volumeControl:   NewVolumeControl(),
 		positionTracker: NewPositionTracker(),
 		registry:        decoders.NewFormatRegistry(),
+		resampleQuality: DefaultResampleQuality,
 	}
 }
 
@@ -65,6 +85,13 @@ func (p *Player) stopAndCleanup() {
 
 	// Clean up resources
 	p.cleanup()
+	p.closePreload()
+	if p.gaplessQueue != nil {
+		if outgoing := p.gaplessQueue.TakeOutgoing(); outgoing != nil {
+			outgoing.close()
+		}
+		p.gaplessQueue = nil
+	}
 
 	// Reset status
 	p.status.IsPlaying = false
@@ -181,11 +208,79 @@ func (p *Player) Stop() error {
 	return nil
 }
 
+// Seek moves playback of the current track to position, clamped to the track
+func (p *Player) Seek(position time.Duration) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.streamer == nil {
+		return fmt.Errorf("no track loaded")
+	}
+
+	sample := max(0, p.format.SampleRate.N(position))
+	if length := p.streamer.Len(); length > 0 {
+		sample = min(sample, length)
+	}
+
+	// The speaker reads from the decoder on its own goroutine
+	speaker.Lock()
+	err := p.streamer.Seek(sample)
+	speaker.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to seek: %w", err)
+	}
+
+	p.positionTracker.Refresh()
+	p.status.Position = p.positionTracker.GetPositionString()
+	return nil
+}
+
 // SetCurrentTrack sets the track to be played
 func (p *Player) SetCurrentTrack(track *shared.Track) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.setCurrentTrack(track)
+}
+
+// SkipTo sets the track to be played like SetCurrentTrack, but crossfades
+// from the playing track when crossfade on skip/back is enabled
+func (p *Player) SkipTo(track *shared.Track) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if track == nil || p.crossfade == 0 || !p.crossfadeManual || !p.status.IsPlaying || p.gaplessQueue == nil {
+		p.setCurrentTrack(track)
+		return
+	}
+
+	if !p.gaplessQueue.ClearNext(p.preloaded) {
+		// The stream is switching tracks right now, so cut over instead
+		p.setCurrentTrack(track)
+		return
+	}
+	p.closePreload()
+
+	fmt.Printf("Loading audio file: %s\n", track.Path)
+	loaded, err := p.openTrack(track.Path)
+	if err != nil {
+		// Let the regular path report the error and reset the player
+		p.setCurrentTrack(track)
+		return
+	}
+	loaded.track = track
+	p.playbackStreamer(loaded)
+
+	// The old track keeps playing under the fade; handleRetire closes it
+	p.gaplessQueue.FadeTo(loaded, p.crossfadeSamples())
+	p.promote(loaded)
+	p.status.Position = "0:00"
+	fmt.Printf("Crossfading to: %s\n", track.Path)
 
+	p.refreshPreload()
+}
+
+// setCurrentTrack sets the track to be played (assumes lock is held)
+func (p *Player) setCurrentTrack(track *shared.Track) {
 	// Remember if we were playing
 	wasPlaying := p.status.IsPlaying
 
@@ -197,6 +292,8 @@ func (p *Player) SetCurrentTrack(track *shared.Track) {
 	p.status.IsPlaying = false
 	p.status.IsPaused = false
 
+	p.status.SampleRate = 0
+
 	if track == nil {
 		p.status.Duration = "0:00"
 		return
@@ -206,12 +303,17 @@ func (p *Player) SetCurrentTrack(track *shared.Track) {
 	fmt.Printf("Loading audio file: %s\n", track.Path)
 	if err := p.loadAudioFile(track.Path); err != nil {
 		fmt.Printf("Error loading audio file %s: %v\n", track.Path, err)
+		if p.onError != nil {
+			go p.onError(track, err)
+		}
 		p.status.Duration = "0:00"
 		p.currentTrack = nil // Reset track on failure
 		return
 	}
 	fmt.Printf("Audio file loaded successfully: %s\n", track.Path)
 
+	p.refreshPreload()
+
 	// If we were playing before, start playing the new track
 	if wasPlaying {
 		p.playInternal()
@@ -225,6 +327,13 @@ func (p *Player) GetCurrentTrack() *shared.Track {
 	return p.currentTrack
 }
 
+// GetMetadata returns the tag metadata of the current track, or nil if it has none
+func (p *Player) GetMetadata() decoders.Metadata {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.metadata
+}
+
 // GetStatus returns the current player status
 func (p *Player) GetStatus() PlayerStatus {
 	p.mu.RLock()
@@ -238,6 +347,70 @@ func (p *Player) GetStatus() PlayerStatus {
 	return status
 }
 
+// SetGaplessSource sets the callbacks used in gapless mode: nextTrack returns
+// the track to preload after the current one (nil for none), and onAdvance is
+// called once playback has moved on to that track
+func (p *Player) SetGaplessSource(nextTrack func() *shared.Track, onAdvance func(track *shared.Track)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.nextTrack = nextTrack
+	p.onAdvance = onAdvance
+}
+
+// SetGapless enables or disables gapless playback
+func (p *Player) SetGapless(enabled bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gapless = enabled
+	p.refreshPreload()
+}
+
+// IsGapless returns true if gapless playback is enabled
+func (p *Player) IsGapless() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.gapless
+}
+
+// SetCrossfade sets how long tracks overlap on auto-advance, and whether
+// skip and back crossfade too. Zero turns crossfading off.
+func (p *Player) SetCrossfade(duration time.Duration, manual bool) error {
+	if duration < 0 || duration > MaxCrossfade {
+		return fmt.Errorf("crossfade must be between 0 and %v", MaxCrossfade)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.crossfade = duration
+	p.crossfadeManual = manual && duration > 0
+	if p.gaplessQueue != nil {
+		p.gaplessQueue.SetCrossfade(p.crossfadeSamples())
+	}
+	p.refreshPreload()
+	return nil
+}
+
+// GetCrossfade returns the crossfade duration and whether it applies to skip/back
+func (p *Player) GetCrossfade() (time.Duration, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.crossfade, p.crossfadeManual
+}
+
+// crossfadeSamples returns the crossfade duration in output samples
+// (assumes lock is held)
+func (p *Player) crossfadeSamples() int {
+	return p.audioSystem.SampleRate().N(p.crossfade)
+}
+
+// RefreshPreload replaces the preloaded track, e.g. after shuffle or repeat
+// changes which track comes next
+func (p *Player) RefreshPreload() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.refreshPreload()
+}
+
 // SetOnTrackComplete sets the callback function to be called when a track finishes
 func (p *Player) SetOnTrackComplete(callback func()) {
 	p.mu.Lock()
@@ -245,6 +418,13 @@ func (p *Player) SetOnTrackComplete(callback func()) {
 	p.onTrackComplete = callback
 }
 
+// SetOnError sets the callback function to be called when a track fails to load
+func (p *Player) SetOnError(callback func(track *shared.Track, err error)) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.onError = callback
+}
+
 // SetVolume sets the playback volume (0.0 to 1.0)
 func (p *Player) SetVolume(volume float64) error {
 	p.mu.Lock()
@@ -254,6 +434,25 @@ func (p *Player) SetVolume(volume float64) error {
 	return p.volumeControl.SetVolume(volume)
 }
 
+// SetResampleQuality sets the resampler quality used for tracks loaded from now on
+func (p *Player) SetResampleQuality(quality int) error {
+	if err := ValidateResampleQuality(quality); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resampleQuality = quality
+	return nil
+}
+
+// GetResampleQuality returns the current resampler quality
+func (p *Player) GetResampleQuality() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.resampleQuality
+}
+
 // GetPosition returns the current playback position
 func (p *Player) GetPosition() time.Duration {
 	return p.positionTracker.GetPosition()
@@ -283,6 +482,7 @@ func (p *Player) Close() error {
 	p.positionTracker.Cleanup()
 	p.volumeControl.Cleanup()
 	p.cleanup()
+	p.closePreload()
 
 	return nil
 }
@@ -303,22 +503,16 @@ func (p *Player) IsPaused() bool {
 
 // loadAudioFile loads an audio file and prepares it for playback
 func (p *Player) loadAudioFile(filePath string) error {
-	// Open the file
-	file, err := os.Open(filePath)
-	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
-	}
-
-	// Use the format registry to decode the file
-	streamer, format, err := p.registry.Decode(filePath, file)
+	loaded, err := p.openTrack(filePath)
 	if err != nil {
-		file.Close()
 		return err
 	}
 
-	p.streamer = streamer
-	p.format = format
-	p.file = file
+	p.current = loaded
+	p.streamer = loaded.streamer
+	p.format = loaded.format
+	p.metadata = loaded.metadata
+	format := loaded.format
 
 	// Initialize speaker if not already done
 	if !p.audioSystem.IsInitialized() {
@@ -329,12 +523,21 @@ func (p *Player) loadAudioFile(filePath string) error {
 		}
 	}
 
-	// Set up position tracking
-	p.positionTracker.SetStreamer(streamer, format)
+	// Position is tracked on the decoder, in the track's own sample rate
+	p.positionTracker.SetStreamer(loaded.streamer, format)
+
+	p.playbackStreamer(loaded)
+	p.status.SampleRate = int(format.SampleRate)
+	p.status.OutputRate = int(p.audioSystem.SampleRate())
+
+	// The gapless queue sits under volume control so the volume carries over
+	// from one track to the next
+	p.gaplessQueue = newGaplessStreamer(loaded, p.handleGaplessSwitch, p.handleRetire)
+	p.gaplessQueue.SetCrossfade(p.crossfadeSamples())
 
 	// Set up volume control
 	fmt.Printf("Setting up volume control...\n")
-	ctrl := p.volumeControl.SetupWithStreamer(streamer, p.onTrackComplete)
+	ctrl := p.volumeControl.SetupWithStreamer(p.gaplessQueue, p.onTrackComplete)
 
 	// Verify control was set up properly
 	if ctrl == nil {
@@ -345,14 +548,130 @@ func (p *Player) loadAudioFile(filePath string) error {
 	return nil
 }
 
+// openTrack opens and decodes an audio file
+func (p *Player) openTrack(filePath string) (*loadedTrack, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	// Use the format registry to decode the file
+	streamer, format, err := p.registry.Decode(filePath, file)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	loaded := &loadedTrack{
+		streamer: streamer,
+		format:   format,
+		file:     file,
+	}
+	if tagged, ok := streamer.(decoders.MetadataStreamer); ok {
+		loaded.metadata = tagged.Metadata()
+	}
+	return loaded, nil
+}
+
+// playbackStreamer returns the track's stream at the speaker's rate. The
+// speaker runs at a single rate, so tracks that differ from it are resampled.
+func (p *Player) playbackStreamer(loaded *loadedTrack) beep.Streamer {
+	outputRate := p.audioSystem.SampleRate()
+	if loaded.format.SampleRate != outputRate {
+		fmt.Printf("Resampling %d Hz -> %d Hz (quality %d)\n", loaded.format.SampleRate, outputRate, p.resampleQuality)
+	}
+	loaded.playback = resampleForSpeaker(loaded.streamer, loaded.format.SampleRate, outputRate, p.resampleQuality)
+	loaded.outputRate = outputRate
+	return loaded.playback
+}
+
+// refreshPreload drops the preloaded track and, in gapless or crossfade
+// mode, decodes the next one and queues it behind the current track (assumes lock is held)
+func (p *Player) refreshPreload() {
+	if p.gaplessQueue != nil && !p.gaplessQueue.ClearNext(p.preloaded) {
+		// The stream has already moved on to the preloaded track;
+		// handleGaplessSwitch takes it over and preloads the one after
+		return
+	}
+	p.closePreload()
+
+	if (!p.gapless && p.crossfade == 0) || p.nextTrack == nil || p.currentTrack == nil || p.gaplessQueue == nil {
+		return
+	}
+
+	track := p.nextTrack()
+	if track == nil {
+		return
+	}
+
+	loaded, err := p.openTrack(track.Path)
+	if err != nil {
+		// Auto-advance falls back to loading it when the current track ends
+		fmt.Printf("Error preloading %s: %v\n", track.Path, err)
+		return
+	}
+	loaded.track = track
+	p.playbackStreamer(loaded)
+
+	p.preloaded = loaded
+	p.gaplessQueue.SetNext(loaded)
+	fmt.Printf("Preloaded next track: %s\n", track.Path)
+}
+
+// handleGaplessSwitch makes the preloaded track current once the stream has
+// switched to it
+func (p *Player) handleGaplessSwitch(next *loadedTrack) {
+	p.mu.Lock()
+	if p.preloaded != next {
+		// A manual track change got there first and closed it
+		p.mu.Unlock()
+		return
+	}
+
+	// The old track may still be fading out; handleRetire closes it
+	p.preloaded = nil
+	p.promote(next)
+	onAdvance := p.onAdvance
+	p.mu.Unlock()
+
+	// Let the owner move its queue forward before picking the track after
+	if onAdvance != nil {
+		onAdvance(next.track)
+	}
+	p.RefreshPreload()
+}
+
+// promote makes an opened track the current one without touching the
+// stream, which has already moved on to it (assumes lock is held)
+func (p *Player) promote(loaded *loadedTrack) {
+	p.currentTrack = loaded.track
+	p.current = loaded
+	p.streamer = loaded.streamer
+	p.format = loaded.format
+	p.metadata = loaded.metadata
+	p.positionTracker.SetStreamer(loaded.streamer, loaded.format)
+	p.status.SampleRate = int(loaded.format.SampleRate)
+}
+
+// handleRetire closes a track once the stream no longer reads from it
+func (p *Player) handleRetire(track *loadedTrack) {
+	track.close()
+}
+
+// closePreload closes the preloaded track, if any (assumes lock is held)
+func (p *Player) closePreload() {
+	if p.preloaded != nil {
+		p.preloaded.close()
+		p.preloaded = nil
+	}
+}
+
 // cleanup cleans up audio resources
 func (p *Player) cleanup() {
-	if p.streamer != nil {
-		p.streamer.Close()
-		p.streamer = nil
-	}
-	if p.file != nil {
-		p.file.Close()
-		p.file = nil
+	if p.current != nil {
+		p.current.close()
+		p.current = nil
 	}
+	p.streamer = nil
+	p.metadata = nil
 }
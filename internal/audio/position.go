@@ -94,6 +94,17 @@ func (pt *PositionTracker) ResetPosition() {
 	pt.position = "0:00"
 }
 
+// Refresh updates the position right away instead of on the next tick,
+// e.g. after a seek
+func (pt *PositionTracker) Refresh() {
+	pt.mu.Lock()
+	defer pt.mu.Unlock()
+
+	if pt.streamer != nil {
+		pt.position = FormatDuration(pt.format.SampleRate.D(pt.streamer.Position()))
+	}
+}
+
 // GetPosition returns the current playback position
 func (pt *PositionTracker) GetPosition() time.Duration {
 	pt.mu.RLock()
@@ -0,0 +1,33 @@
+package audio
+
+import (
+	"fmt"
+
+	"github.com/gopxl/beep/v2"
+)
+
+// Resampler quality bounds. Quality trades CPU for fidelity: 1 is fast and
+// rough, 3-4 is good for on-the-fly playback, 6 and above is very good but
+// costly. beep accepts up to 64, but anything above 16 wastes CPU.
+const (
+	MinResampleQuality     = 1
+	MaxResampleQuality     = 16
+	DefaultResampleQuality = 4
+)
+
+// ValidateResampleQuality checks that quality is within the supported range
+func ValidateResampleQuality(quality int) error {
+	if quality < MinResampleQuality || quality > MaxResampleQuality {
+		return fmt.Errorf("resample quality must be between %d and %d", MinResampleQuality, MaxResampleQuality)
+	}
+	return nil
+}
+
+// resampleForSpeaker wraps streamer in a resampler when its sample rate differs
+// from the speaker's. The streamer is returned unchanged when the rates match.
+func resampleForSpeaker(streamer beep.Streamer, source, output beep.SampleRate, quality int) beep.Streamer {
+	if source == output || output == 0 {
+		return streamer
+	}
+	return beep.Resample(quality, source, output, streamer)
+}
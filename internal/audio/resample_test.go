@@ -0,0 +1,49 @@
+package audio
+
+import (
+	"testing"
+
+	"github.com/gopxl/beep/v2"
+	"github.com/gopxl/beep/v2/generators"
+)
+
+func TestResampleForSpeaker(t *testing.T) {
+	source := generators.Silence(48000)
+
+	// Matching rates pass the streamer through untouched
+	if _, ok := resampleForSpeaker(source, 44100, 44100, DefaultResampleQuality).(*beep.Resampler); ok {
+		t.Error("Streamer should not be wrapped when rates match")
+	}
+
+	// A 48 kHz track on a 44.1 kHz speaker is converted to the speaker's length
+	resampled := resampleForSpeaker(source, 48000, 44100, DefaultResampleQuality)
+	if _, ok := resampled.(*beep.Resampler); !ok {
+		t.Fatalf("Expected a *beep.Resampler, got %T", resampled)
+	}
+
+	buf := make([][2]float64, 4096)
+	total := 0
+	for {
+		n, ok := resampled.Stream(buf)
+		total += n
+		if !ok {
+			break
+		}
+	}
+	if total < 44000 || total > 44200 {
+		t.Errorf("One second at 48 kHz should resample to ~44100 samples, got %d", total)
+	}
+}
+
+func TestValidateResampleQuality(t *testing.T) {
+	for _, q := range []int{MinResampleQuality, DefaultResampleQuality, MaxResampleQuality} {
+		if err := ValidateResampleQuality(q); err != nil {
+			t.Errorf("Quality %d should be valid: %v", q, err)
+		}
+	}
+	for _, q := range []int{0, -1, MaxResampleQuality + 1} {
+		if err := ValidateResampleQuality(q); err == nil {
+			t.Errorf("Quality %d should be rejected", q)
+		}
+	}
+}
@@ -12,6 +12,7 @@ import (
 // AudioSystem manages speaker initialization and audio hardware setup
 type AudioSystem struct {
 	initialized bool
+	sampleRate  beep.SampleRate // Rate the speaker was actually opened at
 }
 
 // NewAudioSystem creates a new audio system manager
@@ -38,6 +39,7 @@ func (a *AudioSystem) Initialize(format beep.Format) error {
 		fmt.Printf("Audio initialized successfully with sample rate: %d Hz, buffer size: %d\n",
 			format.SampleRate, bufferSize)
 		a.initialized = true
+		a.sampleRate = format.SampleRate
 		return nil
 	}
 
@@ -50,6 +52,7 @@ func (a *AudioSystem) Initialize(format beep.Format) error {
 		fmt.Printf("Audio initialized with larger buffer: %d Hz, buffer size: %d\n",
 			format.SampleRate, bufferSize)
 		a.initialized = true
+		a.sampleRate = format.SampleRate
 		return nil
 	}
 
@@ -68,6 +71,7 @@ func (a *AudioSystem) Initialize(format beep.Format) error {
 			fmt.Printf("Audio initialized with fallback sample rate: %d Hz (original: %d Hz)\n",
 				rate, format.SampleRate)
 			a.initialized = true
+			a.sampleRate = rate
 			return nil
 		}
 		fmt.Printf("Fallback rate %d Hz failed: %v\n", rate, err)
@@ -84,6 +88,12 @@ func (a *AudioSystem) IsInitialized() bool {
 	return a.initialized
 }
 
+// SampleRate returns the rate the speaker runs at, or 0 if not yet initialized.
+// This may differ from the first track's rate if a fallback rate was used.
+func (a *AudioSystem) SampleRate() beep.SampleRate {
+	return a.sampleRate
+}
+
 // getPlatformAudioHint provides platform-specific troubleshooting information
 func (a *AudioSystem) getPlatformAudioHint() string {
 	switch runtime.GOOS {
@@ -7,4 +7,7 @@ type PlayerStatus struct {
 	Position  string  `json:"position"` // Current playback position (e.g. "2:34")
 	Duration  string  `json:"duration"` // Total track duration (e.g. "4:12")
 	Volume    float64 `json:"volume"`   // Volume level 0.0 - 1.0
+
+	SampleRate int `json:"sample_rate,omitempty"` // Decoded track's sample rate in Hz
+	OutputRate int `json:"output_rate,omitempty"` // Speaker sample rate in Hz
 }
\ No newline at end of file
@@ -24,7 +24,7 @@ func NewVolumeControl() *VolumeControl {
 }
 
 // SetupWithStreamer creates the volume control wrapper around a streamer
-func (v *VolumeControl) SetupWithStreamer(streamer beep.StreamSeekCloser, onTrackComplete func()) *beep.Ctrl {
+func (v *VolumeControl) SetupWithStreamer(streamer beep.Streamer, onTrackComplete func()) *beep.Ctrl {
 	v.mu.Lock()
 	defer v.mu.Unlock()
 
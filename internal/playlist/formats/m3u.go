@@ -0,0 +1,169 @@
+package formats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// utf8BOM is the byte order mark some editors prepend to UTF-8 playlists
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// extInf holds the metadata from an #EXTINF line
+type extInf struct {
+	title    string
+	duration int // Length in seconds, -1 if unknown
+}
+
+// SniffM3U detects extended M3U playlists by their #EXTM3U header.
+// Plain M3U has no signature and is only recognised by extension.
+func SniffM3U(header []byte) bool {
+	return strings.HasPrefix(strings.ToUpper(firstLine(header)), "#EXTM3U")
+}
+
+// ReadM3U parses plain M3U and extended M3U8 playlists.
+// Lines that are not valid UTF-8 are treated as Latin-1, which is what
+// older players wrote into .m3u files.
+func ReadM3U(r io.Reader) ([]*shared.Track, error) {
+	scanner := bufio.NewScanner(r)
+	// Allow long lines for deeply nested paths
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var tracks []*shared.Track
+	var pending *extInf // Info from the last #EXTINF, applied to the next path
+	atStart := true
+
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if atStart {
+			raw = bytes.TrimPrefix(raw, utf8BOM)
+			atStart = false
+		}
+
+		line := strings.TrimSpace(decodeLine(raw))
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "#") {
+			if strings.HasPrefix(line, "#EXTINF:") {
+				info := parseExtInf(line[len("#EXTINF:"):])
+				pending = &info
+			}
+			// #EXTM3U and any other directives are ignored
+			continue
+		}
+
+		track := &shared.Track{Path: line}
+		if pending != nil {
+			track.Title = pending.title
+			track.Duration = FormatDuration(pending.duration)
+			pending = nil
+		}
+		tracks = append(tracks, track)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read M3U playlist: %w", err)
+	}
+
+	return tracks, nil
+}
+
+// parseExtInf parses the "<seconds>[ attributes],<title>" part of an #EXTINF line
+func parseExtInf(info string) extInf {
+	entry := extInf{duration: -1}
+
+	header, title, found := strings.Cut(info, ",")
+	if found {
+		entry.title = strings.TrimSpace(title)
+	}
+
+	// The duration may be followed by key="value" attributes (IPTV-style)
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		return entry
+	}
+
+	if seconds, err := strconv.ParseFloat(fields[0], 64); err == nil && seconds >= 0 {
+		entry.duration = int(seconds + 0.5)
+	}
+
+	return entry
+}
+
+// decodeLine returns the line as a string, converting from Latin-1 if it isn't valid UTF-8
+func decodeLine(raw []byte) string {
+	if utf8.Valid(raw) {
+		return string(raw)
+	}
+
+	runes := make([]rune, len(raw))
+	for i, b := range raw {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// WriteM3U writes tracks as an extended M3U8 playlist
+func WriteM3U(w io.Writer, tracks []*shared.Track) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "#EXTM3U")
+	for _, track := range tracks {
+		fmt.Fprintf(bw, "#EXTINF:%d,%s\n", ParseDuration(track.Duration), displayTitle(track))
+		fmt.Fprintln(bw, track.Path)
+	}
+
+	return bw.Flush()
+}
+
+// FormatDuration converts seconds to the M:SS format used by shared.Track
+func FormatDuration(seconds int) string {
+	if seconds < 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// ParseDuration converts an M:SS (or H:MM:SS) duration to seconds, -1 if unknown
+func ParseDuration(duration string) int {
+	if duration == "" {
+		return -1
+	}
+
+	seconds := 0
+	for _, part := range strings.Split(duration, ":") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return -1
+		}
+		seconds = seconds*60 + n
+	}
+	return seconds
+}
+
+// displayTitle returns the track title, falling back to the filename without extension
+func displayTitle(track *shared.Track) string {
+	if track.Title != "" {
+		return track.Title
+	}
+
+	name := track.Filename
+	if name == "" {
+		name = track.Path
+		if idx := strings.LastIndexAny(name, "/\\"); idx >= 0 {
+			name = name[idx+1:]
+		}
+	}
+	if idx := strings.LastIndex(name, "."); idx > 0 {
+		name = name[:idx]
+	}
+	return name
+}
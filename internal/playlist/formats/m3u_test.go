@@ -0,0 +1,99 @@
+package formats
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadM3U_Extended(t *testing.T) {
+	input := "\xEF\xBB\xBF#EXTM3U\n" +
+		"#EXTINF:245,Artist - First Track\n" +
+		"music/first.mp3\n" +
+		"\n" +
+		"#EXTINF:-1,Unknown Length\n" +
+		"/abs/second.wav\n" +
+		"third.aiff\n"
+
+	tracks, err := ReadM3U(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadM3U() failed: %v", err)
+	}
+
+	if len(tracks) != 3 {
+		t.Fatalf("Expected 3 tracks, got %d", len(tracks))
+	}
+
+	if tracks[0].Path != "music/first.mp3" {
+		t.Errorf("Entry 0 path = %q, want %q", tracks[0].Path, "music/first.mp3")
+	}
+	if tracks[0].Title != "Artist - First Track" {
+		t.Errorf("Entry 0 title = %q, want %q", tracks[0].Title, "Artist - First Track")
+	}
+	if tracks[0].Duration != "4:05" {
+		t.Errorf("Entry 0 duration = %q, want %q", tracks[0].Duration, "4:05")
+	}
+
+	if tracks[1].Duration != "" {
+		t.Errorf("Entry 1 duration should be unknown, got %q", tracks[1].Duration)
+	}
+
+	// #EXTINF only applies to the path that follows it
+	if tracks[2].Title != "" || tracks[2].Duration != "" {
+		t.Errorf("Entry 2 should have no metadata, got title %q duration %q", tracks[2].Title, tracks[2].Duration)
+	}
+}
+
+func TestReadM3U_Attributes(t *testing.T) {
+	input := "#EXTM3U\n#EXTINF:180.6 tvg-id=\"x\" group-title=\"Sets\",Live Set\nset.mp3\n"
+
+	tracks, err := ReadM3U(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadM3U() failed: %v", err)
+	}
+
+	if len(tracks) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(tracks))
+	}
+	if tracks[0].Duration != "3:01" {
+		t.Errorf("Duration = %q, want %q", tracks[0].Duration, "3:01")
+	}
+	if tracks[0].Title != "Live Set" {
+		t.Errorf("Title = %q, want %q", tracks[0].Title, "Live Set")
+	}
+}
+
+func TestReadM3U_Latin1(t *testing.T) {
+	// "Café.mp3" encoded as Latin-1
+	input := "Caf\xE9.mp3\n"
+
+	tracks, err := ReadM3U(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ReadM3U() failed: %v", err)
+	}
+
+	if len(tracks) != 1 {
+		t.Fatalf("Expected 1 entry, got %d", len(tracks))
+	}
+	if tracks[0].Path != "Café.mp3" {
+		t.Errorf("Expected Latin-1 line to decode to %q, got %q", "Café.mp3", tracks[0].Path)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		seconds int
+		want    string
+	}{
+		{0, "0:00"},
+		{59, "0:59"},
+		{245, "4:05"},
+		{3600, "60:00"},
+		{-1, ""},
+	}
+
+	for _, tt := range tests {
+		if got := FormatDuration(tt.seconds); got != tt.want {
+			t.Errorf("FormatDuration(%d) = %q, want %q", tt.seconds, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package formats
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// plsEntry collects the FileN/TitleN/LengthN keys for one index
+type plsEntry struct {
+	file     string
+	title    string
+	duration int
+}
+
+// SniffPLS detects PLS playlists by their [playlist] section header
+func SniffPLS(header []byte) bool {
+	return strings.EqualFold(firstLine(header), "[playlist]")
+}
+
+// ReadPLS parses Winamp/Shoutcast style PLS playlists.
+// Entries are returned in index order regardless of their order in the file.
+func ReadPLS(r io.Reader) ([]*shared.Track, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	entries := make(map[int]*plsEntry)
+	atStart := true
+
+	for scanner.Scan() {
+		raw := scanner.Bytes()
+		if atStart {
+			raw = bytes.TrimPrefix(raw, utf8BOM)
+			atStart = false
+		}
+
+		line := strings.TrimSpace(decodeLine(raw))
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "[") {
+			continue
+		}
+
+		key, value, found := strings.Cut(line, "=")
+		if !found {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		var field string
+		for _, prefix := range []string{"file", "title", "length"} {
+			if strings.HasPrefix(key, prefix) {
+				field = prefix
+				break
+			}
+		}
+		if field == "" {
+			continue // NumberOfEntries, Version, etc.
+		}
+
+		idx, err := strconv.Atoi(key[len(field):])
+		if err != nil {
+			continue
+		}
+
+		entry, exists := entries[idx]
+		if !exists {
+			entry = &plsEntry{duration: -1}
+			entries[idx] = entry
+		}
+
+		switch field {
+		case "file":
+			entry.file = value
+		case "title":
+			entry.title = value
+		case "length":
+			if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+				entry.duration = seconds
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read PLS playlist: %w", err)
+	}
+
+	indexes := make([]int, 0, len(entries))
+	for idx := range entries {
+		indexes = append(indexes, idx)
+	}
+	sort.Ints(indexes)
+
+	var tracks []*shared.Track
+	for _, idx := range indexes {
+		entry := entries[idx]
+		if entry.file == "" {
+			continue // Title/Length without a file
+		}
+		tracks = append(tracks, &shared.Track{
+			Path:     entry.file,
+			Title:    entry.title,
+			Duration: FormatDuration(entry.duration),
+		})
+	}
+
+	return tracks, nil
+}
+
+// WritePLS writes tracks as a version 2 PLS playlist
+func WritePLS(w io.Writer, tracks []*shared.Track) error {
+	bw := bufio.NewWriter(w)
+
+	fmt.Fprintln(bw, "[playlist]")
+	for i, track := range tracks {
+		fmt.Fprintf(bw, "File%d=%s\n", i+1, track.Path)
+		fmt.Fprintf(bw, "Title%d=%s\n", i+1, displayTitle(track))
+		fmt.Fprintf(bw, "Length%d=%d\n", i+1, ParseDuration(track.Duration))
+	}
+	fmt.Fprintf(bw, "NumberOfEntries=%d\n", len(tracks))
+	fmt.Fprintln(bw, "Version=2")
+
+	return bw.Flush()
+}
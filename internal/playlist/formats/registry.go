@@ -0,0 +1,162 @@
+package formats
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// sniffSize is how much of the file is inspected for content detection
+const sniffSize = 512
+
+// ReaderFunc reads a playlist and returns its tracks. Track paths are left
+// exactly as written in the playlist; the caller resolves them.
+type ReaderFunc func(io.Reader) ([]*shared.Track, error)
+
+// WriterFunc writes tracks as a playlist. Track paths are written as given.
+type WriterFunc func(io.Writer, []*shared.Track) error
+
+// SniffFunc reports whether the start of a file looks like a given format
+type SniffFunc func(header []byte) bool
+
+// playlistFormat describes one registered playlist format
+type playlistFormat struct {
+	name   string
+	reader ReaderFunc
+	writer WriterFunc
+	sniff  SniffFunc
+}
+
+// FormatRegistry manages playlist format readers
+type FormatRegistry struct {
+	formats    []*playlistFormat
+	extensions map[string]*playlistFormat
+}
+
+// NewFormatRegistry creates a new format registry with default readers
+func NewFormatRegistry() *FormatRegistry {
+	registry := &FormatRegistry{
+		extensions: make(map[string]*playlistFormat),
+	}
+
+	// Register default formats. Order matters for sniffing: the more
+	// specific signatures are checked first.
+	registry.RegisterFormat("xspf", []string{".xspf"}, SniffXSPF, ReadXSPF)
+	registry.RegisterFormat("pls", []string{".pls"}, SniffPLS, ReadPLS)
+	registry.RegisterFormat("m3u", []string{".m3u", ".m3u8"}, SniffM3U, ReadM3U)
+
+	registry.RegisterWriter("xspf", WriteXSPF)
+	registry.RegisterWriter("pls", WritePLS)
+	registry.RegisterWriter("m3u", WriteM3U)
+
+	return registry
+}
+
+// RegisterFormat registers a reader for a playlist format with its file extensions
+// and content signature. sniff may be nil for formats that can't be detected by content.
+func (r *FormatRegistry) RegisterFormat(name string, exts []string, sniff SniffFunc, reader ReaderFunc) {
+	format := &playlistFormat{
+		name:   name,
+		reader: reader,
+		sniff:  sniff,
+	}
+
+	r.formats = append(r.formats, format)
+	for _, ext := range exts {
+		r.extensions[strings.ToLower(ext)] = format
+	}
+}
+
+// RegisterWriter registers a writer for an already registered playlist format
+func (r *FormatRegistry) RegisterWriter(name string, writer WriterFunc) {
+	for _, f := range r.formats {
+		if f.name == name {
+			f.writer = writer
+			return
+		}
+	}
+}
+
+// Read reads a playlist, detecting its format from content and falling back to the extension
+func (r *FormatRegistry) Read(filePath string, file io.Reader) ([]*shared.Track, error) {
+	buffered := bufio.NewReaderSize(file, sniffSize)
+
+	// Peek returns what it could read along with io.EOF for short files
+	header, err := buffered.Peek(sniffSize)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, fmt.Errorf("failed to read playlist: %w", err)
+	}
+
+	format := r.Detect(filePath, header)
+	if format == "" {
+		return nil, fmt.Errorf("unsupported playlist format: %s", filepath.Base(filePath))
+	}
+
+	for _, f := range r.formats {
+		if f.name == format {
+			return f.reader(buffered)
+		}
+	}
+
+	return nil, fmt.Errorf("unsupported playlist format: %s", format)
+}
+
+// Write writes tracks as a playlist in the format matching the file extension
+func (r *FormatRegistry) Write(filePath string, w io.Writer, tracks []*shared.Track) error {
+	format := r.FormatForExtension(filePath)
+	if format == "" {
+		return fmt.Errorf("unsupported playlist format: %s", filepath.Ext(filePath))
+	}
+
+	f := r.extensions[strings.ToLower(filepath.Ext(filePath))]
+	if f.writer == nil {
+		return fmt.Errorf("writing %s playlists is not supported", format)
+	}
+
+	return f.writer(w, tracks)
+}
+
+// FormatForExtension returns the name of the playlist format registered for
+// the file's extension, or an empty string if there is none
+func (r *FormatRegistry) FormatForExtension(filePath string) string {
+	if f, exists := r.extensions[strings.ToLower(filepath.Ext(filePath))]; exists {
+		return f.name
+	}
+	return ""
+}
+
+// Detect returns the name of the playlist format for the given header and
+// file path, or an empty string if the format is not recognised
+func (r *FormatRegistry) Detect(filePath string, header []byte) string {
+	for _, f := range r.formats {
+		if f.sniff != nil && f.sniff(header) {
+			return f.name
+		}
+	}
+
+	return r.FormatForExtension(filePath)
+}
+
+// GetSupportedExtensions returns a list of supported playlist file extensions
+func (r *FormatRegistry) GetSupportedExtensions() []string {
+	extensions := make([]string, 0, len(r.extensions))
+	for ext := range r.extensions {
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+// firstLine returns the first non-blank line of the header, without a UTF-8 BOM
+func firstLine(header []byte) string {
+	text := strings.TrimPrefix(string(header), string(utf8BOM))
+	for _, line := range strings.Split(text, "\n") {
+		if trimmed := strings.TrimSpace(line); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
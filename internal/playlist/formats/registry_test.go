@@ -0,0 +1,177 @@
+package formats
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+func TestFormatRegistry_Detect(t *testing.T) {
+	registry := NewFormatRegistry()
+
+	tests := []struct {
+		name     string
+		path     string
+		header   string
+		expected string
+	}{
+		{"extended m3u", "set.m3u", "#EXTM3U\n", "m3u"},
+		{"plain m3u by extension", "set.m3u8", "track.mp3\n", "m3u"},
+		{"pls by content", "set.txt", "[playlist]\nFile1=a.mp3\n", "pls"},
+		{"pls lowercase header", "set", "\n[Playlist]\n", "pls"},
+		{"xspf by namespace", "set.xml", `<playlist version="1" xmlns="http://xspf.org/ns/0/">`, "xspf"},
+		{"mislabeled pls", "set.m3u", "[playlist]\nFile1=a.mp3\n", "pls"},
+		{"mislabeled xspf", "set.pls", "<?xml version=\"1.0\"?>\n<playlist>", "xspf"},
+		{"unknown", "notes.txt", "hello", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.Detect(tt.path, []byte(tt.header)); got != tt.expected {
+				t.Errorf("Detect(%q) = %q, want %q", tt.path, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatRegistry_ReadUnsupported(t *testing.T) {
+	registry := NewFormatRegistry()
+
+	_, err := registry.Read("notes.txt", strings.NewReader("just some text"))
+	if err == nil {
+		t.Error("Read() should fail for an unrecognised playlist")
+	}
+}
+
+func TestReadPLS(t *testing.T) {
+	input := "[playlist]\n" +
+		"NumberOfEntries=3\n" +
+		"File2=second.mp3\n" +
+		"Title2=Second\n" +
+		"Length2=-1\n" +
+		"File1=C:\\Music\\first.mp3\n" +
+		"Title1=First\n" +
+		"Length1=312\n" +
+		"Title3=No file for this one\n" +
+		"Version=2\n"
+
+	tracks, err := NewFormatRegistry().Read("set.pls", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(tracks))
+	}
+
+	// Entries come back in index order, not file order
+	if tracks[0].Path != "C:\\Music\\first.mp3" || tracks[0].Title != "First" || tracks[0].Duration != "5:12" {
+		t.Errorf("Track 0 = %+v, want first.mp3/First/5:12", *tracks[0])
+	}
+	if tracks[1].Path != "second.mp3" || tracks[1].Duration != "" {
+		t.Errorf("Track 1 = %+v, want second.mp3 with unknown duration", *tracks[1])
+	}
+}
+
+func TestReadXSPF(t *testing.T) {
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<playlist version="1" xmlns="http://xspf.org/ns/0/">
+  <trackList>
+    <track>
+      <location>music/Deep%20Cut.mp3</location>
+      <creator>Artist</creator>
+      <title>Deep Cut</title>
+      <duration>215400</duration>
+    </track>
+    <track>
+      <location>file:///music/second.wav</location>
+      <duration>unknown</duration>
+    </track>
+    <track>
+      <title>No location</title>
+    </track>
+  </trackList>
+</playlist>`
+
+	tracks, err := NewFormatRegistry().Read("export", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("Read() failed: %v", err)
+	}
+
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(tracks))
+	}
+
+	if tracks[0].Path != "music/Deep Cut.mp3" {
+		t.Errorf("Track 0 path = %q, want %q", tracks[0].Path, "music/Deep Cut.mp3")
+	}
+	if tracks[0].Title != "Artist - Deep Cut" {
+		t.Errorf("Track 0 title = %q, want %q", tracks[0].Title, "Artist - Deep Cut")
+	}
+	if tracks[0].Duration != "3:35" {
+		t.Errorf("Track 0 duration = %q, want %q", tracks[0].Duration, "3:35")
+	}
+
+	// file:// URIs are resolved by the loader
+	if tracks[1].Path != "file:///music/second.wav" {
+		t.Errorf("Track 1 path = %q, want %q", tracks[1].Path, "file:///music/second.wav")
+	}
+	// A duration that isn't a number is ignored rather than failing the playlist
+	if tracks[1].Duration != "" {
+		t.Errorf("Track 1 duration = %q, want it unknown", tracks[1].Duration)
+	}
+}
+
+func TestFormatRegistry_WriteRoundTrip(t *testing.T) {
+	registry := NewFormatRegistry()
+
+	tracks := []*shared.Track{
+		{Filename: "one.mp3", Path: "/music/one.mp3", Title: "Artist - One", Duration: "4:05"},
+		{Filename: "two track.wav", Path: "sub/two track.wav"},
+	}
+
+	for _, path := range []string{"out.m3u8", "out.pls", "out.xspf"} {
+		t.Run(path, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := registry.Write(path, &buf, tracks); err != nil {
+				t.Fatalf("Write() failed: %v", err)
+			}
+
+			// Read back without the extension to check the output is detectable by content
+			readBack, err := registry.Read("saved", &buf)
+			if err != nil {
+				t.Fatalf("Read() failed: %v", err)
+			}
+
+			if len(readBack) != len(tracks) {
+				t.Fatalf("Expected %d tracks, got %d", len(tracks), len(readBack))
+			}
+
+			if readBack[0].Path != "/music/one.mp3" && readBack[0].Path != "file:///music/one.mp3" {
+				t.Errorf("Track 0 path = %q", readBack[0].Path)
+			}
+			if readBack[0].Title != "Artist - One" {
+				t.Errorf("Track 0 title = %q, want %q", readBack[0].Title, "Artist - One")
+			}
+			if readBack[0].Duration != "4:05" {
+				t.Errorf("Track 0 duration = %q, want %q", readBack[0].Duration, "4:05")
+			}
+			if readBack[1].Path != "sub/two track.wav" {
+				t.Errorf("Track 1 path = %q, want %q", readBack[1].Path, "sub/two track.wav")
+			}
+			// Untitled tracks fall back to the filename
+			if readBack[1].Title != "two track" {
+				t.Errorf("Track 1 title = %q, want %q", readBack[1].Title, "two track")
+			}
+		})
+	}
+}
+
+func TestFormatRegistry_WriteUnsupported(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewFormatRegistry().Write("out.txt", &buf, nil); err == nil {
+		t.Error("Write() should fail for an unknown extension")
+	}
+}
@@ -0,0 +1,131 @@
+package formats
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// xspfNamespace is the XML namespace of XSPF version 1
+const xspfNamespace = "http://xspf.org/ns/0/"
+
+// xspfPlaylist mirrors the parts of the XSPF document we use
+type xspfPlaylist struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+type xspfTrack struct {
+	Locations []string `xml:"location"`
+	Title     string   `xml:"title,omitempty"`
+	Creator   string   `xml:"creator,omitempty"`
+	Duration  string   `xml:"duration,omitempty"` // Milliseconds; kept as text so a bad value only loses the duration
+}
+
+// xspfDocument is the structure written by WriteXSPF
+type xspfDocument struct {
+	XMLName xml.Name    `xml:"playlist"`
+	Version string      `xml:"version,attr"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	Tracks  []xspfTrack `xml:"trackList>track"`
+}
+
+// SniffXSPF detects XSPF playlists by their namespace or root element
+func SniffXSPF(header []byte) bool {
+	return bytes.Contains(header, []byte(xspfNamespace)) ||
+		(bytes.Contains(header, []byte("<?xml")) && bytes.Contains(header, []byte("<playlist")))
+}
+
+// ReadXSPF parses XSPF (XML Shareable Playlist Format) playlists
+func ReadXSPF(r io.Reader) ([]*shared.Track, error) {
+	var playlist xspfPlaylist
+	if err := xml.NewDecoder(r).Decode(&playlist); err != nil {
+		return nil, fmt.Errorf("failed to read XSPF playlist: %w", err)
+	}
+
+	var tracks []*shared.Track
+	for _, t := range playlist.Tracks {
+		if len(t.Locations) == 0 {
+			continue
+		}
+
+		title := strings.TrimSpace(t.Title)
+		if creator := strings.TrimSpace(t.Creator); creator != "" && title != "" {
+			title = creator + " - " + title
+		}
+
+		duration := -1
+		if ms, err := strconv.ParseFloat(strings.TrimSpace(t.Duration), 64); err == nil && ms > 0 {
+			duration = int(math.Round(ms / 1000))
+		}
+
+		tracks = append(tracks, &shared.Track{
+			Path:     xspfLocation(strings.TrimSpace(t.Locations[0])),
+			Title:    title,
+			Duration: FormatDuration(duration),
+		})
+	}
+
+	return tracks, nil
+}
+
+// xspfLocation converts a relative XSPF location URI into a plain path.
+// Absolute URIs such as file:// are left for the loader to resolve.
+func xspfLocation(location string) string {
+	if strings.Contains(location, "://") {
+		return location
+	}
+
+	if unescaped, err := url.PathUnescape(location); err == nil {
+		return unescaped
+	}
+	return location
+}
+
+// WriteXSPF writes tracks as an XSPF playlist
+func WriteXSPF(w io.Writer, tracks []*shared.Track) error {
+	doc := xspfDocument{
+		Version: "1",
+		Xmlns:   xspfNamespace,
+	}
+
+	for _, track := range tracks {
+		entry := xspfTrack{
+			Locations: []string{xspfURI(track.Path)},
+			Title:     displayTitle(track),
+		}
+		if seconds := ParseDuration(track.Duration); seconds >= 0 {
+			entry.Duration = strconv.Itoa(seconds * 1000)
+		}
+		doc.Tracks = append(doc.Tracks, entry)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to write XSPF playlist: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// xspfURI converts a path into a location URI: file:// for absolute paths,
+// a percent-encoded relative reference otherwise
+func xspfURI(path string) string {
+	if strings.HasPrefix(path, "/") {
+		return (&url.URL{Scheme: "file", Path: path}).String()
+	}
+	return (&url.URL{Path: path}).String()
+}
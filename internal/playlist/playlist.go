@@ -17,6 +17,18 @@ const (
 	RepeatOne
 )
 
+// String returns the mode as shown to users: "off", "all" or "one"
+func (m RepeatMode) String() string {
+	switch m {
+	case RepeatAll:
+		return "all"
+	case RepeatOne:
+		return "one"
+	default:
+		return "off"
+	}
+}
+
 type Playlist struct {
 	tracks     []*shared.Track
 	currentIdx int
@@ -24,6 +36,11 @@ type Playlist struct {
 	sourceType shared.SourceType
 	isShuffled bool
 	repeatMode RepeatMode
+	order      []int      // Shuffled play order of track indices, nil when not shuffled
+	orderPos   int        // Position of the current track in order
+	nextIdx    int        // First track of the next shuffle cycle, -1 until PeekNext chooses one
+	rng        *rand.Rand // Shuffle source; see Seed
+	lastID     int        // Last queue entry ID given out; see withIDs
 	mu         sync.RWMutex
 }
 
@@ -31,6 +48,8 @@ func NewPlaylist() *Playlist {
 	return &Playlist{
 		tracks:     make([]*shared.Track, 0),
 		currentIdx: 0,
+		nextIdx:    -1,
+		rng:        rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
 }
 
@@ -38,11 +57,11 @@ func (p *Playlist) LoadTracks(tracks []*shared.Track, source string, sourceType
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.tracks = tracks
+	p.tracks = p.withIDs(tracks)
 	p.source = source
 	p.sourceType = sourceType
 	p.currentIdx = 0
-	p.isShuffled = false
+	p.unshuffle()
 
 	return nil
 }
@@ -74,8 +93,12 @@ func (p *Playlist) Next() bool {
 	}
 
 	if p.isShuffled {
-		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-		p.currentIdx = rng.Intn(len(p.tracks))
+		if p.orderPos+1 >= len(p.order) {
+			return false // Every track in this cycle has played
+		}
+		p.orderPos++
+		p.currentIdx = p.order[p.orderPos]
+		p.nextIdx = -1
 		return true
 	}
 
@@ -95,9 +118,15 @@ func (p *Playlist) Previous() bool {
 		return false
 	}
 
+	p.nextIdx = -1
+
+	// In shuffle mode, retrace the tracks played in this cycle
 	if p.isShuffled {
-		rng := rand.New(rand.NewSource(time.Now().UnixNano()))
-		p.currentIdx = rng.Intn(len(p.tracks))
+		if p.orderPos <= 0 {
+			return false
+		}
+		p.orderPos--
+		p.currentIdx = p.order[p.orderPos]
 		return true
 	}
 
@@ -109,6 +138,111 @@ func (p *Playlist) Previous() bool {
 	return false // Already at the beginning
 }
 
+// HasNext reports whether Next would move to another track
+func (p *Playlist) HasNext() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.isShuffled {
+		return p.orderPos+1 < len(p.order)
+	}
+	return p.currentIdx < len(p.tracks)-1
+}
+
+// HasPrevious reports whether Previous would move to another track
+func (p *Playlist) HasPrevious() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if len(p.tracks) == 0 {
+		return false
+	}
+	if p.isShuffled {
+		return p.orderPos > 0
+	}
+	return p.currentIdx > 0
+}
+
+// PeekNext returns the track that auto-advance will move to when the current
+// one ends, following the shuffle and repeat modes, or nil at the end of the
+// queue. When repeat-all starts a new shuffle cycle, its first track is
+// picked here and kept, so the next Advance lands on it.
+func (p *Playlist) PeekNext() *shared.Track {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := p.peekNext()
+	if idx < 0 {
+		return nil
+	}
+	return p.tracks[idx]
+}
+
+// PeekNextIndex returns the queue index of the track PeekNext returns, or
+// -1 at the end of the queue
+func (p *Playlist) PeekNextIndex() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.peekNext()
+}
+
+// Advance moves to the track PeekNext returns, replaying the current track in
+// repeat-one mode and wrapping around in repeat-all mode. It returns false at
+// the end of the queue.
+func (p *Playlist) Advance() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	idx := p.peekNext()
+	if idx < 0 {
+		return false
+	}
+
+	if p.isShuffled && p.repeatMode != RepeatOne {
+		if p.orderPos+1 < len(p.order) {
+			p.orderPos++
+		} else {
+			p.shuffleOrder(idx) // Repeat-all: start a new cycle
+		}
+	}
+
+	p.currentIdx = idx
+	p.nextIdx = -1
+	return true
+}
+
+// peekNext returns the index auto-advance moves to, or -1 (assumes lock is held)
+func (p *Playlist) peekNext() int {
+	if len(p.tracks) == 0 {
+		return -1
+	}
+
+	if p.repeatMode == RepeatOne {
+		return p.currentIdx
+	}
+
+	if p.isShuffled {
+		if p.orderPos+1 < len(p.order) {
+			return p.order[p.orderPos+1]
+		}
+		if p.repeatMode != RepeatAll {
+			return -1
+		}
+		if p.nextIdx < 0 || p.nextIdx >= len(p.tracks) {
+			p.nextIdx = p.nextCycleStart()
+		}
+		return p.nextIdx
+	}
+
+	if p.currentIdx < len(p.tracks)-1 {
+		return p.currentIdx + 1
+	}
+	if p.repeatMode == RepeatAll {
+		return 0
+	}
+	return -1
+}
+
 func (p *Playlist) TrackCount() int {
 	p.mu.RLock()
 	defer p.mu.RUnlock()
@@ -122,12 +256,8 @@ func (p *Playlist) GetTrackList() []*shared.Track {
 	// Return a deep copy to prevent external modification
 	tracks := make([]*shared.Track, len(p.tracks))
 	for i, track := range p.tracks {
-		trackCopy := &shared.Track{
-			Filename: track.Filename,
-			Path:     track.Path,
-			Duration: track.Duration,
-		}
-		tracks[i] = trackCopy
+		trackCopy := *track
+		tracks[i] = &trackCopy
 	}
 	return tracks
 }
@@ -147,11 +277,8 @@ func (p *Playlist) GetTrackWindow(windowSize int) ([]*shared.Track, int, int) {
 	if totalTracks <= windowSize {
 		tracks := make([]*shared.Track, totalTracks)
 		for i, track := range p.tracks {
-			tracks[i] = &shared.Track{
-				Filename: track.Filename,
-				Path:     track.Path,
-				Duration: track.Duration,
-			}
+			trackCopy := *track
+			tracks[i] = &trackCopy
 		}
 		return tracks, 0, totalTracks
 	}
@@ -181,11 +308,8 @@ func (p *Playlist) GetTrackWindow(windowSize int) ([]*shared.Track, int, int) {
 	// Extract windowed tracks
 	windowTracks := make([]*shared.Track, endIdx-startIdx+1)
 	for i := startIdx; i <= endIdx; i++ {
-		windowTracks[i-startIdx] = &shared.Track{
-			Filename: p.tracks[i].Filename,
-			Path:     p.tracks[i].Path,
-			Duration: p.tracks[i].Duration,
-		}
+		trackCopy := *p.tracks[i]
+		windowTracks[i-startIdx] = &trackCopy
 	}
 
 	return windowTracks, startIdx, totalTracks
@@ -214,26 +338,36 @@ func (p *Playlist) SetCurrentIndex(idx int) bool {
 	}
 
 	p.currentIdx = idx
+	p.nextIdx = -1
+	if p.isShuffled {
+		p.placeInOrder(idx)
+	}
 	return true
 }
 
+// Shuffle starts a new shuffled play order from the current track
 func (p *Playlist) Shuffle() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.isShuffled = true
+	p.shuffle()
 }
 
+// Unshuffle returns to queue order, keeping the current track
 func (p *Playlist) Unshuffle() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
-	p.isShuffled = false
+	p.unshuffle()
 }
 
 func (p *Playlist) ToggleShuffle() bool {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	p.isShuffled = !p.isShuffled
+	if p.isShuffled {
+		p.unshuffle()
+	} else {
+		p.shuffle()
+	}
 	return p.isShuffled
 }
 
@@ -279,6 +413,6 @@ func (p *Playlist) Clear() {
 	p.currentIdx = 0
 	p.source = ""
 	p.sourceType = ""
-	p.isShuffled = false
 	p.repeatMode = RepeatOff
+	p.unshuffle()
 }
@@ -123,6 +123,67 @@ func TestPlaylist_Next(t *testing.T) {
 	}
 }
 
+func TestPlaylist_PeekNextAndAdvance(t *testing.T) {
+	playlist := NewPlaylist()
+
+	if playlist.PeekNext() != nil || playlist.Advance() {
+		t.Error("PeekNext()/Advance() should find nothing in an empty playlist")
+	}
+
+	tracks := []*shared.Track{
+		{Filename: "track1.mp3", Path: "/path/track1.mp3"},
+		{Filename: "track2.mp3", Path: "/path/track2.mp3"},
+		{Filename: "track3.mp3", Path: "/path/track3.mp3"},
+	}
+	playlist.LoadTracks(tracks, "/path", shared.SourceFolder)
+
+	// Peeking doesn't move, advancing lands on the peeked track
+	if next := playlist.PeekNext(); next != tracks[1] {
+		t.Errorf("PeekNext() = %v, want track2", next)
+	}
+	if playlist.GetCurrentIndex() != 0 {
+		t.Errorf("PeekNext() should not move, current index is %d", playlist.GetCurrentIndex())
+	}
+	if !playlist.Advance() || playlist.GetCurrentIndex() != 1 {
+		t.Errorf("Advance() should move to index 1, got %d", playlist.GetCurrentIndex())
+	}
+
+	// Repeat off stops at the end
+	playlist.SetCurrentIndex(2)
+	if playlist.PeekNext() != nil || playlist.Advance() {
+		t.Error("PeekNext()/Advance() should stop at the end with repeat off")
+	}
+
+	// Repeat all wraps around
+	playlist.SetRepeatMode(RepeatAll)
+	if next := playlist.PeekNext(); next != tracks[0] {
+		t.Errorf("PeekNext() with repeat all = %v, want track1", next)
+	}
+
+	// Repeat one stays on the current track
+	playlist.SetRepeatMode(RepeatOne)
+	if !playlist.Advance() || playlist.GetCurrentIndex() != 2 {
+		t.Errorf("Advance() with repeat one should stay at 2, got %d", playlist.GetCurrentIndex())
+	}
+
+	// PeekNext and Advance agree in shuffle mode, including when repeat-all
+	// starts a new cycle, so the preloaded track is the one played next
+	playlist.SetRepeatMode(RepeatAll)
+	playlist.Shuffle()
+	for i := 0; i < 20; i++ {
+		next := playlist.PeekNext()
+		if again := playlist.PeekNext(); again != next {
+			t.Fatalf("PeekNext() changed its pick from %v to %v", next, again)
+		}
+		if idx := playlist.PeekNextIndex(); tracks[idx] != next {
+			t.Fatalf("PeekNextIndex() = %d, want the index of %v", idx, next)
+		}
+		if !playlist.Advance() || playlist.GetCurrentTrack() != next {
+			t.Fatalf("Advance() should land on the peeked track %v, got %v", next, playlist.GetCurrentTrack())
+		}
+	}
+}
+
 func TestPlaylist_Previous(t *testing.T) {
 	playlist := NewPlaylist()
 
@@ -166,6 +227,46 @@ func TestPlaylist_Previous(t *testing.T) {
 	}
 }
 
+func TestPlaylist_HasNextAndPrevious(t *testing.T) {
+	playlist := NewPlaylist()
+
+	if playlist.HasNext() || playlist.HasPrevious() {
+		t.Error("Empty playlist should have no next or previous track")
+	}
+
+	tracks := []*shared.Track{
+		{Filename: "track1.mp3", Path: "/path/track1.mp3"},
+		{Filename: "track2.mp3", Path: "/path/track2.mp3"},
+		{Filename: "track3.mp3", Path: "/path/track3.mp3"},
+	}
+	playlist.LoadTracks(tracks, "/path", shared.SourceFolder)
+
+	for idx, want := range []struct{ next, previous bool }{{true, false}, {true, true}, {false, true}} {
+		playlist.SetCurrentIndex(idx)
+		if got := playlist.HasNext(); got != want.next {
+			t.Errorf("HasNext() at index %d = %v, want %v", idx, got, want.next)
+		}
+		if got := playlist.HasPrevious(); got != want.previous {
+			t.Errorf("HasPrevious() at index %d = %v, want %v", idx, got, want.previous)
+		}
+	}
+
+	// Shuffled, they follow the play order rather than the indices
+	playlist.Seed(1)
+	playlist.Shuffle()
+	if playlist.HasPrevious() {
+		t.Error("HasPrevious() should be false at the start of a shuffle cycle")
+	}
+	playlist.Next()
+	playlist.Next()
+	if playlist.HasNext() {
+		t.Error("HasNext() should be false at the end of a shuffle cycle")
+	}
+	if !playlist.HasPrevious() {
+		t.Error("HasPrevious() should be true after moving through the cycle")
+	}
+}
+
 func TestPlaylist_SetCurrentIndex(t *testing.T) {
 	playlist := NewPlaylist()
 
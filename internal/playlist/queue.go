@@ -0,0 +1,234 @@
+package playlist
+
+import (
+	"slices"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// Queue editing. Every edit keeps currentIdx on the same track when entries
+// before it change, and keeps the shuffle order in step with the new
+// indices. Edits build new slices rather than shifting in place, as the
+// track slice may be shared with whoever loaded it.
+
+// Append adds tracks to the end of the queue. In shuffle mode they are
+// scattered among the tracks still to play in this cycle.
+func (p *Playlist) Append(tracks []*shared.Track) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := len(p.tracks)
+	tracks = p.withIDs(tracks)
+	p.tracks = slices.Concat(p.tracks, tracks)
+
+	if p.isShuffled {
+		for i := range tracks {
+			unplayed := max(len(p.order)-p.orderPos, 1)
+			pos := min(p.orderPos+1+p.rng.Intn(unplayed), len(p.order))
+			p.order = slices.Insert(p.order, pos, start+i)
+		}
+	}
+}
+
+// InsertNext inserts tracks right after the current one so they play next,
+// in shuffle mode too
+func (p *Playlist) InsertNext(tracks []*shared.Track) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(tracks) == 0 {
+		return
+	}
+
+	at := min(p.currentIdx+1, len(p.tracks))
+	p.tracks = slices.Concat(p.tracks[:at], p.withIDs(tracks), p.tracks[at:])
+	p.nextIdx = -1
+
+	if p.isShuffled {
+		p.remapOrder(func(idx int) int {
+			if idx >= at {
+				return idx + len(tracks)
+			}
+			return idx
+		})
+		inserted := make([]int, len(tracks))
+		for i := range inserted {
+			inserted[i] = at + i
+		}
+		p.order = slices.Insert(p.order, min(p.orderPos+1, len(p.order)), inserted...)
+	}
+}
+
+// Remove removes the tracks from index first to last, inclusive (0-based).
+// If the current track is among them, the track that followed it becomes
+// current (the next in play order when shuffled, or the new last track if
+// none did) and currentRemoved is true.
+func (p *Playlist) Remove(first, last int) (currentRemoved bool, ok bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if first < 0 || last >= len(p.tracks) || first > last {
+		return false, false
+	}
+	return p.remove(first, last), true
+}
+
+// remove removes a valid range of tracks and reports whether the current
+// track was among them (assumes lock is held)
+func (p *Playlist) remove(first, last int) (currentRemoved bool) {
+	count := last - first + 1
+	newIndex := func(idx int) int {
+		switch {
+		case idx < first:
+			return idx
+		case idx <= last:
+			return -1
+		default:
+			return idx - count
+		}
+	}
+
+	p.tracks = slices.Concat(p.tracks[:first], p.tracks[last+1:])
+	p.nextIdx = -1
+	p.remapOrder(newIndex)
+
+	currentRemoved = p.currentIdx >= first && p.currentIdx <= last
+	switch {
+	case len(p.tracks) == 0:
+		// Nothing left; shuffle stays on and starts over with the next tracks added
+		p.currentIdx = 0
+		if p.isShuffled {
+			p.order = []int{}
+			p.orderPos = 0
+		}
+	case !currentRemoved:
+		p.currentIdx = newIndex(p.currentIdx)
+	case p.isShuffled && len(p.order) > 0:
+		p.currentIdx = p.order[p.orderPos]
+	default:
+		p.currentIdx = max(min(first, len(p.tracks)-1), 0)
+	}
+	return currentRemoved
+}
+
+// Move moves the track at index from to index to (0-based), shifting the
+// tracks in between
+func (p *Playlist) Move(from, to int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if from < 0 || from >= len(p.tracks) || to < 0 || to >= len(p.tracks) {
+		return false
+	}
+	if from == to {
+		return true
+	}
+
+	newIndex := func(idx int) int {
+		switch {
+		case idx == from:
+			return to
+		case from < to && idx > from && idx <= to:
+			return idx - 1
+		case from > to && idx >= to && idx < from:
+			return idx + 1
+		default:
+			return idx
+		}
+	}
+
+	track := p.tracks[from]
+	rest := slices.Concat(p.tracks[:from], p.tracks[from+1:])
+	p.tracks = slices.Concat(rest[:to], []*shared.Track{track}, rest[to:])
+	p.nextIdx = -1
+	p.remapOrder(newIndex)
+	p.currentIdx = newIndex(p.currentIdx)
+	return true
+}
+
+// IndexOfID returns the index of the queue entry with the given ID, or -1
+func (p *Playlist) IndexOfID(id int) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return slices.IndexFunc(p.tracks, func(t *shared.Track) bool { return t.ID == id })
+}
+
+// withIDs gives tracks entering the queue new IDs and returns them. IDs are
+// never reused, so one always names the same entry wherever edits move it
+// (assumes lock is held).
+func (p *Playlist) withIDs(tracks []*shared.Track) []*shared.Track {
+	for _, track := range tracks {
+		p.lastID++
+		track.ID = p.lastID
+	}
+	return tracks
+}
+
+// ClearUpcoming removes every track that would play after the current one
+// and returns how many were removed. In shuffle mode that is the rest of
+// the shuffled order; the tracks already played this cycle stay.
+func (p *Playlist) ClearUpcoming() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.isShuffled {
+		if p.orderPos+1 >= len(p.order) {
+			return 0
+		}
+		upcoming := slices.Clone(p.order[p.orderPos+1:])
+		p.removeIndices(upcoming)
+		return len(upcoming)
+	}
+
+	first := p.currentIdx + 1
+	if first >= len(p.tracks) {
+		return 0
+	}
+	removed := len(p.tracks) - first
+	p.remove(first, len(p.tracks)-1)
+	return removed
+}
+
+// removeIndices removes the tracks at the given indices, none of which may
+// be the current track (assumes lock is held)
+func (p *Playlist) removeIndices(indices []int) {
+	if len(indices) == 0 {
+		return
+	}
+
+	newIndices := make([]int, len(p.tracks))
+	tracks := make([]*shared.Track, 0, len(p.tracks)-len(indices))
+	for idx, track := range p.tracks {
+		if slices.Contains(indices, idx) {
+			newIndices[idx] = -1
+			continue
+		}
+		newIndices[idx] = len(tracks)
+		tracks = append(tracks, track)
+	}
+	newIndex := func(idx int) int { return newIndices[idx] }
+
+	p.tracks = tracks
+	p.nextIdx = -1
+	p.remapOrder(newIndex)
+	p.currentIdx = newIndex(p.currentIdx)
+}
+
+// UpdateTrack replaces the track at idx with a copy changed by update, if
+// the track there is still the one at path. Tracks are copied rather than
+// changed in place, as others may be reading them. It reports whether the
+// track was found.
+func (p *Playlist) UpdateTrack(idx int, path string, update func(*shared.Track)) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if idx < 0 || idx >= len(p.tracks) || p.tracks[idx].Path != path {
+		return false
+	}
+	track := *p.tracks[idx]
+	update(&track)
+	p.tracks = slices.Clone(p.tracks)
+	p.tracks[idx] = &track
+	return true
+}
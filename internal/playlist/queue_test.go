@@ -0,0 +1,217 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// queueOf returns a playlist of tracks named after names, with current set
+func queueOf(current int, names ...string) *Playlist {
+	tracks := make([]*shared.Track, len(names))
+	for i, name := range names {
+		tracks[i] = &shared.Track{Filename: name, Path: "/music/" + name}
+	}
+	playlist := NewPlaylist()
+	playlist.LoadTracks(tracks, "/music", shared.SourceFolder)
+	playlist.SetCurrentIndex(current)
+	return playlist
+}
+
+// names returns the filenames in the queue, in order
+func names(playlist *Playlist) []string {
+	var result []string
+	for _, track := range playlist.GetTrackList() {
+		result = append(result, track.Filename)
+	}
+	return result
+}
+
+func TestPlaylist_AppendAndInsertNext(t *testing.T) {
+	playlist := queueOf(1, "a", "b", "c")
+
+	playlist.Append([]*shared.Track{{Filename: "d"}})
+	playlist.InsertNext([]*shared.Track{{Filename: "x"}, {Filename: "y"}})
+
+	if got, want := names(playlist), []string{"a", "b", "x", "y", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Queue = %v, want %v", got, want)
+	}
+	if got := playlist.GetCurrentTrack().Filename; got != "b" {
+		t.Errorf("Current track = %s, want b", got)
+	}
+	if next := playlist.PeekNext(); next == nil || next.Filename != "x" {
+		t.Errorf("PeekNext() = %v, want x", next)
+	}
+
+	// Even in shuffle mode, an inserted track plays next
+	playlist.Shuffle()
+	playlist.InsertNext([]*shared.Track{{Filename: "z"}})
+	if next := playlist.PeekNext(); next == nil || next.Filename != "z" {
+		t.Errorf("PeekNext() in shuffle mode = %v, want z", next)
+	}
+}
+
+func TestPlaylist_Remove(t *testing.T) {
+	tests := []struct {
+		name           string
+		first, last    int
+		wantQueue      []string
+		wantCurrent    string
+		currentRemoved bool
+	}{
+		{"before current", 0, 1, []string{"c", "d", "e"}, "c", false},
+		{"after current", 3, 4, []string{"a", "b", "c"}, "c", false},
+		{"current", 2, 2, []string{"a", "b", "d", "e"}, "d", true},
+		{"range with current", 1, 3, []string{"a", "e"}, "e", true},
+		{"current at the end", 2, 4, []string{"a", "b"}, "b", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist := queueOf(2, "a", "b", "c", "d", "e")
+
+			currentRemoved, ok := playlist.Remove(tt.first, tt.last)
+			if !ok {
+				t.Fatalf("Remove(%d, %d) failed", tt.first, tt.last)
+			}
+			if currentRemoved != tt.currentRemoved {
+				t.Errorf("currentRemoved = %v, want %v", currentRemoved, tt.currentRemoved)
+			}
+			if got := names(playlist); !reflect.DeepEqual(got, tt.wantQueue) {
+				t.Errorf("Queue = %v, want %v", got, tt.wantQueue)
+			}
+			if got := playlist.GetCurrentTrack().Filename; got != tt.wantCurrent {
+				t.Errorf("Current track = %s, want %s", got, tt.wantCurrent)
+			}
+		})
+	}
+
+	playlist := queueOf(0, "a", "b")
+	if _, ok := playlist.Remove(1, 2); ok {
+		t.Error("Remove() past the end should fail")
+	}
+	if _, ok := playlist.Remove(1, 0); ok {
+		t.Error("Remove() with first > last should fail")
+	}
+}
+
+func TestPlaylist_Move(t *testing.T) {
+	tests := []struct {
+		name      string
+		from, to  int
+		wantQueue []string
+	}{
+		{"current forward", 2, 4, []string{"a", "b", "d", "e", "c"}},
+		{"across current forward", 0, 3, []string{"b", "c", "d", "a", "e"}},
+		{"across current backward", 4, 1, []string{"a", "e", "b", "c", "d"}},
+		{"after current", 3, 4, []string{"a", "b", "c", "e", "d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			playlist := queueOf(2, "a", "b", "c", "d", "e")
+
+			if !playlist.Move(tt.from, tt.to) {
+				t.Fatalf("Move(%d, %d) failed", tt.from, tt.to)
+			}
+			if got := names(playlist); !reflect.DeepEqual(got, tt.wantQueue) {
+				t.Errorf("Queue = %v, want %v", got, tt.wantQueue)
+			}
+			if got := playlist.GetCurrentTrack().Filename; got != "c" {
+				t.Errorf("Current track = %s, want c", got)
+			}
+		})
+	}
+
+	if queueOf(0, "a").Move(0, 1) {
+		t.Error("Move() past the end should fail")
+	}
+}
+
+func TestPlaylist_ClearUpcoming(t *testing.T) {
+	playlist := queueOf(1, "a", "b", "c", "d")
+
+	if removed := playlist.ClearUpcoming(); removed != 2 {
+		t.Errorf("ClearUpcoming() = %d, want 2", removed)
+	}
+	if got, want := names(playlist), []string{"a", "b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Queue = %v, want %v", got, want)
+	}
+	if playlist.PeekNext() != nil {
+		t.Error("Nothing should follow the current track")
+	}
+}
+
+func TestPlaylist_ClearUpcomingShuffled(t *testing.T) {
+	playlist := shuffledQueue(1, 6)
+	playlist.Next()
+	playlist.Next()
+	played := playlist.PlayOrder()[:3]
+
+	if removed := playlist.ClearUpcoming(); removed != 3 {
+		t.Errorf("ClearUpcoming() = %d, want 3", removed)
+	}
+	var got, want []string
+	for _, track := range playlist.PlayOrder() {
+		got = append(got, track.Filename)
+	}
+	for _, track := range played {
+		want = append(want, track.Filename)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Play order = %v, want the tracks played so far %v", got, want)
+	}
+	if current := playlist.GetCurrentTrack().Filename; current != want[2] {
+		t.Errorf("Current track = %s, want %s", current, want[2])
+	}
+	if playlist.PeekNext() != nil {
+		t.Error("Nothing should follow the current track")
+	}
+	if !playlist.Previous() || playlist.GetCurrentTrack().Filename != want[1] {
+		t.Errorf("Previous() should go back to %s", want[1])
+	}
+}
+
+func TestPlaylist_ClearUpcomingShuffledEmpty(t *testing.T) {
+	playlist := shuffledQueue(1, 4)
+	if _, ok := playlist.Remove(0, 3); !ok {
+		t.Fatal("Remove() should remove every track")
+	}
+
+	if removed := playlist.ClearUpcoming(); removed != 0 {
+		t.Errorf("ClearUpcoming() on an empty queue = %d, want 0", removed)
+	}
+
+	// Tracks added afterwards are still shuffled and playable
+	playlist.Append([]*shared.Track{{Filename: "x"}, {Filename: "y"}})
+	if !playlist.IsShuffled() {
+		t.Error("Emptying the queue should leave shuffle on")
+	}
+	if playlist.GetCurrentTrack() == nil || playlist.PeekNext() == nil {
+		t.Error("Appended tracks should be current and next")
+	}
+}
+
+func TestPlaylist_UpdateTrack(t *testing.T) {
+	playlist := queueOf(1, "a", "b", "c")
+	before := playlist.GetCurrentTrack()
+
+	if !playlist.UpdateTrack(1, "/music/b", func(track *shared.Track) { track.Rating = 4 }) {
+		t.Fatal("UpdateTrack() should find b")
+	}
+	if got := playlist.GetCurrentTrack().Rating; got != 4 {
+		t.Errorf("Rating = %d, want 4", got)
+	}
+	if before.Rating != 0 {
+		t.Error("UpdateTrack() changed the track in place, want a copy")
+	}
+
+	// The queue moved on, so the track isn't there any more
+	if playlist.UpdateTrack(1, "/music/a", func(track *shared.Track) { track.Rating = 1 }) {
+		t.Error("UpdateTrack() should fail when the path doesn't match")
+	}
+	if playlist.UpdateTrack(5, "/music/b", func(track *shared.Track) {}) {
+		t.Error("UpdateTrack() should fail for an index out of range")
+	}
+}
@@ -0,0 +1,75 @@
+package playlist
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Find returns the indices of tracks whose filename or "Artist - Title"
+// display name matches query, in queue order. Matching ignores case and
+// punctuation, so "daft punk" finds "Daft_Punk-One_More_Time.mp3". Names
+// that contain the query win over looser matches where its letters merely
+// appear in order; only the best kind of match that has any hits is returned.
+func (p *Playlist) Find(query string) []int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	words := normalizeForSearch(query)
+	if words == "" {
+		return nil
+	}
+	letters := strings.ReplaceAll(words, " ", "")
+
+	var contains, inOrder []int
+	for i, track := range p.tracks {
+		names := []string{normalizeForSearch(track.Filename)}
+		if display := track.DisplayName(); display != track.Filename {
+			names = append(names, normalizeForSearch(display))
+		}
+		switch {
+		case slices.ContainsFunc(names, func(name string) bool { return strings.Contains(name, words) }):
+			contains = append(contains, i)
+		case slices.ContainsFunc(names, func(name string) bool { return isSubsequence(letters, strings.ReplaceAll(name, " ", "")) }):
+			inOrder = append(inOrder, i)
+		}
+	}
+
+	if len(contains) > 0 {
+		return contains
+	}
+	return inOrder
+}
+
+// normalizeForSearch lowercases s and turns each run of anything other
+// than letters and digits into a single space
+func normalizeForSearch(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// isSubsequence reports whether the runes of needle appear in haystack in order
+func isSubsequence(needle, haystack string) bool {
+	rest := []rune(needle)
+	for _, r := range haystack {
+		if len(rest) == 0 {
+			break
+		}
+		if r == rest[0] {
+			rest = rest[1:]
+		}
+	}
+	return len(rest) == 0
+}
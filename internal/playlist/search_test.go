@@ -0,0 +1,43 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+func TestPlaylist_Find(t *testing.T) {
+	playlist := NewPlaylist()
+	tracks := []*shared.Track{
+		{Filename: "01 - Intro.mp3"},
+		{Filename: "Daft_Punk-One_More_Time.mp3"},
+		{Filename: "Daft Punk - Digital Love.flac"},
+		{Filename: "Deadmau5 - Strobe.flac"},
+		{Filename: "track05.mp3", Artist: "Kraftwerk", Title: "Computer Love"},
+	}
+	playlist.LoadTracks(tracks, "/music", shared.SourceFolder)
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{"daft punk", []int{1, 2}},  // Punctuation and case are ignored
+		{"one more time", []int{1}}, // Underscores count as spaces
+		{"DIGITAL", []int{2}},       // Case-insensitive
+		{"strb", []int{3}},          // Letters in order when nothing contains the query
+		{"dm", []int{3}},            // A substring hit beats looser ones like "Daft Punk ... More"
+		{"dpo", []int{1, 2}},        // Loose matches follow queue order
+		{"kraftwerk", []int{4}},     // Tagged artist and title count too
+		{"zzz", nil},                // No match
+		{"  -_ ", nil},              // Nothing to search for
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := playlist.Find(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Find(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
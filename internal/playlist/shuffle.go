@@ -0,0 +1,134 @@
+package playlist
+
+import (
+	"math/rand"
+	"slices"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// Shuffle mode plays the tracks in a shuffled order that covers every track
+// once per cycle. order[:orderPos] are the tracks played so far in this
+// cycle, which is what back retraces.
+
+// Seed reseeds the shuffle RNG so the play order is reproducible, e.g. in tests
+func (p *Playlist) Seed(seed int64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.rng = rand.New(rand.NewSource(seed))
+}
+
+// PlayOrder returns copies of the tracks in the order they play: the
+// shuffled order when shuffle is on, otherwise queue order
+func (p *Playlist) PlayOrder() []*shared.Track {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	indices := p.order
+	if !p.isShuffled {
+		indices = make([]int, len(p.tracks))
+		for i := range indices {
+			indices[i] = i
+		}
+	}
+
+	tracks := make([]*shared.Track, len(indices))
+	for i, idx := range indices {
+		trackCopy := *p.tracks[idx]
+		tracks[i] = &trackCopy
+	}
+	return tracks
+}
+
+// shuffle turns shuffle mode on with a fresh order that starts at the
+// current track (assumes lock is held)
+func (p *Playlist) shuffle() {
+	p.isShuffled = true
+	p.shuffleOrder(p.currentIdx)
+}
+
+// unshuffle turns shuffle mode off, staying on the current track
+// (assumes lock is held)
+func (p *Playlist) unshuffle() {
+	p.isShuffled = false
+	p.order = nil
+	p.orderPos = 0
+	p.nextIdx = -1
+}
+
+// shuffleOrder starts a new cycle: a Fisher–Yates shuffle of every track,
+// with first moved to the front (assumes lock is held)
+func (p *Playlist) shuffleOrder(first int) {
+	order := make([]int, len(p.tracks))
+	for i := range order {
+		order[i] = i
+	}
+	for i := len(order) - 1; i > 0; i-- {
+		j := p.rng.Intn(i + 1)
+		order[i], order[j] = order[j], order[i]
+	}
+
+	if pos := slices.Index(order, first); pos > 0 {
+		order[0], order[pos] = order[pos], order[0]
+	}
+
+	p.order = order
+	p.orderPos = 0
+	p.nextIdx = -1
+}
+
+// nextCycleStart picks the first track of the next cycle, avoiding the
+// track that ends this one so it doesn't play twice in a row
+// (assumes lock is held)
+func (p *Playlist) nextCycleStart() int {
+	if len(p.tracks) == 1 {
+		return 0
+	}
+	idx := p.rng.Intn(len(p.tracks) - 1)
+	if idx >= p.currentIdx {
+		idx++
+	}
+	return idx
+}
+
+// placeInOrder makes idx the current track of the shuffle order, right
+// after the tracks played so far, so back returns to the track before it
+// (assumes lock is held)
+func (p *Playlist) placeInOrder(idx int) {
+	pos := slices.Index(p.order, idx)
+	if pos < 0 || pos == p.orderPos {
+		return
+	}
+
+	p.order = slices.Delete(p.order, pos, pos+1)
+	if pos > p.orderPos {
+		p.orderPos++
+	}
+	p.order = slices.Insert(p.order, p.orderPos, idx)
+}
+
+// remapOrder rewrites the shuffle order after the track list changed.
+// newIndex returns a track's new index, or -1 if it was removed. If the
+// current track was removed, orderPos ends up on the track that followed it
+// in the order (assumes lock is held).
+func (p *Playlist) remapOrder(newIndex func(int) int) {
+	if p.order == nil {
+		return
+	}
+
+	order := make([]int, 0, len(p.order))
+	pos := 0
+	for i, idx := range p.order {
+		mapped := newIndex(idx)
+		if mapped < 0 {
+			continue
+		}
+		if i < p.orderPos {
+			pos++
+		}
+		order = append(order, mapped)
+	}
+
+	p.order = order
+	p.orderPos = max(min(pos, len(order)-1), 0)
+}
@@ -0,0 +1,205 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// shuffledQueue returns a seeded, shuffled playlist of count tracks
+func shuffledQueue(seed int64, count int) *Playlist {
+	tracks := make([]*shared.Track, count)
+	for i := range tracks {
+		tracks[i] = &shared.Track{Filename: string(rune('a' + i))}
+	}
+	playlist := NewPlaylist()
+	playlist.LoadTracks(tracks, "/music", shared.SourceFolder)
+	playlist.Seed(seed)
+	playlist.Shuffle()
+	return playlist
+}
+
+// playOrder skips through the rest of the cycle and returns the indices played
+func playOrder(playlist *Playlist) []int {
+	played := []int{playlist.GetCurrentIndex()}
+	for playlist.Next() {
+		played = append(played, playlist.GetCurrentIndex())
+	}
+	return played
+}
+
+func TestShuffle_PlaysEveryTrackOncePerCycle(t *testing.T) {
+	playlist := shuffledQueue(1, 10)
+
+	played := playOrder(playlist)
+	if len(played) != 10 {
+		t.Fatalf("Cycle played %d tracks, want 10: %v", len(played), played)
+	}
+	if played[0] != 0 {
+		t.Errorf("Shuffle should start from the current track, started at %d", played[0])
+	}
+	seen := make(map[int]bool)
+	for _, idx := range played {
+		if seen[idx] {
+			t.Fatalf("Track %d played twice in one cycle: %v", idx, played)
+		}
+		seen[idx] = true
+	}
+
+	// With repeat off the cycle ends the queue
+	if playlist.PeekNext() != nil || playlist.Advance() {
+		t.Error("Shuffle with repeat off should end after every track has played")
+	}
+}
+
+func TestShuffle_PlayOrder(t *testing.T) {
+	playlist := shuffledQueue(7, 8)
+
+	var got []string
+	for _, track := range playlist.PlayOrder() {
+		got = append(got, track.Filename)
+	}
+	var want []string
+	tracks := playlist.GetTrackList()
+	for _, idx := range playOrder(shuffledQueue(7, 8)) {
+		want = append(want, tracks[idx].Filename)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PlayOrder() = %v, want the shuffled order %v", got, want)
+	}
+
+	playlist.Unshuffle()
+	got = nil
+	for _, track := range playlist.PlayOrder() {
+		got = append(got, track.Filename)
+	}
+	if want := []string{"a", "b", "c", "d", "e", "f", "g", "h"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PlayOrder() unshuffled = %v, want queue order %v", got, want)
+	}
+}
+
+func TestShuffle_SeedIsDeterministic(t *testing.T) {
+	first := playOrder(shuffledQueue(42, 20))
+	second := playOrder(shuffledQueue(42, 20))
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Same seed gave different orders:\n%v\n%v", first, second)
+	}
+}
+
+func TestShuffle_BackRetracesHistory(t *testing.T) {
+	playlist := shuffledQueue(7, 8)
+
+	var played []int
+	for i := 0; i < 5; i++ {
+		played = append(played, playlist.GetCurrentIndex())
+		playlist.Next()
+	}
+
+	for i := len(played) - 1; i >= 0; i-- {
+		if !playlist.Previous() {
+			t.Fatalf("Previous() failed with %d tracks of history left", i+1)
+		}
+		if got := playlist.GetCurrentIndex(); got != played[i] {
+			t.Errorf("Previous() = track %d, want %d", got, played[i])
+		}
+	}
+	if playlist.Previous() {
+		t.Error("Previous() should stop at the first track of the cycle")
+	}
+
+	// Going forward again replays the same tracks
+	playlist.Next()
+	if got := playlist.GetCurrentIndex(); got != played[1] {
+		t.Errorf("Next() after back = track %d, want %d", got, played[1])
+	}
+}
+
+func TestShuffle_JumpThenBack(t *testing.T) {
+	playlist := shuffledQueue(3, 6)
+	playlist.Next()
+	before := playlist.GetCurrentIndex()
+
+	// Jump to a track that hasn't played yet and isn't simply the next one
+	next := playlist.PeekNext().Filename
+	jumped := -1
+	for i, track := range playlist.GetTrackList() {
+		if i != 0 && i != before && track.Filename != next {
+			jumped = i
+			break
+		}
+	}
+	playlist.SetCurrentIndex(jumped)
+
+	if !playlist.Previous() || playlist.GetCurrentIndex() != before {
+		t.Errorf("Back after a jump = track %d, want %d", playlist.GetCurrentIndex(), before)
+	}
+	if !playlist.Next() || playlist.GetCurrentIndex() != jumped {
+		t.Errorf("Next after back = track %d, want the jumped-to track %d", playlist.GetCurrentIndex(), jumped)
+	}
+
+	// The jumped-to track still only plays once in the cycle
+	played := playOrder(playlist)
+	for _, idx := range played[1:] {
+		if idx == jumped {
+			t.Errorf("Track %d played again later in the cycle: %v", jumped, played)
+		}
+	}
+}
+
+func TestShuffle_RepeatAllStartsNewCycle(t *testing.T) {
+	playlist := shuffledQueue(5, 4)
+	playlist.SetRepeatMode(RepeatAll)
+
+	last := playlist.GetCurrentIndex()
+	counts := make(map[int]int)
+	for i := 0; i < 12; i++ {
+		if !playlist.Advance() {
+			t.Fatal("Advance() should never end with repeat all")
+		}
+		current := playlist.GetCurrentIndex()
+		if current == last {
+			t.Errorf("Track %d played twice in a row across cycles", current)
+		}
+		counts[current]++
+		last = current
+	}
+
+	// 12 advances span three to four cycles of 4 tracks
+	for idx := 0; idx < 4; idx++ {
+		if counts[idx] < 2 || counts[idx] > 4 {
+			t.Errorf("Track %d played %d times in 12 advances", idx, counts[idx])
+		}
+	}
+}
+
+func TestShuffle_UnshuffleKeepsCurrentTrack(t *testing.T) {
+	playlist := shuffledQueue(9, 10)
+	playlist.Next()
+	playlist.Next()
+	playlist.SetCurrentIndex(4)
+
+	playlist.Unshuffle()
+	if got := playlist.GetCurrentIndex(); got != 4 {
+		t.Errorf("Unshuffle() moved from track 4 to %d", got)
+	}
+	if next := playlist.PeekNext(); next == nil || next.Filename != "f" {
+		t.Errorf("After unshuffle the next track should be f, got %v", next)
+	}
+}
+
+func TestShuffle_RemoveCurrentFollowsPlayOrder(t *testing.T) {
+	playlist := shuffledQueue(11, 6)
+	next := playlist.PeekNext()
+
+	current := playlist.GetCurrentIndex()
+	if currentRemoved, _ := playlist.Remove(current, current); !currentRemoved {
+		t.Fatal("Remove() should report the current track was removed")
+	}
+	if got := playlist.GetCurrentTrack(); got != next {
+		t.Errorf("After removing the current track, current = %v, want the next in play order %v", got, next)
+	}
+	if played := playOrder(playlist); len(played) != 5 {
+		t.Errorf("Remaining cycle has %d tracks, want 5", len(played))
+	}
+}
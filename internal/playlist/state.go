@@ -0,0 +1,90 @@
+package playlist
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// State is a snapshot of the queue and its play modes, used to save the
+// session and restore it later
+type State struct {
+	Tracks     []*shared.Track   `json:"tracks"`
+	Source     string            `json:"source,omitempty"`
+	SourceType shared.SourceType `json:"source_type,omitempty"`
+	CurrentIdx int               `json:"current_idx"`
+	Repeat     RepeatMode        `json:"repeat"`
+	Shuffled   bool              `json:"shuffled,omitempty"`
+	Order      []int             `json:"order,omitempty"`     // Shuffled play order
+	OrderPos   int               `json:"order_pos,omitempty"` // Position of the current track in Order
+}
+
+// Snapshot returns a copy of the playlist's state
+func (p *Playlist) Snapshot() State {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	return State{
+		Tracks:     slices.Clone(p.tracks),
+		Source:     p.source,
+		SourceType: p.sourceType,
+		CurrentIdx: p.currentIdx,
+		Repeat:     p.repeatMode,
+		Shuffled:   p.isShuffled,
+		Order:      slices.Clone(p.order),
+		OrderPos:   p.orderPos,
+	}
+}
+
+// Restore replaces the playlist with a saved state. A shuffle order that
+// doesn't match the tracks is replaced with a fresh one starting at the
+// current track.
+func (p *Playlist) Restore(state State) error {
+	if len(state.Tracks) == 0 {
+		return fmt.Errorf("saved queue is empty")
+	}
+	if state.CurrentIdx < 0 || state.CurrentIdx >= len(state.Tracks) {
+		return fmt.Errorf("saved track index %d is out of range", state.CurrentIdx)
+	}
+	if state.Repeat < RepeatOff || state.Repeat > RepeatOne {
+		return fmt.Errorf("unknown repeat mode %d", state.Repeat)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.tracks = p.withIDs(slices.Clone(state.Tracks))
+	p.source = state.Source
+	p.sourceType = state.SourceType
+	p.currentIdx = state.CurrentIdx
+	p.repeatMode = state.Repeat
+	p.unshuffle()
+
+	if state.Shuffled {
+		if validOrder(state.Order, len(state.Tracks), state.OrderPos, state.CurrentIdx) {
+			p.isShuffled = true
+			p.order = slices.Clone(state.Order)
+			p.orderPos = state.OrderPos
+		} else {
+			p.shuffle()
+		}
+	}
+	return nil
+}
+
+// validOrder reports whether order is a permutation of count track indices
+// with current at pos
+func validOrder(order []int, count, pos, current int) bool {
+	if len(order) != count || pos < 0 || pos >= count || order[pos] != current {
+		return false
+	}
+	seen := make([]bool, len(order))
+	for _, idx := range order {
+		if idx < 0 || idx >= len(order) || seen[idx] {
+			return false
+		}
+		seen[idx] = true
+	}
+	return true
+}
@@ -0,0 +1,65 @@
+package playlist
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestState_RestoreKeepsShuffleHistory(t *testing.T) {
+	original := shuffledQueue(3, 8)
+	original.SetRepeatMode(RepeatAll)
+	original.Next()
+	original.Next()
+
+	restored := NewPlaylist()
+	if err := restored.Restore(original.Snapshot()); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+
+	if got, want := restored.GetCurrentIndex(), original.GetCurrentIndex(); got != want {
+		t.Errorf("Current index = %d, want %d", got, want)
+	}
+	if !restored.IsShuffled() || restored.GetRepeatMode() != RepeatAll {
+		t.Errorf("Restore() lost play modes: shuffled=%v repeat=%v", restored.IsShuffled(), restored.GetRepeatMode())
+	}
+
+	// back retraces the same history, and the rest of the cycle is unchanged
+	restored.Previous()
+	original.Previous()
+	if got, want := restored.GetCurrentIndex(), original.GetCurrentIndex(); got != want {
+		t.Errorf("After back, current index = %d, want %d", got, want)
+	}
+	if got, want := playOrder(restored), playOrder(original); !reflect.DeepEqual(got, want) {
+		t.Errorf("Restored play order = %v, want %v", got, want)
+	}
+}
+
+func TestState_RestoreReshufflesBadOrder(t *testing.T) {
+	state := shuffledQueue(4, 5).Snapshot()
+	state.Order = []int{0, 1, 1, 2, 3}
+
+	playlist := NewPlaylist()
+	if err := playlist.Restore(state); err != nil {
+		t.Fatalf("Restore() failed: %v", err)
+	}
+	if played := playOrder(playlist); len(played) != 5 {
+		t.Errorf("Reshuffled cycle played %v, want all 5 tracks", played)
+	}
+}
+
+func TestState_RestoreRejectsInvalidState(t *testing.T) {
+	valid := shuffledQueue(5, 3).Snapshot()
+
+	empty := valid
+	empty.Tracks = nil
+	outOfRange := valid
+	outOfRange.CurrentIdx = 3
+	badRepeat := valid
+	badRepeat.Repeat = 7
+
+	for name, state := range map[string]State{"empty": empty, "index": outOfRange, "repeat": badRepeat} {
+		if err := NewPlaylist().Restore(state); err == nil {
+			t.Errorf("Restore() with bad %s should fail", name)
+		}
+	}
+}
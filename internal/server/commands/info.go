@@ -3,6 +3,8 @@ package commands
 import (
 	"fmt"
 	"log"
+	"math"
+	"time"
 
 	"github.com/cerberussg/auxbox/internal/audio"
 	"github.com/cerberussg/auxbox/internal/playlist"
@@ -22,23 +24,55 @@ func NewInfoHandler(player *audio.Player, playlist *playlist.Playlist) *InfoHand
 }
 
 func (h *InfoHandler) HandleStatus() shared.Response {
+	trackInfo := h.TrackInfo()
+	if trackInfo == nil {
+		return shared.NewSuccessResponse("No track loaded", nil)
+	}
+
+	return shared.NewSuccessResponse("Current status", *trackInfo)
+}
+
+// TrackInfo describes the current track and playback state, or returns nil
+// if no track is loaded
+func (h *InfoHandler) TrackInfo() *shared.TrackInfo {
 	currentTrack := h.playlist.GetCurrentTrack()
 	if currentTrack == nil {
-		return shared.NewSuccessResponse("No track loaded", nil)
+		return nil
 	}
 
 	status := h.player.GetStatus()
-	trackInfo := shared.TrackInfo{
+	state := "stopped"
+	switch {
+	case status.IsPlaying:
+		state = "playing"
+	case status.IsPaused:
+		state = "paused"
+	}
+
+	return &shared.TrackInfo{
 		Filename:    currentTrack.Filename,
 		Path:        currentTrack.Path,
+		Title:       currentTrack.Title,
 		Duration:    status.Duration,
 		Position:    status.Position,
 		TrackNumber: h.playlist.GetCurrentIndex() + 1,
 		TotalTracks: h.playlist.TrackCount(),
 		Source:      h.playlist.GetSource(),
+		SampleRate:  status.SampleRate,
+		OutputRate:  status.OutputRate,
+		State:       state,
+		Volume:      int(math.Round(status.Volume * 100)),
+		Shuffle:     h.playlist.IsShuffled(),
+		Repeat:      h.playlist.GetRepeatMode().String(),
+		Artist:      currentTrack.Artist,
+		Album:       currentTrack.Album,
+		Genre:       currentTrack.Genre,
+		BPM:         currentTrack.BPM,
+		Key:         currentTrack.Key,
+		Label:       currentTrack.Label,
+		Comment:     currentTrack.Comment,
+		Rating:      currentTrack.Rating,
 	}
-
-	return shared.NewSuccessResponse("Current status", trackInfo)
 }
 
 func (h *InfoHandler) HandleList() shared.Response {
@@ -52,7 +86,7 @@ func (h *InfoHandler) HandleList() shared.Response {
 
 	trackNames := make([]string, len(tracks))
 	for i, track := range tracks {
-		trackNames[i] = track.Filename
+		trackNames[i] = track.DisplayName()
 	}
 
 	playlistInfo := shared.PlaylistInfo{
@@ -97,3 +131,54 @@ func (h *InfoHandler) HandleVolume(cmd shared.Command) shared.Response {
 
 	return shared.NewSuccessResponse(fmt.Sprintf("Volume set to %d%%", cmd.Volume), nil)
 }
+
+// HandleResample shows or sets the resampler quality used when a track's
+// sample rate differs from the speaker's
+func (h *InfoHandler) HandleResample(cmd shared.Command) shared.Response {
+	if cmd.Quality == -1 {
+		quality := h.player.GetResampleQuality()
+		return shared.NewSuccessResponse(
+			fmt.Sprintf("Resample quality: %d", quality),
+			map[string]interface{}{"quality": quality},
+		)
+	}
+
+	if err := h.player.SetResampleQuality(cmd.Quality); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to set resample quality: %v", err))
+	}
+
+	log.Printf("Resample quality set to %d", cmd.Quality)
+	return shared.NewSuccessResponse(fmt.Sprintf("Resample quality set to %d (applies from the next track)", cmd.Quality), nil)
+}
+
+// HandleCrossfade shows or sets how long tracks overlap when playback moves on
+func (h *InfoHandler) HandleCrossfade(cmd shared.Command) shared.Response {
+	if cmd.Seconds < 0 {
+		duration, fadeSkips := h.player.GetCrossfade()
+		data := map[string]interface{}{
+			"seconds":    duration.Seconds(),
+			"fade_skips": fadeSkips,
+		}
+		return shared.NewSuccessResponse(crossfadeMessage("Crossfade", duration, fadeSkips), data)
+	}
+
+	duration := time.Duration(cmd.Seconds * float64(time.Second))
+	if err := h.player.SetCrossfade(duration, cmd.FadeSkips); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to set crossfade: %v", err))
+	}
+
+	message := crossfadeMessage("Crossfade set to", duration, cmd.FadeSkips)
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// crossfadeMessage describes a crossfade setting after prefix
+func crossfadeMessage(prefix string, duration time.Duration, fadeSkips bool) string {
+	if duration == 0 {
+		return "Crossfade off"
+	}
+	if fadeSkips {
+		return fmt.Sprintf("%s %gs (also on skip/back)", prefix, duration.Seconds())
+	}
+	return fmt.Sprintf("%s %gs", prefix, duration.Seconds())
+}
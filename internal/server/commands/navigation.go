@@ -3,13 +3,18 @@ package commands
 import (
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/cerberussg/auxbox/internal/audio"
 	"github.com/cerberussg/auxbox/internal/playlist"
 	"github.com/cerberussg/auxbox/internal/shared"
 )
 
-// NavigationHandler handles skip/back commands
+// maxJumpAlternatives caps how many other matches a fuzzy jump lists
+const maxJumpAlternatives = 5
+
+// NavigationHandler handles skip/back/jump commands
 type NavigationHandler struct {
 	player   *audio.Player
 	playlist *playlist.Playlist
@@ -46,7 +51,7 @@ func (h *NavigationHandler) HandleSkip(cmd shared.Command) shared.Response {
 	// Update player with new current track
 	currentTrack := h.playlist.GetCurrentTrack()
 	if currentTrack != nil {
-		h.player.SetCurrentTrack(currentTrack)
+		h.player.SkipTo(currentTrack)
 		log.Printf("Skipped %d track(s), now at: %s", skipped, currentTrack.Filename)
 		return shared.NewSuccessResponse(
 			fmt.Sprintf("Skipped %d track(s), now playing: %s", skipped, currentTrack.Filename),
@@ -80,7 +85,7 @@ func (h *NavigationHandler) HandleBack(cmd shared.Command) shared.Response {
 	// Update player with new current track
 	currentTrack := h.playlist.GetCurrentTrack()
 	if currentTrack != nil {
-		h.player.SetCurrentTrack(currentTrack)
+		h.player.SkipTo(currentTrack)
 		log.Printf("Moved back %d track(s), now at: %s", moved, currentTrack.Filename)
 		return shared.NewSuccessResponse(
 			fmt.Sprintf("Moved back %d track(s), now playing: %s", moved, currentTrack.Filename),
@@ -89,4 +94,66 @@ func (h *NavigationHandler) HandleBack(cmd shared.Command) shared.Response {
 	}
 
 	return shared.NewSuccessResponse(fmt.Sprintf("Moved back %d track(s)", moved), nil)
-}
\ No newline at end of file
+}
+
+// HandleJump moves straight to a queue entry, given either its number as
+// shown by list or part of its filename
+func (h *NavigationHandler) HandleJump(cmd shared.Command) shared.Response {
+	query := strings.TrimSpace(cmd.Query)
+	if query == "" {
+		return shared.NewErrorResponse("No track number or name given")
+	}
+
+	trackCount := h.playlist.TrackCount()
+	if trackCount == 0 {
+		return shared.NewErrorResponse("No tracks loaded")
+	}
+
+	var idx int
+	var others []int
+	if number, err := strconv.Atoi(query); err == nil {
+		if number < 1 || number > trackCount {
+			return shared.NewErrorResponse(fmt.Sprintf("Track number must be between 1 and %d", trackCount))
+		}
+		idx = number - 1
+	} else {
+		matches := h.playlist.Find(query)
+		if len(matches) == 0 {
+			return shared.NewErrorResponse(fmt.Sprintf("No track matches %q", query))
+		}
+		idx, others = matches[0], matches[1:]
+	}
+
+	if !h.playlist.SetCurrentIndex(idx) {
+		return shared.NewErrorResponse(fmt.Sprintf("Track %d is no longer in the queue", idx+1))
+	}
+
+	currentTrack := h.playlist.GetCurrentTrack()
+	h.player.SkipTo(currentTrack)
+	log.Printf("Jumped to track %d: %s", idx+1, currentTrack.Filename)
+
+	message := fmt.Sprintf("Jumped to %d. %s", idx+1, currentTrack.Filename)
+	if len(others) > 0 {
+		message += h.describeOtherMatches(others)
+	}
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// describeOtherMatches lists the matches a fuzzy jump passed over, so an
+// ambiguous query can be narrowed down
+func (h *NavigationHandler) describeOtherMatches(others []int) string {
+	tracks := h.playlist.GetTrackList()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%d other track(s) also match:", len(others))
+	for i, idx := range others {
+		if i == maxJumpAlternatives {
+			fmt.Fprintf(&b, "\n  ... and %d more", len(others)-maxJumpAlternatives)
+			break
+		}
+		if idx < len(tracks) {
+			fmt.Fprintf(&b, "\n  %d. %s", idx+1, tracks[idx].Filename)
+		}
+	}
+	return b.String()
+}
@@ -82,6 +82,33 @@ func (h *PlaybackHandler) HandlePlay() shared.Response {
 	return shared.NewSuccessResponse("Playback started", nil)
 }
 
+// HandleSeek moves playback within the current track
+func (h *PlaybackHandler) HandleSeek(cmd shared.Command) shared.Response {
+	target, err := shared.ParseSeekTarget(cmd.Position)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Invalid seek position: %v", err))
+	}
+
+	if h.player.GetCurrentTrack() == nil {
+		return shared.NewErrorResponse("No track loaded")
+	}
+
+	duration := h.player.GetDuration()
+	if target.Kind == shared.SeekPercent && duration <= 0 {
+		return shared.NewErrorResponse("Track length is unknown, so it can't be seeked by percent")
+	}
+	position := target.Resolve(h.player.GetPosition(), duration)
+	if err := h.player.Seek(position); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to seek: %v", err))
+	}
+
+	log.Printf("Seeked to %s", audio.FormatDuration(position))
+	return shared.NewSuccessResponse(
+		fmt.Sprintf("Seeked to %s / %s", audio.FormatDuration(position), audio.FormatDuration(duration)),
+		nil,
+	)
+}
+
 // HandlePause handles the pause command
 func (h *PlaybackHandler) HandlePause() shared.Response {
 	if err := h.player.Pause(); err != nil {
@@ -0,0 +1,140 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/cerberussg/auxbox/internal/audio"
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// QueueHandler handles commands that edit the queue in place
+type QueueHandler struct {
+	player   *audio.Player
+	playlist *playlist.Playlist
+}
+
+// NewQueueHandler creates a new queue command handler
+func NewQueueHandler(player *audio.Player, playlist *playlist.Playlist) *QueueHandler {
+	return &QueueHandler{
+		player:   player,
+		playlist: playlist,
+	}
+}
+
+// HandleAdd adds loaded tracks to the end of the queue, or right after the
+// current track when playNext is set
+func (h *QueueHandler) HandleAdd(tracks []*shared.Track, skipped []shared.SkippedEntry, playNext bool) shared.Response {
+	if len(tracks) == 0 {
+		if len(skipped) > 0 {
+			return shared.NewErrorResponse(fmt.Sprintf("No playable tracks in playlist (%d entries skipped)", len(skipped)))
+		}
+		return shared.NewErrorResponse("No audio files found in the specified location")
+	}
+
+	var message string
+	if playNext {
+		h.playlist.InsertNext(tracks)
+		message = fmt.Sprintf("Queued %d track(s) to play next", len(tracks))
+	} else {
+		h.playlist.Append(tracks)
+		message = fmt.Sprintf("Added %d track(s) to the end of the queue", len(tracks))
+	}
+	if len(skipped) > 0 {
+		message += fmt.Sprintf(", skipped %d entries", len(skipped))
+	}
+
+	// The track after the current one may have changed
+	h.player.RefreshPreload()
+
+	log.Println(message)
+	return shared.NewSuccessResponse(message, shared.LoadResult{TrackCount: len(tracks), Skipped: skipped})
+}
+
+// HandleRemove removes the track at cmd.From, or the range cmd.From to
+// cmd.To (1-based, inclusive). Removing the current track moves playback on
+// to the track that followed it.
+func (h *QueueHandler) HandleRemove(cmd shared.Command) shared.Response {
+	first, last := cmd.From, cmd.To
+	if last == 0 {
+		last = first
+	}
+
+	tracks := h.playlist.GetTrackList()
+	if problem := queuePositionProblem(first, len(tracks)); problem != "" {
+		return shared.NewErrorResponse(problem)
+	}
+	if problem := queuePositionProblem(last, len(tracks)); problem != "" {
+		return shared.NewErrorResponse(problem)
+	}
+	if first > last {
+		return shared.NewErrorResponse(fmt.Sprintf("Invalid range: %d-%d", first, last))
+	}
+
+	currentRemoved, ok := h.playlist.Remove(first-1, last-1)
+	if !ok {
+		return shared.NewErrorResponse("The queue changed, try again")
+	}
+
+	if currentRemoved {
+		// SkipTo keeps playing if we were; nil stops when nothing is left
+		h.player.SkipTo(h.playlist.GetCurrentTrack())
+	} else {
+		h.player.RefreshPreload()
+	}
+
+	var message string
+	if first == last {
+		message = fmt.Sprintf("Removed %d. %s", first, tracks[first-1].Filename)
+	} else {
+		message = fmt.Sprintf("Removed %d tracks (%d-%d)", last-first+1, first, last)
+	}
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// HandleMove moves the track at cmd.From to position cmd.To (1-based)
+func (h *QueueHandler) HandleMove(cmd shared.Command) shared.Response {
+	tracks := h.playlist.GetTrackList()
+	if problem := queuePositionProblem(cmd.From, len(tracks)); problem != "" {
+		return shared.NewErrorResponse(problem)
+	}
+	if problem := queuePositionProblem(cmd.To, len(tracks)); problem != "" {
+		return shared.NewErrorResponse(problem)
+	}
+
+	if !h.playlist.Move(cmd.From-1, cmd.To-1) {
+		return shared.NewErrorResponse("The queue changed, try again")
+	}
+	h.player.RefreshPreload()
+
+	message := fmt.Sprintf("Moved %s to position %d", tracks[cmd.From-1].Filename, cmd.To)
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// HandleClear removes every track after the current one
+func (h *QueueHandler) HandleClear() shared.Response {
+	removed := h.playlist.ClearUpcoming()
+	if removed == 0 {
+		return shared.NewSuccessResponse("No upcoming tracks to clear", nil)
+	}
+	h.player.RefreshPreload()
+
+	message := fmt.Sprintf("Cleared %d upcoming track(s)", removed)
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// queuePositionProblem describes why a 1-based position is outside the
+// queue, or returns "" if it is valid
+func queuePositionProblem(position, trackCount int) string {
+	if trackCount == 0 {
+		return "No tracks loaded"
+	}
+	if position < 1 || position > trackCount {
+		return fmt.Sprintf("Queue position must be between 1 and %d, got %d", trackCount, position)
+	}
+	return ""
+}
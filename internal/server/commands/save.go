@@ -0,0 +1,112 @@
+package commands
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/playlist/formats"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// SaveHandler handles writing the current queue to a playlist file
+type SaveHandler struct {
+	playlist *playlist.Playlist
+	formats  *formats.FormatRegistry
+}
+
+// NewSaveHandler creates a new save command handler
+func NewSaveHandler(playlist *playlist.Playlist) *SaveHandler {
+	return &SaveHandler{
+		playlist: playlist,
+		formats:  formats.NewFormatRegistry(),
+	}
+}
+
+// HandleSave writes the queue, in the order it plays (shuffled or not), to
+// cmd.Path.
+// The format is chosen from the file extension (.m3u8/.m3u, .pls, .xspf).
+func (h *SaveHandler) HandleSave(cmd shared.Command) shared.Response {
+	if cmd.Path == "" {
+		return shared.NewErrorResponse("No playlist path given")
+	}
+
+	format := h.formats.FormatForExtension(cmd.Path)
+	if format == "" {
+		return shared.NewErrorResponse(fmt.Sprintf("Unsupported playlist format: %q (use .m3u8, .m3u, .pls or .xspf)", filepath.Ext(cmd.Path)))
+	}
+
+	tracks := h.playlist.PlayOrder()
+	if len(tracks) == 0 {
+		return shared.NewErrorResponse("No tracks loaded")
+	}
+
+	if cmd.Relative {
+		baseDir := filepath.Dir(cmd.Path)
+		for _, track := range tracks {
+			if rel, err := filepath.Rel(baseDir, track.Path); err == nil {
+				track.Path = filepath.ToSlash(rel)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := h.formats.Write(cmd.Path, &buf, tracks); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to save playlist: %v", err))
+	}
+
+	if err := writeFileAtomic(cmd.Path, buf.Bytes()); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to save playlist: %v", err))
+	}
+
+	log.Printf("Saved %d tracks to %s (%s)", len(tracks), cmd.Path, format)
+	return shared.NewSuccessResponse(fmt.Sprintf("Saved %d tracks to %s", len(tracks), cmd.Path), nil)
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so an existing playlist is never left half-written. A symlink
+// is followed, so the file it points to is replaced and the link kept, and
+// an existing file keeps its permissions.
+func writeFileAtomic(path string, data []byte) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if stat, err := os.Stat(path); err == nil {
+		mode = stat.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Chmod(tmpPath, mode); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package commands
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/cerberussg/auxbox/internal/audio/decoders"
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// TagHandler handles commands that write tags to the current track's file
+type TagHandler struct {
+	playlist   *playlist.Playlist
+	formats    *decoders.FormatRegistry
+	vocabulary *Vocabulary
+}
+
+// NewTagHandler creates a new tag command handler
+func NewTagHandler(playlist *playlist.Playlist) *TagHandler {
+	return &TagHandler{
+		playlist:   playlist,
+		formats:    decoders.NewFormatRegistry(),
+		vocabulary: &Vocabulary{},
+	}
+}
+
+// SetVocabulary replaces the in-memory vocabulary of genres and labels
+func (h *TagHandler) SetVocabulary(vocabulary *Vocabulary) {
+	h.vocabulary = vocabulary
+}
+
+// HandleStars rates the current track from 1 to 5 stars, or clears its
+// rating with 0. The file is replaced rather than rewritten in place, so a
+// track that is playing carries on.
+func (h *TagHandler) HandleStars(cmd shared.Command) shared.Response {
+	if cmd.Stars < 0 || cmd.Stars > 5 {
+		return shared.NewErrorResponse(fmt.Sprintf("Invalid rating %d: use 0-5 stars", cmd.Stars))
+	}
+
+	idx := h.playlist.GetCurrentIndex()
+	track := h.playlist.GetCurrentTrack()
+	if track == nil {
+		return shared.NewErrorResponse("No track loaded")
+	}
+
+	stars := cmd.Stars
+	if err := h.formats.WriteTags(track.Path, decoders.TagChanges{Rating: &stars}); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to rate %s: %v", track.Filename, err))
+	}
+	h.playlist.UpdateTrack(idx, track.Path, func(t *shared.Track) { t.Rating = stars })
+
+	message := fmt.Sprintf("Rated %s %s", track.DisplayName(), shared.FormatStars(stars))
+	if stars == 0 {
+		message = fmt.Sprintf("Cleared the rating of %s", track.DisplayName())
+	}
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// textField describes a text tag that can be set from a command
+type textField struct {
+	key   string // Metadata key, e.g. GENRE
+	name  string // As shown in messages, e.g. genre
+	multi bool   // Whether it can hold several values
+	get   func(*shared.Track) string
+	set   func(*shared.Track, []string)
+}
+
+var (
+	genreField = textField{
+		key: "GENRE", name: "genre", multi: true,
+		get: func(t *shared.Track) string { return t.Genre },
+		set: func(t *shared.Track, values []string) { t.Genre = strings.Join(values, ", ") },
+	}
+	labelField = textField{
+		key: "LABEL", name: "label",
+		get: func(t *shared.Track) string { return t.Label },
+		set: func(t *shared.Track, values []string) { t.Label = strings.Join(values, ", ") },
+	}
+)
+
+// HandleGenre sets the current track's genres, clears them, or with no
+// values shows them along with the genres used before
+func (h *TagHandler) HandleGenre(cmd shared.Command) shared.Response {
+	return h.handleText(genreField, cmd)
+}
+
+// HandleLabel sets the current track's label, clears it, or with no value
+// shows it along with the labels used before
+func (h *TagHandler) HandleLabel(cmd shared.Command) shared.Response {
+	return h.handleText(labelField, cmd)
+}
+
+func (h *TagHandler) handleText(field textField, cmd shared.Command) shared.Response {
+	idx := h.playlist.GetCurrentIndex()
+	track := h.playlist.GetCurrentTrack()
+
+	var values []string
+	for _, value := range cmd.Values {
+		value = strings.TrimSpace(value)
+		if value != "" && !slices.ContainsFunc(values, func(v string) bool { return strings.EqualFold(v, value) }) {
+			values = append(values, value)
+		}
+	}
+
+	if cmd.Forget {
+		return h.forget(field, values)
+	}
+
+	if len(values) == 0 && !cmd.Clear {
+		result := shared.TagValues{Known: h.vocabulary.Known(field.key)}
+		if track != nil {
+			result.Current = field.get(track)
+		}
+		return shared.NewSuccessResponse("", result)
+	}
+
+	if track == nil {
+		return shared.NewErrorResponse("No track loaded")
+	}
+	if cmd.Clear {
+		values = nil
+	}
+	if len(values) > 1 && !field.multi {
+		return shared.NewErrorResponse(fmt.Sprintf("A track has one %s, got %d", field.name, len(values)))
+	}
+
+	for i, value := range values {
+		canonical, suggestion := h.vocabulary.Resolve(field.key, value)
+		if suggestion != "" && !cmd.AllowNew {
+			return shared.NewErrorResponse(fmt.Sprintf("%s %q looks like a typo of %q; use --new to add it as a new %s",
+				strings.ToUpper(field.name[:1])+field.name[1:], value, suggestion, field.name))
+		}
+		values[i] = canonical
+	}
+
+	changes := decoders.TagChanges{Text: map[string][]string{field.key: values}}
+	if err := h.formats.WriteTags(track.Path, changes); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to set the %s of %s: %v", field.name, track.Filename, err))
+	}
+	h.playlist.UpdateTrack(idx, track.Path, func(t *shared.Track) { field.set(t, values) })
+
+	if err := h.vocabulary.Add(field.key, values...); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+
+	message := fmt.Sprintf("Set %s of %s to %q", field.name, track.DisplayName(), strings.Join(values, ", "))
+	if len(values) == 0 {
+		message = fmt.Sprintf("Cleared the %s of %s", field.name, track.DisplayName())
+	}
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// forget drops values from the known values of field, without changing any
+// file, so a typo added with --new stops being offered
+func (h *TagHandler) forget(field textField, values []string) shared.Response {
+	if len(values) == 0 {
+		return shared.NewErrorResponse(fmt.Sprintf("No %s to forget", field.name))
+	}
+
+	removed, err := h.vocabulary.Remove(field.key, values...)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to forget %s: %v", field.name, err))
+	}
+	if len(removed) == 0 {
+		return shared.NewErrorResponse(fmt.Sprintf("Unknown %s %q", field.name, strings.Join(values, ", ")))
+	}
+
+	message := fmt.Sprintf("Forgot %s %q", field.name, strings.Join(removed, ", "))
+	log.Println(message)
+	return shared.NewSuccessResponse(message, nil)
+}
+
+// Learn adds the genres and labels that tracks were loaded with to the
+// vocabulary, so typos of a library's existing values are caught before
+// any have been set with auxbox
+func (h *TagHandler) Learn(tracks []*shared.Track) {
+	var genres, labels []string
+	for _, track := range tracks {
+		// The loader joins a track's genres with ", "
+		for _, genre := range strings.Split(track.Genre, ", ") {
+			if genre = strings.TrimSpace(genre); genre != "" && !slices.Contains(genres, genre) {
+				genres = append(genres, genre)
+			}
+		}
+		if track.Label != "" && !slices.Contains(labels, track.Label) {
+			labels = append(labels, track.Label)
+		}
+	}
+
+	if err := h.vocabulary.Add(genreField.key, genres...); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	if err := h.vocabulary.Add(labelField.key, labels...); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Vocabulary is the genres and labels used before, kept so values can be
+// completed and typos of known ones caught
+type Vocabulary struct {
+	mu     sync.Mutex
+	path   string   // Where it is saved; empty keeps it in memory only
+	Genres []string `json:"genres,omitempty"`
+	Labels []string `json:"labels,omitempty"`
+}
+
+// LoadVocabulary reads the vocabulary saved at path. A missing file gives an
+// empty vocabulary that is created on the first Add.
+func LoadVocabulary(path string) (*Vocabulary, error) {
+	v := &Vocabulary{path: path}
+	if path == "" {
+		return v, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return v, nil
+}
+
+// values returns the list for field, "GENRE" or "LABEL"
+func (v *Vocabulary) values(field string) *[]string {
+	if field == "LABEL" {
+		return &v.Labels
+	}
+	return &v.Genres
+}
+
+// Known returns the known values of field, sorted
+func (v *Vocabulary) Known(field string) []string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	known := slices.Clone(*v.values(field))
+	slices.SortFunc(known, func(a, b string) int {
+		return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+	})
+	return known
+}
+
+// Complete returns the known values of field starting with prefix, ignoring
+// case
+func (v *Vocabulary) Complete(field, prefix string) []string {
+	var matches []string
+	for _, value := range v.Known(field) {
+		if strings.HasPrefix(strings.ToLower(value), strings.ToLower(prefix)) {
+			matches = append(matches, value)
+		}
+	}
+	return matches
+}
+
+// Resolve looks value up among the known values of field. A match ignoring
+// case returns the known spelling; otherwise a known value within a typo or
+// two of it is returned as a suggestion.
+func (v *Vocabulary) Resolve(field, value string) (canonical, suggestion string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	known := *v.values(field)
+	for _, k := range known {
+		if strings.EqualFold(k, value) {
+			return k, ""
+		}
+	}
+
+	// Short names are allowed fewer typos, so "Dub" is not mistaken for "Dnb"
+	maxDistance := 2
+	if len([]rune(value)) <= 5 {
+		maxDistance = 1
+	}
+	best := maxDistance + 1
+	for _, k := range known {
+		if d := editDistance(strings.ToLower(k), strings.ToLower(value)); d < best {
+			best, suggestion = d, k
+		}
+	}
+	return value, suggestion
+}
+
+// Add records values of field as known and saves the vocabulary
+func (v *Vocabulary) Add(field string, values ...string) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	list := v.values(field)
+	changed := false
+	for _, value := range values {
+		if value == "" || slices.ContainsFunc(*list, func(k string) bool { return strings.EqualFold(k, value) }) {
+			continue
+		}
+		*list = append(*list, value)
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return v.save()
+}
+
+// Remove forgets values of field, ignoring case, saves the vocabulary and
+// returns the values it knew
+func (v *Vocabulary) Remove(field string, values ...string) ([]string, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	list := v.values(field)
+	var removed []string
+	*list = slices.DeleteFunc(slices.Clone(*list), func(k string) bool {
+		if slices.ContainsFunc(values, func(value string) bool { return strings.EqualFold(k, value) }) {
+			removed = append(removed, k)
+			return true
+		}
+		return false
+	})
+	if len(removed) == 0 {
+		return nil, nil
+	}
+	return removed, v.save()
+}
+
+// save writes the vocabulary to its file, if it has one (assumes lock is
+// held)
+func (v *Vocabulary) save() error {
+	if v.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode vocabulary: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0755); err != nil {
+		return fmt.Errorf("failed to create vocabulary directory: %w", err)
+	}
+	if err := writeFileAtomic(v.path, data); err != nil {
+		return fmt.Errorf("failed to save vocabulary: %w", err)
+	}
+	return nil
+}
+
+// editDistance counts the insertions, deletions, substitutions and swaps of
+// adjacent letters needed to turn a into b
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// Three rows of the distance table: two back, the previous and the current
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
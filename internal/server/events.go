@@ -0,0 +1,141 @@
+package server
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// eventState is the part of the session that events report on.
+// publishChanges compares it before and after a change to decide which
+// events to send, so every path that changes playback is covered without
+// each handler publishing its own events.
+type eventState struct {
+	track    string // Path of the current track
+	playing  bool
+	paused   bool
+	volume   int // Percentage
+	shuffled bool
+	repeat   playlist.RepeatMode
+}
+
+// Events returns the bus that subscribed clients receive events from
+func (s *Server) Events() *shared.EventBus {
+	return s.events
+}
+
+// captureEventState reads the current eventState from the player and playlist
+func (s *Server) captureEventState() eventState {
+	state := eventState{
+		playing:  s.player.IsPlaying(),
+		paused:   s.player.IsPaused(),
+		volume:   int(math.Round(s.player.GetStatus().Volume * 100)),
+		shuffled: s.playlist.IsShuffled(),
+		repeat:   s.playlist.GetRepeatMode(),
+	}
+	if track := s.player.GetCurrentTrack(); track != nil {
+		state.track = track.Path
+	}
+	return state
+}
+
+// publishChanges sends an event for everything that changed since the last call
+func (s *Server) publishChanges() {
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+
+	prev := s.lastEvents
+	cur := s.captureEventState()
+	s.lastEvents = cur
+
+	if cur.track != prev.track && cur.track != "" {
+		if track := s.trackInfo(); track != nil {
+			s.events.Publish(shared.Event{Type: shared.EventTrack, Message: "Now playing " + track.DisplayName(), Track: track})
+		}
+	}
+
+	switch {
+	case cur.playing && !prev.playing:
+		s.events.Publish(shared.Event{Type: shared.EventPlay, Message: "Playing", Track: s.trackInfo()})
+	case cur.paused && !prev.paused:
+		s.events.Publish(shared.Event{Type: shared.EventPause, Message: "Paused", Track: s.trackInfo()})
+	case !cur.playing && !cur.paused && (prev.playing || prev.paused):
+		s.events.Publish(shared.Event{Type: shared.EventStop, Message: "Stopped", Track: s.trackInfo()})
+	}
+
+	if cur.volume != prev.volume {
+		volume := cur.volume
+		s.events.Publish(shared.Event{Type: shared.EventVolume, Message: fmt.Sprintf("Volume %d%%", volume), Volume: &volume})
+	}
+	if cur.shuffled != prev.shuffled {
+		shuffled := cur.shuffled
+		message := "Shuffle off"
+		if shuffled {
+			message = "Shuffle on"
+		}
+		s.events.Publish(shared.Event{Type: shared.EventShuffle, Message: message, Shuffle: &shuffled})
+	}
+	if cur.repeat != prev.repeat {
+		s.events.Publish(shared.Event{Type: shared.EventRepeat, Message: "Repeat " + cur.repeat.String(), Repeat: cur.repeat.String()})
+	}
+}
+
+// publishQueueChange reports that tracks were loaded, added, removed or moved
+func (s *Server) publishQueueChange() {
+	s.queueVersion.Add(1)
+	total := s.playlist.TrackCount()
+	s.events.Publish(shared.Event{
+		Type:        shared.EventQueue,
+		Message:     fmt.Sprintf("Queue changed, %d tracks", total),
+		TotalTracks: total,
+	})
+}
+
+// publishSeek reports that playback moved within the current track
+func (s *Server) publishSeek() {
+	track := s.trackInfo()
+	if track == nil {
+		return
+	}
+	s.events.Publish(shared.Event{Type: shared.EventSeek, Message: "Seeked to " + track.Position, Track: track})
+}
+
+// playlistVersion identifies the state of the queue for MPD clients, which
+// reload it when the number changes. It starts at 1, as 0 means "never
+// seen" to them.
+func (s *Server) playlistVersion() uint32 {
+	return s.queueVersion.Load() + 1
+}
+
+// publishError reports a track that failed to load
+func (s *Server) publishError(track *shared.Track, err error) {
+	s.events.Publish(shared.Event{
+		Type:    shared.EventError,
+		Message: fmt.Sprintf("Failed to load %s: %v", track.Filename, err),
+		Track:   &shared.TrackInfo{Filename: track.Filename, Path: track.Path, Title: track.Title, Artist: track.Artist},
+	})
+}
+
+// publishEnd reports that playback ran off the end of the queue
+func (s *Server) publishEnd() {
+	s.events.Publish(shared.Event{Type: shared.EventEnd, Message: "Reached the end of the queue"})
+}
+
+// changesQueue reports whether a successful command changed the tracks in
+// the queue, as opposed to just moving through them
+func changesQueue(cmd shared.Command) bool {
+	switch cmd.Type {
+	case shared.CmdEnqueue, shared.CmdPlayNext, shared.CmdRemove, shared.CmdMove, shared.CmdClear, shared.CmdResume:
+		return true
+	case shared.CmdPlay:
+		return cmd.Path != ""
+	}
+	return false
+}
+
+// trackInfo describes the current track, or returns nil if none
+func (s *Server) trackInfo() *shared.TrackInfo {
+	return s.infoHandler.TrackInfo()
+}
@@ -0,0 +1,65 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// drainEvents returns the events received so far
+func drainEvents(events <-chan shared.Event) []shared.Event {
+	var got []shared.Event
+	for {
+		select {
+		case event := <-events:
+			got = append(got, event)
+		default:
+			return got
+		}
+	}
+}
+
+func TestServer_PublishesEvents(t *testing.T) {
+	server := NewServer()
+	events, cancel := server.Events().Subscribe()
+	defer cancel()
+
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: "/music/a.mp3"},
+		{Filename: "b.mp3", Path: "/music/b.mp3"},
+		{Filename: "c.mp3", Path: "/music/c.mp3"},
+	}
+	server.LoadTracks(tracks, "/music", shared.SourceFolder)
+
+	// Settle the initial state, e.g. the default volume
+	server.HandleCommand(shared.NewStatusCommand())
+	drainEvents(events)
+
+	tests := []struct {
+		cmd  shared.Command
+		want shared.EventType
+	}{
+		{shared.Command{Type: shared.CmdShuffle}, shared.EventShuffle},
+		{shared.Command{Type: shared.CmdRepeat}, shared.EventRepeat},
+		{shared.NewVolumeCommand(40), shared.EventVolume},
+		{shared.NewMoveCommand(3, 2), shared.EventQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.cmd.Type), func(t *testing.T) {
+			if resp := server.HandleCommand(tt.cmd); !resp.Success {
+				t.Fatalf("%s failed: %s", tt.cmd.Type, resp.Message)
+			}
+			got := drainEvents(events)
+			if len(got) != 1 || got[0].Type != tt.want {
+				t.Errorf("%s published %+v, want one %s event", tt.cmd.Type, got, tt.want)
+			}
+		})
+	}
+
+	// Commands that change nothing publish nothing
+	server.HandleCommand(shared.NewListCommand())
+	if got := drainEvents(events); len(got) != 0 {
+		t.Errorf("list published %+v, want no events", got)
+	}
+}
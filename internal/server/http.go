@@ -0,0 +1,279 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// DefaultHTTPAddr is where the HTTP API listens unless told otherwise. It
+// only accepts connections from this machine.
+const DefaultHTTPAddr = "127.0.0.1:7733"
+
+// maxHTTPBody caps request bodies; commands are a few hundred bytes at most
+const maxHTTPBody = 64 * 1024
+
+// eventKeepAlive is how often an idle event stream sends a comment, so
+// proxies and browsers don't time it out
+const eventKeepAlive = 30 * time.Second
+
+// httpRoutes maps REST endpoints to socket commands. The request body, if
+// any, is decoded over the command, so it uses the same JSON fields as the
+// socket protocol: POST /seek {"position": "1:30"}.
+var httpRoutes = []struct {
+	pattern string
+	cmd     shared.Command
+}{
+	{"GET /status", shared.NewStatusCommand()},
+	{"GET /queue", shared.NewListCommand()},
+	{"GET /volume", shared.NewVolumeCommand(-1)},
+	{"GET /resample", shared.NewResampleCommand(-1)},
+	{"GET /crossfade", shared.NewCrossfadeCommand(-1, false)},
+	{"GET /genre", shared.NewGenreCommand()},
+	{"GET /label", shared.NewLabelCommand("")},
+
+	{"POST /play", shared.NewPlayCommand()},
+	{"POST /pause", shared.NewPauseCommand()},
+	{"POST /stop", shared.NewStopCommand()},
+	{"POST /skip", shared.NewSkipCommand(1)},
+	{"POST /back", shared.NewBackCommand(1)},
+	{"POST /jump", shared.Command{Type: shared.CmdJump}},
+	{"POST /seek", shared.Command{Type: shared.CmdSeek}},
+	{"POST /shuffle", shared.Command{Type: shared.CmdShuffle}},
+	{"POST /repeat", shared.Command{Type: shared.CmdRepeat}},
+	{"POST /gapless", shared.NewGaplessCommand()},
+	{"POST /volume", shared.Command{Type: shared.CmdVolume}},
+	{"POST /resample", shared.Command{Type: shared.CmdResample}},
+	{"POST /crossfade", shared.Command{Type: shared.CmdCrossfade}},
+	{"POST /save", shared.Command{Type: shared.CmdSave}},
+	{"POST /stars", shared.Command{Type: shared.CmdStars}},
+	{"POST /genre", shared.Command{Type: shared.CmdGenre}},
+	{"POST /label", shared.Command{Type: shared.CmdLabel}},
+	{"POST /resume", shared.NewResumeCommand()},
+	{"POST /exit", shared.NewExitCommand()},
+
+	{"POST /queue", shared.Command{Type: shared.CmdEnqueue}},
+	{"POST /queue/next", shared.Command{Type: shared.CmdPlayNext}},
+	{"POST /queue/remove", shared.Command{Type: shared.CmdRemove}},
+	{"POST /queue/move", shared.Command{Type: shared.CmdMove}},
+	{"POST /queue/clear", shared.NewClearCommand()},
+}
+
+// httpRequired lists the body fields of endpoints that set a value, so a
+// missing one is rejected rather than taken as zero: POST /volume {} would
+// otherwise mute playback
+var httpRequired = map[string][]string{
+	"POST /jump":         {"query"},
+	"POST /seek":         {"position"},
+	"POST /volume":       {"volume"},
+	"POST /resample":     {"quality"},
+	"POST /crossfade":    {"seconds"},
+	"POST /save":         {"path"},
+	"POST /stars":        {"stars"},
+	"POST /queue":        {"path"},
+	"POST /queue/next":   {"path"},
+	"POST /queue/remove": {"from"},
+	"POST /queue/move":   {"from", "to"},
+}
+
+// SetHTTP enables the HTTP API on addr, e.g. "127.0.0.1:7733", for clients
+// that present token as a bearer token. It starts with the server.
+func (s *Server) SetHTTP(addr, token string) error {
+	if token == "" {
+		return fmt.Errorf("the HTTP API requires a token")
+	}
+	if addr == "" {
+		addr = DefaultHTTPAddr
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("invalid HTTP address %q: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.httpAddr = addr
+	s.httpToken = token
+	return nil
+}
+
+// startHTTP starts the HTTP API if it is enabled. A failure is logged
+// rather than stopping the daemon, since the socket still works.
+func (s *Server) startHTTP() {
+	if s.httpAddr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", s.httpAddr)
+	if err != nil {
+		log.Printf("HTTP API disabled: %v", err)
+		return
+	}
+
+	s.httpServer = &http.Server{
+		Handler:           s.httpHandler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Printf("HTTP API listening on http://%s", listener.Addr())
+
+	go func(server *http.Server) {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP API error: %v", err)
+		}
+	}(s.httpServer)
+}
+
+// stopHTTP shuts the HTTP API down, if it is running
+func (s *Server) stopHTTP() {
+	if s.httpServer == nil {
+		return
+	}
+
+	if err := s.httpServer.Close(); err != nil {
+		log.Printf("Error stopping HTTP API: %v", err)
+	}
+	s.httpServer = nil
+}
+
+// httpHandler routes REST requests to the same command handling as the
+// socket. The web remote page itself is public; it asks for the token.
+func (s *Server) httpHandler() http.Handler {
+	api := http.NewServeMux()
+	for _, route := range httpRoutes {
+		api.HandleFunc(route.pattern, func(w http.ResponseWriter, r *http.Request) {
+			s.serveCommand(w, r, route.cmd, httpRequired[route.pattern])
+		})
+	}
+	api.HandleFunc("GET /events", s.serveEvents)
+	api.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		writeHTTPResponse(w, http.StatusNotFound, shared.NewErrorResponse(fmt.Sprintf("Unknown endpoint: %s %s", r.Method, r.URL.Path)))
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /{$}", webRemoteHandler())
+	mux.Handle("/", s.requireToken(api))
+	return mux
+}
+
+// requireToken rejects requests without the configured bearer token.
+// Browsers can't set headers on an EventSource, so /events also accepts
+// the token as a query parameter.
+func (s *Server) requireToken(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok && r.URL.Path == "/events" {
+			token = r.URL.Query().Get("token")
+			ok = token != ""
+		}
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.httpToken)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="auxbox"`)
+			writeHTTPResponse(w, http.StatusUnauthorized, shared.NewErrorResponse("Missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveCommand decodes the request body over cmd and runs it, once the
+// body has every required field
+func (s *Server) serveCommand(w http.ResponseWriter, r *http.Request, cmd shared.Command, required []string) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBody))
+	if err != nil {
+		writeHTTPResponse(w, http.StatusRequestEntityTooLarge, shared.NewErrorResponse("Request body too large"))
+		return
+	}
+
+	if len(strings.TrimSpace(string(body))) > 0 {
+		cmdType := cmd.Type
+		if err := json.Unmarshal(body, &cmd); err != nil {
+			writeHTTPResponse(w, http.StatusBadRequest, shared.NewErrorResponse(fmt.Sprintf("Invalid JSON body: %v", err)))
+			return
+		}
+		// The endpoint decides the command, not the body
+		cmd.Type = cmdType
+	}
+
+	if missing := missingFields(body, required); len(missing) > 0 {
+		writeHTTPResponse(w, http.StatusBadRequest, shared.NewErrorResponse(fmt.Sprintf("Missing %s in request body", strings.Join(missing, ", "))))
+		return
+	}
+
+	resp := s.handleCommand(cmd)
+
+	status := http.StatusOK
+	if !resp.Success {
+		status = http.StatusBadRequest
+	}
+	writeHTTPResponse(w, status, resp)
+}
+
+// missingFields returns the fields that are not keys of the JSON object body
+func missingFields(body []byte, fields []string) []string {
+	var present map[string]json.RawMessage
+	json.Unmarshal(body, &present)
+
+	var missing []string
+	for _, field := range fields {
+		if _, ok := present[field]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// serveEvents streams events as server-sent events until the client goes away
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeHTTPResponse(w, http.StatusInternalServerError, shared.NewErrorResponse("Streaming not supported"))
+		return
+	}
+
+	events, cancel := s.events.Subscribe()
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(eventKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event := <-events:
+			data, err := event.ToJSON()
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+		}
+		flusher.Flush()
+	}
+}
+
+// writeHTTPResponse writes a command response as JSON
+func writeHTTPResponse(w http.ResponseWriter, status int, resp shared.Response) {
+	data, err := resp.ToJSON()
+	if err != nil {
+		status = http.StatusInternalServerError
+		data, _ = shared.NewErrorResponse("failed to serialize response").ToJSON()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,185 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// newHTTPTestServer returns a server with three tracks loaded and its HTTP
+// API behind an httptest server
+func newHTTPTestServer(t *testing.T) (*Server, *httptest.Server) {
+	server := NewServer()
+	if err := server.SetHTTP("", "secret"); err != nil {
+		t.Fatalf("SetHTTP() failed: %v", err)
+	}
+
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: "/music/a.mp3"},
+		{Filename: "b.mp3", Path: "/music/b.mp3"},
+		{Filename: "c.mp3", Path: "/music/c.mp3"},
+	}
+	server.LoadTracks(tracks, "/music", shared.SourceFolder)
+
+	api := httptest.NewServer(server.httpHandler())
+	t.Cleanup(api.Close)
+	return server, api
+}
+
+// doHTTP sends a request with the given token and decodes the response
+func doHTTP(t *testing.T, api *httptest.Server, method, path, token, body string) (int, shared.Response) {
+	req, err := http.NewRequest(method, api.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", method, path, err)
+	}
+	defer res.Body.Close()
+
+	var resp shared.Response
+	if err := json.NewDecoder(res.Body).Decode(&resp); err != nil {
+		t.Fatalf("%s %s returned invalid JSON: %v", method, path, err)
+	}
+	return res.StatusCode, resp
+}
+
+func TestHTTP_SetHTTP(t *testing.T) {
+	server := NewServer()
+	if err := server.SetHTTP("", ""); err == nil {
+		t.Error("SetHTTP() without a token should fail")
+	}
+	if err := server.SetHTTP("localhost", "secret"); err == nil {
+		t.Error("SetHTTP() with an address without a port should fail")
+	}
+	if err := server.SetHTTP("", "secret"); err != nil || server.httpAddr != DefaultHTTPAddr {
+		t.Errorf("SetHTTP() = %v, addr %q, want the default address", err, server.httpAddr)
+	}
+}
+
+func TestHTTP_RequiresToken(t *testing.T) {
+	_, api := newHTTPTestServer(t)
+
+	for _, token := range []string{"", "wrong"} {
+		if status, _ := doHTTP(t, api, "GET", "/queue", token, ""); status != http.StatusUnauthorized {
+			t.Errorf("Token %q: status %d, want 401", token, status)
+		}
+	}
+}
+
+func TestHTTP_Commands(t *testing.T) {
+	server, api := newHTTPTestServer(t)
+
+	status, resp := doHTTP(t, api, "GET", "/queue", "secret", "")
+	if status != http.StatusOK || !resp.Success || !strings.Contains(resp.Message, "3 tracks") {
+		t.Errorf("GET /queue = %d %+v", status, resp)
+	}
+
+	// Bodies use the socket protocol's fields
+	status, resp = doHTTP(t, api, "POST", "/queue/move", "secret", `{"from": 3, "to": 1}`)
+	if status != http.StatusOK || !resp.Success {
+		t.Fatalf("POST /queue/move = %d %+v", status, resp)
+	}
+	if first := server.playlist.GetTrackList()[0].Filename; first != "c.mp3" {
+		t.Errorf("After move the first track is %s, want c.mp3", first)
+	}
+
+	// The endpoint picks the command even if the body names another
+	status, resp = doHTTP(t, api, "POST", "/shuffle", "secret", `{"type": "exit"}`)
+	if status != http.StatusOK || !server.playlist.IsShuffled() {
+		t.Errorf("POST /shuffle = %d %+v, want shuffle on", status, resp)
+	}
+
+	// Failed commands and bad input are 400s with a message
+	if status, resp = doHTTP(t, api, "POST", "/queue/remove", "secret", `{"from": 9}`); status != http.StatusBadRequest || resp.Message == "" {
+		t.Errorf("POST /queue/remove out of range = %d %+v, want 400", status, resp)
+	}
+	if status, _ = doHTTP(t, api, "POST", "/seek", "secret", `{"position":`); status != http.StatusBadRequest {
+		t.Errorf("POST /seek with bad JSON = %d, want 400", status)
+	}
+	// Values that are set must be given, so an empty body doesn't mute
+	volume := server.player.GetStatus().Volume
+	for _, body := range []string{"", "{}", `{"fade_skips": true}`} {
+		if status, resp = doHTTP(t, api, "POST", "/volume", "secret", body); status != http.StatusBadRequest || !strings.Contains(resp.Message, "volume") {
+			t.Errorf("POST /volume %q = %d %+v, want 400", body, status, resp)
+		}
+	}
+	if got := server.player.GetStatus().Volume; got != volume {
+		t.Errorf("Volume after rejected requests = %v, want %v", got, volume)
+	}
+	if status, _ = doHTTP(t, api, "POST", "/stars", "secret", ""); status != http.StatusBadRequest {
+		t.Errorf("POST /stars without stars = %d, want 400", status)
+	}
+	if status, resp = doHTTP(t, api, "GET", "/resample", "secret", ""); status != http.StatusOK || !strings.Contains(resp.Message, "quality") {
+		t.Errorf("GET /resample = %d %+v", status, resp)
+	}
+
+	if status, _ = doHTTP(t, api, "POST", "/nope", "secret", ""); status != http.StatusNotFound {
+		t.Errorf("POST /nope = %d, want 404", status)
+	}
+}
+
+func TestHTTP_WebRemote(t *testing.T) {
+	_, api := newHTTPTestServer(t)
+
+	// The page is public; it asks for the token itself
+	res, err := http.Get(api.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET / = %d %s, want the HTML page", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+}
+
+func TestHTTP_EventStream(t *testing.T) {
+	server, api := newHTTPTestServer(t)
+
+	if res, err := http.Get(api.URL + "/events?token=wrong"); err != nil || res.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GET /events with a wrong token = %v, %v, want 401", res, err)
+	}
+
+	res, err := http.Get(api.URL + "/events?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if res.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events Content-Type = %q", res.Header.Get("Content-Type"))
+	}
+
+	server.HandleCommand(shared.NewVolumeCommand(30))
+
+	reader := bufio.NewReader(res.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Event stream ended: %v", err)
+		}
+		data, ok := strings.CutPrefix(strings.TrimSpace(line), "data: ")
+		if !ok {
+			continue
+		}
+		event, err := shared.EventFromJSON([]byte(data))
+		if err != nil {
+			t.Fatalf("Invalid event %q: %v", data, err)
+		}
+		if event.Type == shared.EventVolume {
+			if event.Volume == nil || *event.Volume != 30 {
+				t.Errorf("Volume event = %+v, want 30%%", event)
+			}
+			return
+		}
+	}
+}
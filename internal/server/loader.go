@@ -3,33 +3,35 @@ package server
 import (
 	"fmt"
 	"log"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"github.com/cerberussg/auxbox/internal/audio/decoders"
+	"github.com/cerberussg/auxbox/internal/playlist/formats"
 	"github.com/cerberussg/auxbox/internal/shared"
 )
 
 // Loader handles loading tracks from various sources
-type Loader struct{}
+type Loader struct {
+	audioFormats    *decoders.FormatRegistry
+	playlistFormats *formats.FormatRegistry
+}
 
 // NewLoader creates a new loader instance
 func NewLoader() *Loader {
-	return &Loader{}
+	return &Loader{
+		audioFormats:    decoders.NewFormatRegistry(),
+		playlistFormats: formats.NewFormatRegistry(),
+	}
 }
 
 // LoadFolder loads audio tracks from a folder
 func (l *Loader) LoadFolder(folderPath string) ([]*shared.Track, error) {
 	log.Printf("LoadFolder: Starting scan of %s", folderPath)
 
-	// Supported audio extensions
-	supportedExts := map[string]bool{
-		".mp3":  true,
-		".aiff": true,
-		".aif":  true,
-		".wav":  true, // For testing
-	}
-
 	var tracks []*shared.Track
 
 	err := filepath.Walk(folderPath, func(path string, info os.FileInfo, err error) error {
@@ -48,12 +50,11 @@ func (l *Loader) LoadFolder(folderPath string) ([]*shared.Track, error) {
 			return nil
 		}
 
-		ext := strings.ToLower(filepath.Ext(path))
-		if supportedExts[ext] {
-			track := &shared.Track{
-				Filename: filename,
-				Path:     path,
-			}
+		track := &shared.Track{
+			Filename: filename,
+			Path:     path,
+		}
+		if l.probe(track) {
 			tracks = append(tracks, track)
 			if len(tracks) <= 3 { // Log first few tracks for debugging
 				log.Printf("LoadFolder: Found track %d: %s", len(tracks), track.Filename)
@@ -72,11 +73,149 @@ func (l *Loader) LoadFolder(folderPath string) ([]*shared.Track, error) {
 	return tracks, nil
 }
 
-// LoadPlaylist loads audio tracks from a playlist file
-func (l *Loader) LoadPlaylist(playlistPath string) ([]*shared.Track, error) {
-	// TODO: Implement playlist file parsing (M3U, PLS, etc.)
-	// For now, just return an error
-	return nil, fmt.Errorf("playlist loading not yet implemented")
+// LoadPlaylist loads audio tracks from a playlist file.
+// Entries that are missing or unsupported are skipped and returned so the
+// caller can report them, rather than failing the whole load.
+func (l *Loader) LoadPlaylist(playlistPath string) ([]*shared.Track, []shared.SkippedEntry, error) {
+	log.Printf("LoadPlaylist: Reading %s", playlistPath)
+
+	file, err := os.Open(playlistPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not open playlist: %v", err)
+	}
+	defer file.Close()
+
+	entries, err := l.playlistFormats.Read(playlistPath, file)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	baseDir := filepath.Dir(playlistPath)
+
+	var tracks []*shared.Track
+	var skipped []shared.SkippedEntry
+
+	// Readers return paths as written; resolve them against the playlist's directory
+	for _, entry := range entries {
+		path, err := l.resolveEntryPath(entry.Path, baseDir)
+		if err != nil {
+			skipped = append(skipped, shared.SkippedEntry{Location: entry.Path, Reason: err.Error()})
+			continue
+		}
+
+		location := entry.Path
+		entry.Filename = filepath.Base(path)
+		entry.Path = path
+		if !l.probe(entry) {
+			skipped = append(skipped, shared.SkippedEntry{Location: location, Reason: "unsupported format"})
+			continue
+		}
+		tracks = append(tracks, entry)
+	}
+
+	log.Printf("LoadPlaylist: Found %d tracks, skipped %d entries", len(tracks), len(skipped))
+	return tracks, skipped, nil
+}
+
+// LoadPath loads tracks from a folder, a playlist file or a single audio file
+func (l *Loader) LoadPath(path string) ([]*shared.Track, []shared.SkippedEntry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("path does not exist: %s", path)
+	}
+
+	if info.IsDir() {
+		tracks, err := l.LoadFolder(path)
+		return tracks, nil, err
+	}
+
+	if l.playlistFormats.FormatForExtension(path) != "" {
+		return l.LoadPlaylist(path)
+	}
+
+	track := &shared.Track{Filename: filepath.Base(path), Path: path}
+	if !l.probe(track) {
+		return nil, nil, fmt.Errorf("unsupported file: %s", filepath.Base(path))
+	}
+	return []*shared.Track{track}, nil, nil
+}
+
+// IsSupported reports whether the player can decode the file. The format is
+// sniffed from the file's content, falling back to its extension, the same
+// way the player picks a decoder.
+func (l *Loader) IsSupported(path string) bool {
+	return l.audioFormats.Probe(path) != ""
+}
+
+// probe reports whether the player can decode the track's file and fills in
+// its artist, title and other tags, opening the file once. A title from the
+// tags replaces one from the playlist; tags that can't be read are ignored,
+// as the track still plays.
+func (l *Loader) probe(track *shared.Track) bool {
+	format, tags, err := l.audioFormats.ProbeTags(track.Path)
+	if format == "" {
+		return false
+	}
+	if err != nil || len(tags) == 0 {
+		return true
+	}
+
+	if title := tags.Get("TITLE"); title != "" {
+		track.Title = title
+	}
+	track.Artist = strings.Join(tags["ARTIST"], ", ")
+	track.Album = tags.Get("ALBUM")
+	track.Genre = strings.Join(tags["GENRE"], ", ")
+	track.BPM = tags.Get("BPM")
+	track.Key = tags.Get("KEY")
+	track.Label = tags.Get("LABEL")
+	track.Comment = tags.Get("COMMENT")
+	track.Rating, _ = strconv.Atoi(tags.Get("RATING"))
+	return true
+}
+
+// resolveEntryPath turns a playlist entry into an absolute path to an existing file
+func (l *Loader) resolveEntryPath(location, baseDir string) (string, error) {
+	if strings.HasPrefix(location, "file://") {
+		parsed, err := url.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("invalid file URL")
+		}
+		location = parsed.Path
+	} else if strings.Contains(location, "://") {
+		return "", fmt.Errorf("remote streams are not supported")
+	}
+
+	path := location
+	if strings.HasPrefix(path, "~/") {
+		expanded, err := l.ExpandPath(path)
+		if err != nil {
+			return "", err
+		}
+		path = expanded
+	} else if !filepath.IsAbs(path) {
+		path = filepath.Join(baseDir, path)
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("entry is a directory")
+		}
+		return path, nil
+	}
+
+	// Playlists written on Windows use backslash separators
+	if filepath.Separator != '\\' && strings.Contains(location, "\\") {
+		converted := strings.ReplaceAll(location, "\\", "/")
+		if !filepath.IsAbs(converted) {
+			converted = filepath.Join(baseDir, converted)
+		}
+		if info, err := os.Stat(converted); err == nil && !info.IsDir() {
+			return converted, nil
+		}
+	}
+
+	return "", fmt.Errorf("file not found")
 }
 
 // ExpandPath expands a file path (e.g., ~ to home directory)
@@ -97,4 +236,4 @@ func (l *Loader) ExpandPath(path string) (string, error) {
 	}
 
 	return absPath, nil
-}
\ No newline at end of file
+}
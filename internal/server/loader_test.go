@@ -0,0 +1,186 @@
+package server
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoader_LoadPlaylist_M3U(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	musicDir := filepath.Join(tmpDir, "music")
+	if err := os.MkdirAll(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"one.mp3", "two.wav", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(musicDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	playlistPath := filepath.Join(tmpDir, "set.m3u8")
+	content := "#EXTM3U\n" +
+		"#EXTINF:125,Opener\n" +
+		"music/one.mp3\n" +
+		"music/missing.mp3\n" +
+		"music/notes.txt\n" +
+		filepath.Join(musicDir, "two.wav") + "\n" +
+		"http://example.com/stream.mp3\n"
+	if err := os.WriteFile(playlistPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader()
+	tracks, skipped, err := loader.LoadPlaylist(playlistPath)
+	if err != nil {
+		t.Fatalf("LoadPlaylist() failed: %v", err)
+	}
+
+	if len(tracks) != 2 {
+		t.Fatalf("Expected 2 tracks, got %d", len(tracks))
+	}
+
+	// Relative entries resolve against the playlist's directory
+	if tracks[0].Path != filepath.Join(musicDir, "one.mp3") {
+		t.Errorf("Track 0 path = %s, want %s", tracks[0].Path, filepath.Join(musicDir, "one.mp3"))
+	}
+	if tracks[0].Title != "Opener" {
+		t.Errorf("Track 0 title = %q, want %q", tracks[0].Title, "Opener")
+	}
+	if tracks[0].Duration != "2:05" {
+		t.Errorf("Track 0 duration = %q, want %q", tracks[0].Duration, "2:05")
+	}
+
+	if len(skipped) != 3 {
+		t.Fatalf("Expected 3 skipped entries, got %d: %+v", len(skipped), skipped)
+	}
+
+	expectedReasons := map[string]string{
+		"music/missing.mp3":             "file not found",
+		"music/notes.txt":               "unsupported format",
+		"http://example.com/stream.mp3": "remote streams are not supported",
+	}
+	for _, entry := range skipped {
+		if want := expectedReasons[entry.Location]; entry.Reason != want {
+			t.Errorf("Skipped %s reason = %q, want %q", entry.Location, entry.Reason, want)
+		}
+	}
+}
+
+func TestLoader_LoadPlaylist_MissingFile(t *testing.T) {
+	loader := NewLoader()
+
+	_, _, err := loader.LoadPlaylist("/path/that/does/not/exist.m3u")
+	if err == nil {
+		t.Error("LoadPlaylist() should fail for a missing playlist file")
+	}
+}
+
+func TestLoader_LoadFolder_SniffsContent(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	files := map[string]string{
+		"untitled":   "RIFF\x24\x00\x00\x00WAVEfmt ", // WAV without an extension
+		"promo.txt":  "fLaC\x00\x00\x00\x22",         // Mislabeled FLAC
+		"notes.txt":  "just some notes",
+		"cover.jpg":  "\xFF\xD8\xFF\xE0",
+		"track.aiff": "", // Unreadable content falls back to the extension
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tracks, err := NewLoader().LoadFolder(tmpDir)
+	if err != nil {
+		t.Fatalf("LoadFolder() failed: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, track := range tracks {
+		found[track.Filename] = true
+	}
+
+	for _, name := range []string{"untitled", "promo.txt", "track.aiff"} {
+		if !found[name] {
+			t.Errorf("Expected %s to be loaded", name)
+		}
+	}
+	if len(tracks) != 3 {
+		t.Errorf("Expected 3 tracks, got %d", len(tracks))
+	}
+}
+
+func TestLoader_LoadPath(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"one.mp3", "two.flac", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	playlistPath := filepath.Join(tmpDir, "set.m3u")
+	if err := os.WriteFile(playlistPath, []byte("one.mp3\nmissing.mp3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader()
+
+	tracks, _, err := loader.LoadPath(tmpDir)
+	if err != nil || len(tracks) != 2 {
+		t.Errorf("LoadPath(folder) = %d tracks, %v, want 2 tracks", len(tracks), err)
+	}
+
+	tracks, skipped, err := loader.LoadPath(playlistPath)
+	if err != nil || len(tracks) != 1 || len(skipped) != 1 {
+		t.Errorf("LoadPath(playlist) = %d tracks, %d skipped, %v, want 1 and 1", len(tracks), len(skipped), err)
+	}
+
+	tracks, _, err = loader.LoadPath(filepath.Join(tmpDir, "two.flac"))
+	if err != nil || len(tracks) != 1 || tracks[0].Filename != "two.flac" {
+		t.Errorf("LoadPath(file) = %v, %v, want two.flac", tracks, err)
+	}
+
+	if _, _, err := loader.LoadPath(filepath.Join(tmpDir, "notes.txt")); err == nil {
+		t.Error("LoadPath() should reject unsupported files")
+	}
+	if _, _, err := loader.LoadPath(filepath.Join(tmpDir, "missing")); err == nil {
+		t.Error("LoadPath() should fail for a missing path")
+	}
+}
+
+func TestLoader_ReadsTags(t *testing.T) {
+	// A minimal ID3v2.3 tag with title, artist and BPM frames
+	frame := func(id, text string) string {
+		size := len(text) + 1
+		return id + string([]byte{0, 0, byte(size >> 8), byte(size), 0, 0, 0}) + text
+	}
+	body := frame("TIT2", "Strings of Life") + frame("TPE1", "Rhythim Is Rhythim") + frame("TBPM", "124")
+	tag := "ID3\x03\x00\x00" + string([]byte{0, 0, byte(len(body) >> 7), byte(len(body) & 0x7F)}) + body
+
+	tmpDir := t.TempDir()
+	path := filepath.Join(tmpDir, "01_final_master_v3.mp3")
+	if err := os.WriteFile(path, []byte(tag+"\xFF\xFB\x90\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	playlistPath := filepath.Join(tmpDir, "set.m3u")
+	if err := os.WriteFile(playlistPath, []byte("#EXTM3U\n#EXTINF:-1,Old name\n01_final_master_v3.mp3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	loader := NewLoader()
+	for _, source := range []string{tmpDir, playlistPath, path} {
+		tracks, _, err := loader.LoadPath(source)
+		if err != nil || len(tracks) != 1 {
+			t.Fatalf("LoadPath(%s) = %v, %v, want one track", source, tracks, err)
+		}
+		track := tracks[0]
+		if track.Title != "Strings of Life" || track.Artist != "Rhythim Is Rhythim" || track.BPM != "124" {
+			t.Errorf("LoadPath(%s) track = %+v, want its tags", source, track)
+		}
+		if got, want := track.DisplayName(), "Rhythim Is Rhythim - Strings of Life"; got != want {
+			t.Errorf("DisplayName() = %q, want %q", got, want)
+		}
+	}
+}
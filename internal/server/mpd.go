@@ -0,0 +1,415 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// DefaultMPDAddr is the address MPD clients try first, on this machine only
+const DefaultMPDAddr = "127.0.0.1:6600"
+
+// mpdProtocolVersion is the MPD protocol version announced to clients
+const mpdProtocolVersion = "0.23.0"
+
+// MPD error codes, sent back in ACK lines
+const (
+	mpdErrArg        = 2
+	mpdErrPassword   = 3
+	mpdErrPermission = 4
+	mpdErrUnknown    = 5
+	mpdErrNoExist    = 50
+	mpdErrSystem     = 52
+)
+
+// mpdSubsystems are the idle subsystems auxbox reports changes in
+var mpdSubsystems = []string{"player", "mixer", "options", "playlist"}
+
+// mpdError is a command failure, reported to the client as an ACK line
+type mpdError struct {
+	code    int
+	message string
+}
+
+func (e *mpdError) Error() string {
+	return e.message
+}
+
+// mpdArgError reports a malformed argument
+func mpdArgError(format string, args ...interface{}) error {
+	return &mpdError{code: mpdErrArg, message: fmt.Sprintf(format, args...)}
+}
+
+// SetMPD enables the MPD protocol server on addr, e.g. "127.0.0.1:6600".
+// If password is set, clients must send it before anything else. It starts
+// with the server.
+func (s *Server) SetMPD(addr, password string) error {
+	if addr == "" {
+		addr = DefaultMPDAddr
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return fmt.Errorf("invalid MPD address %q: %w", addr, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mpdAddr = addr
+	s.mpdPassword = password
+	return nil
+}
+
+// startMPD starts the MPD server if it is enabled. Like the HTTP API, a
+// failure is logged rather than stopping the daemon.
+func (s *Server) startMPD() {
+	if s.mpdAddr == "" {
+		return
+	}
+
+	listener, err := net.Listen("tcp", s.mpdAddr)
+	if err != nil {
+		log.Printf("MPD server disabled: %v", err)
+		return
+	}
+	s.mpdListener = listener
+	s.mpdClients = make(map[net.Conn]struct{})
+	log.Printf("MPD server listening on %s", listener.Addr())
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					log.Printf("MPD server error: %v", err)
+				}
+				return
+			}
+			go s.serveMPDClient(conn)
+		}
+	}()
+}
+
+// stopMPD closes the MPD listener and every client connection
+func (s *Server) stopMPD() {
+	if s.mpdListener == nil {
+		return
+	}
+
+	if err := s.mpdListener.Close(); err != nil {
+		log.Printf("Error stopping MPD server: %v", err)
+	}
+	s.mpdListener = nil
+
+	s.mpdMu.Lock()
+	for conn := range s.mpdClients {
+		conn.Close()
+	}
+	s.mpdMu.Unlock()
+}
+
+// mpdSession is one MPD client connection
+type mpdSession struct {
+	server     *Server
+	w          *bufio.Writer
+	authorized bool
+	changed    map[string]bool // Subsystems changed since the last idle
+	list       []string        // Commands collected in a command list
+	inList     bool
+	listOK     bool // Send list_OK after each command in the list
+}
+
+// serveMPDClient speaks the MPD protocol on conn until the client hangs up
+func (s *Server) serveMPDClient(conn net.Conn) {
+	s.mpdMu.Lock()
+	if s.mpdClients != nil {
+		s.mpdClients[conn] = struct{}{}
+	}
+	s.mpdMu.Unlock()
+	defer func() {
+		s.mpdMu.Lock()
+		delete(s.mpdClients, conn)
+		s.mpdMu.Unlock()
+		conn.Close()
+	}()
+
+	// Watch for changes from the start, so idle reports anything since
+	events, cancel := s.events.Subscribe()
+	defer cancel()
+
+	session := &mpdSession{
+		server:     s,
+		w:          bufio.NewWriter(conn),
+		authorized: s.mpdPassword == "",
+		changed:    make(map[string]bool),
+	}
+
+	// Read lines on their own goroutine, so idle can wait on events and
+	// the client at the same time
+	lines := make(chan string)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	fmt.Fprintf(session.w, "OK MPD %s\n", mpdProtocolVersion)
+	if session.w.Flush() != nil {
+		return
+	}
+
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok || !session.handleLine(line, lines, events) {
+				return
+			}
+			if session.w.Flush() != nil {
+				return
+			}
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			session.noteEvent(event)
+		}
+	}
+}
+
+// handleLine runs one line from the client. It returns false when the
+// connection should close.
+func (c *mpdSession) handleLine(line string, lines <-chan string, events <-chan shared.Event) bool {
+	if c.inList {
+		if strings.TrimSpace(line) != "command_list_end" {
+			c.list = append(c.list, line)
+			return true
+		}
+		c.runList()
+		return true
+	}
+
+	args, err := parseMPDLine(line)
+	if err != nil {
+		c.ack(0, "", mpdArgError("%v", err))
+		return true
+	}
+	if len(args) == 0 {
+		c.ack(0, "", &mpdError{code: mpdErrUnknown, message: "No command given"})
+		return true
+	}
+
+	switch args[0] {
+	case "close":
+		return false
+	case "command_list_begin", "command_list_ok_begin":
+		c.inList = true
+		c.listOK = args[0] == "command_list_ok_begin"
+		c.list = nil
+		return true
+	case "idle":
+		if !c.authorized {
+			c.ack(0, args[0], c.permissionError(args[0]))
+			return true
+		}
+		return c.idle(args[1:], lines, events)
+	case "noidle":
+		// Only meaningful while idle; a stray one is ignored
+		return true
+	}
+
+	if err := c.execute(args); err != nil {
+		c.ack(0, args[0], err)
+		return true
+	}
+	c.w.WriteString("OK\n")
+	return true
+}
+
+// runList runs the commands collected between command_list_begin and
+// command_list_end, stopping at the first failure
+func (c *mpdSession) runList() {
+	list := c.list
+	c.inList, c.list = false, nil
+
+	for i, line := range list {
+		args, err := parseMPDLine(line)
+		if err != nil {
+			c.ack(i, "", mpdArgError("%v", err))
+			return
+		}
+		if len(args) == 0 {
+			c.ack(i, "", &mpdError{code: mpdErrUnknown, message: "No command given"})
+			return
+		}
+		if slices.Contains([]string{"idle", "noidle", "close", "command_list_begin", "command_list_ok_begin"}, args[0]) {
+			c.ack(i, args[0], mpdArgError("%s is not allowed in a command list", args[0]))
+			return
+		}
+		if err := c.execute(args); err != nil {
+			c.ack(i, args[0], err)
+			return
+		}
+		if c.listOK {
+			c.w.WriteString("list_OK\n")
+		}
+	}
+	c.w.WriteString("OK\n")
+}
+
+// execute runs a single command, writing its response lines but not the
+// final OK
+func (c *mpdSession) execute(args []string) error {
+	name := args[0]
+	command, ok := mpdCommands[name]
+	if !ok {
+		return &mpdError{code: mpdErrUnknown, message: fmt.Sprintf("unknown command %q", name)}
+	}
+	if !c.authorized && !command.public {
+		return c.permissionError(name)
+	}
+
+	args = args[1:]
+	if len(args) < command.minArgs || (command.maxArgs >= 0 && len(args) > command.maxArgs) {
+		return mpdArgError("wrong number of arguments for %q", name)
+	}
+	return command.run(c, args)
+}
+
+// idle waits until something changes in one of the given subsystems, or in
+// any of them if none are given, or until the client sends noidle
+func (c *mpdSession) idle(subsystems []string, lines <-chan string, events <-chan shared.Event) bool {
+	for _, name := range subsystems {
+		if !slices.Contains(mpdSubsystems, name) && !slices.Contains(mpdOtherSubsystems, name) {
+			c.ack(0, "idle", mpdArgError("Unrecognized idle event: %s", name))
+			return true
+		}
+	}
+	if len(subsystems) == 0 {
+		subsystems = mpdSubsystems
+	}
+
+	for {
+		if changed := c.takeChanged(subsystems); len(changed) > 0 {
+			for _, name := range changed {
+				fmt.Fprintf(c.w, "changed: %s\n", name)
+			}
+			c.w.WriteString("OK\n")
+			return true
+		}
+		if c.w.Flush() != nil {
+			return false
+		}
+
+		select {
+		case event, ok := <-events:
+			if !ok {
+				return false
+			}
+			c.noteEvent(event)
+		case line, ok := <-lines:
+			if !ok {
+				return false
+			}
+			if strings.TrimSpace(line) != "noidle" {
+				// Only noidle is allowed while idle; MPD hangs up too
+				return false
+			}
+			c.w.WriteString("OK\n")
+			return true
+		}
+	}
+}
+
+// mpdOtherSubsystems are idle subsystems clients may wait on that never
+// change in auxbox
+var mpdOtherSubsystems = []string{
+	"database", "update", "stored_playlist", "output", "sticker",
+	"subscription", "message", "partition", "neighbor", "mount",
+}
+
+// noteEvent records which subsystem an event changed
+func (c *mpdSession) noteEvent(event shared.Event) {
+	switch event.Type {
+	case shared.EventTrack, shared.EventPlay, shared.EventPause, shared.EventStop,
+		shared.EventSeek, shared.EventEnd, shared.EventError:
+		c.changed["player"] = true
+	case shared.EventVolume:
+		c.changed["mixer"] = true
+	case shared.EventShuffle, shared.EventRepeat:
+		c.changed["options"] = true
+	case shared.EventQueue:
+		c.changed["playlist"] = true
+	}
+}
+
+// takeChanged returns and forgets the changed subsystems among the given ones
+func (c *mpdSession) takeChanged(subsystems []string) []string {
+	var changed []string
+	for _, name := range subsystems {
+		if c.changed[name] {
+			changed = append(changed, name)
+			delete(c.changed, name)
+		}
+	}
+	return changed
+}
+
+// ack writes an error response for the command at index in a command list
+// (0 outside lists)
+func (c *mpdSession) ack(index int, command string, err error) {
+	code := mpdErrSystem
+	var mpdErr *mpdError
+	if errors.As(err, &mpdErr) {
+		code = mpdErr.code
+	}
+	fmt.Fprintf(c.w, "ACK [%d@%d] {%s} %s\n", code, index, command, err)
+}
+
+// permissionError is returned for commands sent before the password
+func (c *mpdSession) permissionError(command string) error {
+	return &mpdError{code: mpdErrPermission, message: fmt.Sprintf("you don't have permission for %q", command)}
+}
+
+// parseMPDLine splits a command line into arguments. Arguments containing
+// spaces are double-quoted, with backslash escapes inside the quotes.
+func parseMPDLine(line string) ([]string, error) {
+	var args []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t', '\r':
+			i++
+		case '"':
+			var arg strings.Builder
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				arg.WriteByte(line[i])
+			}
+			if i >= len(line) {
+				return nil, fmt.Errorf("missing closing '\"'")
+			}
+			i++
+			args = append(args, arg.String())
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' && line[i] != '\r' {
+				i++
+			}
+			args = append(args, line[start:i])
+		}
+	}
+	return args, nil
+}
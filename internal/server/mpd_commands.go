@@ -0,0 +1,750 @@
+package server
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/audio/decoders"
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// The MPD commands auxbox understands, each translated into the same
+// commands the socket takes. Song positions are 0-based queue indices and
+// song IDs are the playlist's queue entry IDs, which stay with a song when
+// the queue is edited around it.
+
+// mpdCommand describes how to run one MPD command
+type mpdCommand struct {
+	minArgs int
+	maxArgs int  // -1 for no limit
+	public  bool // Allowed before the password is given
+	run     func(c *mpdSession, args []string) error
+}
+
+var mpdCommands map[string]mpdCommand
+
+func init() {
+	mpdCommands = map[string]mpdCommand{
+		"ping":        {0, 0, true, func(c *mpdSession, args []string) error { return nil }},
+		"password":    {1, 1, true, (*mpdSession).password},
+		"commands":    {0, 0, true, (*mpdSession).commands},
+		"notcommands": {0, 0, true, func(c *mpdSession, args []string) error { return nil }},
+		"tagtypes":    {0, -1, false, (*mpdSession).tagTypes},
+		"outputs":     {0, 0, false, (*mpdSession).outputs},
+
+		"status":      {0, 0, false, (*mpdSession).status},
+		"currentsong": {0, 0, false, (*mpdSession).currentSong},
+
+		"play":     {0, 1, false, (*mpdSession).play},
+		"playid":   {0, 1, false, (*mpdSession).playID},
+		"pause":    {0, 1, false, (*mpdSession).pause},
+		"stop":     {0, 0, false, func(c *mpdSession, args []string) error { return c.run(shared.NewStopCommand()) }},
+		"next":     {0, 0, false, func(c *mpdSession, args []string) error { return c.run(shared.NewSkipCommand(1)) }},
+		"previous": {0, 0, false, func(c *mpdSession, args []string) error { return c.run(shared.NewBackCommand(1)) }},
+		"seekcur":  {1, 1, false, (*mpdSession).seekCur},
+		"seek":     {2, 2, false, (*mpdSession).seek},
+		"seekid":   {2, 2, false, (*mpdSession).seekID},
+
+		"setvol": {1, 1, false, (*mpdSession).setVol},
+		"volume": {1, 1, false, (*mpdSession).volume},
+		"random": {1, 1, false, (*mpdSession).random},
+		"repeat": {1, 1, false, (*mpdSession).repeat},
+		"single": {1, 1, false, (*mpdSession).single},
+		"consume": {1, 1, false, func(c *mpdSession, args []string) error {
+			if args[0] != "0" {
+				return mpdArgError("consume mode is not supported")
+			}
+			return nil
+		}},
+
+		"playlistinfo":   {0, 1, false, (*mpdSession).playlistInfo},
+		"playlistid":     {0, 1, false, (*mpdSession).playlistID},
+		"plchanges":      {1, 2, false, (*mpdSession).plChanges},
+		"plchangesposid": {1, 2, false, (*mpdSession).plChangesPosID},
+		"add":            {1, 1, false, (*mpdSession).add},
+		"addid":          {1, 2, false, (*mpdSession).addID},
+		"delete":         {1, 1, false, (*mpdSession).delete},
+		"deleteid":       {1, 1, false, (*mpdSession).deleteID},
+		"move":           {2, 2, false, (*mpdSession).move},
+		"moveid":         {2, 2, false, (*mpdSession).moveID},
+		"clear":          {0, 0, false, (*mpdSession).clear},
+	}
+}
+
+// mpdTagTypes are the tags currentsong reports, mapped to the metadata
+// fields they come from
+var mpdTagTypes = []struct{ name, field string }{
+	{"Artist", "ARTIST"},
+	{"Album", "ALBUM"},
+	{"AlbumArtist", "ALBUMARTIST"},
+	{"Title", "TITLE"},
+	{"Track", "TRACKNUMBER"},
+	{"Genre", "GENRE"},
+	{"Date", "DATE"},
+	{"Label", "LABEL"},
+	{"Comment", "COMMENT"},
+}
+
+// run handles a command as if it came over the socket
+func (c *mpdSession) run(cmd shared.Command) error {
+	resp := c.server.handleCommand(cmd)
+	if !resp.Success {
+		return &mpdError{code: mpdErrSystem, message: resp.Message}
+	}
+	return nil
+}
+
+// lineBreaks turns line breaks in values into spaces, as MPD does, so a
+// multi-line tag can't add lines to a response
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// pair writes one "key: value" response line
+func (c *mpdSession) pair(key string, value interface{}) {
+	fmt.Fprintf(c.w, "%s: %s\n", key, lineBreaks.Replace(fmt.Sprint(value)))
+}
+
+func (c *mpdSession) password(args []string) error {
+	if args[0] != c.server.mpdPassword {
+		return &mpdError{code: mpdErrPassword, message: "incorrect password"}
+	}
+	c.authorized = true
+	return nil
+}
+
+func (c *mpdSession) commands(args []string) error {
+	names := []string{"close", "command_list_begin", "command_list_ok_begin", "command_list_end", "idle", "noidle"}
+	for name, command := range mpdCommands {
+		if c.authorized || command.public {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		c.pair("command", name)
+	}
+	return nil
+}
+
+// tagTypes lists the supported tags. Clients may also ask to turn tags on
+// or off; auxbox always sends what it has, so those requests are accepted
+// and ignored.
+func (c *mpdSession) tagTypes(args []string) error {
+	if len(args) > 0 {
+		return nil
+	}
+	for _, tag := range mpdTagTypes {
+		c.pair("tagtype", tag.name)
+	}
+	return nil
+}
+
+func (c *mpdSession) outputs(args []string) error {
+	c.pair("outputid", 0)
+	c.pair("outputname", "auxbox")
+	c.pair("plugin", "beep")
+	c.pair("outputenabled", 1)
+	return nil
+}
+
+func (c *mpdSession) status(args []string) error {
+	player, list := c.server.player, c.server.playlist
+	mode := list.GetRepeatMode()
+
+	c.pair("volume", int(math.Round(player.GetStatus().Volume*100)))
+	c.pair("repeat", mpdBool(mode != playlist.RepeatOff))
+	c.pair("random", mpdBool(list.IsShuffled()))
+	c.pair("single", mpdBool(mode == playlist.RepeatOne))
+	c.pair("consume", 0)
+	c.pair("playlist", c.server.playlistVersion())
+	c.pair("playlistlength", list.TrackCount())
+
+	state := "stop"
+	switch {
+	case player.IsPlaying():
+		state = "play"
+	case player.IsPaused():
+		state = "pause"
+	}
+	c.pair("state", state)
+
+	track := list.GetCurrentTrack()
+	if track == nil {
+		return nil
+	}
+	c.pair("song", list.GetCurrentIndex())
+	c.pair("songid", track.ID)
+
+	if next := list.PeekNextIndex(); next >= 0 {
+		if tracks := list.GetTrackList(); next < len(tracks) {
+			c.pair("nextsong", next)
+			c.pair("nextsongid", tracks[next].ID)
+		}
+	}
+
+	if state != "stop" {
+		elapsed, duration := player.GetPosition(), player.GetDuration()
+		c.pair("time", fmt.Sprintf("%d:%d", int(elapsed.Seconds()), int(duration.Seconds())))
+		c.pair("elapsed", fmt.Sprintf("%.3f", elapsed.Seconds()))
+		c.pair("duration", fmt.Sprintf("%.3f", duration.Seconds()))
+	}
+	if crossfade, _ := player.GetCrossfade(); crossfade > 0 {
+		c.pair("xfade", int(math.Round(crossfade.Seconds())))
+	}
+	return nil
+}
+
+func (c *mpdSession) currentSong(args []string) error {
+	list := c.server.playlist
+	track := list.GetCurrentTrack()
+	if track == nil {
+		return nil
+	}
+	c.song(list.GetCurrentIndex(), track, true)
+	return nil
+}
+
+// song writes the description of a queue entry. Every track has the tags
+// read when it was loaded; the current track has been opened, so it has all
+// of its tags and a duration.
+func (c *mpdSession) song(idx int, track *shared.Track, current bool) {
+	c.pair("file", track.Path)
+
+	tags := trackTags(track)
+	if current {
+		if opened := c.server.player.GetMetadata(); len(opened) > 0 {
+			tags = opened
+		}
+	}
+
+	title := track.Title
+	for _, tag := range mpdTagTypes {
+		if tag.field == "TITLE" {
+			if title == "" {
+				title = tags.Get(tag.field)
+			}
+			continue
+		}
+		for _, value := range tags[tag.field] {
+			c.pair(tag.name, value)
+		}
+	}
+	if title != "" {
+		c.pair("Title", title)
+	}
+
+	if current {
+		if duration := c.server.player.GetDuration(); duration > 0 {
+			c.pair("Time", int(math.Round(duration.Seconds())))
+			c.pair("duration", fmt.Sprintf("%.3f", duration.Seconds()))
+		}
+	}
+	c.pair("Pos", idx)
+	c.pair("Id", track.ID)
+}
+
+// trackTags returns the tags a queue entry was loaded with
+func trackTags(track *shared.Track) decoders.Metadata {
+	tags := make(decoders.Metadata)
+	for field, value := range map[string]string{
+		"ARTIST":  track.Artist,
+		"ALBUM":   track.Album,
+		"GENRE":   track.Genre,
+		"LABEL":   track.Label,
+		"COMMENT": track.Comment,
+	} {
+		if value != "" {
+			tags.Add(field, value)
+		}
+	}
+	return tags
+}
+
+func (c *mpdSession) play(args []string) error {
+	if len(args) == 0 {
+		return c.resume()
+	}
+	pos, err := c.songPos(args[0])
+	if err != nil {
+		return err
+	}
+	return c.playAt(pos)
+}
+
+func (c *mpdSession) playID(args []string) error {
+	if len(args) == 0 {
+		return c.resume()
+	}
+	pos, err := c.songID(args[0])
+	if err != nil {
+		return err
+	}
+	return c.playAt(pos)
+}
+
+// resume starts or resumes playback, and does nothing if it is playing
+func (c *mpdSession) resume() error {
+	if c.server.player.IsPlaying() {
+		return nil
+	}
+	return c.run(shared.NewPlayCommand())
+}
+
+// playAt jumps to the song at pos and plays it
+func (c *mpdSession) playAt(pos int) error {
+	if err := c.run(shared.NewJumpCommand(strconv.Itoa(pos + 1))); err != nil {
+		return err
+	}
+	return c.resume()
+}
+
+// pause toggles pause, or sets it with 1 or 0
+func (c *mpdSession) pause(args []string) error {
+	pause := c.server.player.IsPlaying()
+	if len(args) > 0 {
+		value, err := parseMPDBool(args[0])
+		if err != nil {
+			return err
+		}
+		pause = value
+	}
+
+	switch {
+	case pause && c.server.player.IsPlaying():
+		return c.run(shared.NewPauseCommand())
+	case !pause && c.server.player.IsPaused():
+		return c.run(shared.NewPlayCommand())
+	}
+	return nil
+}
+
+// seekCur seeks within the current song, relatively if the time starts
+// with + or -
+func (c *mpdSession) seekCur(args []string) error {
+	target, err := mpdSeekTarget(args[0])
+	if err != nil {
+		return err
+	}
+	return c.run(shared.NewSeekCommand(target))
+}
+
+func (c *mpdSession) seek(args []string) error {
+	pos, err := c.songPos(args[0])
+	if err != nil {
+		return err
+	}
+	return c.seekSong(pos, args[1])
+}
+
+func (c *mpdSession) seekID(args []string) error {
+	pos, err := c.songID(args[0])
+	if err != nil {
+		return err
+	}
+	return c.seekSong(pos, args[1])
+}
+
+// seekSong seeks to an absolute time in the song at pos, jumping to it first
+// if it isn't current
+func (c *mpdSession) seekSong(pos int, timeArg string) error {
+	if strings.HasPrefix(timeArg, "+") || strings.HasPrefix(timeArg, "-") {
+		return mpdArgError("Bad time: %s", timeArg)
+	}
+	target, err := mpdSeekTarget(timeArg)
+	if err != nil {
+		return err
+	}
+
+	if pos != c.server.playlist.GetCurrentIndex() {
+		if err := c.run(shared.NewJumpCommand(strconv.Itoa(pos + 1))); err != nil {
+			return err
+		}
+	}
+	return c.run(shared.NewSeekCommand(target))
+}
+
+func (c *mpdSession) setVol(args []string) error {
+	volume, err := strconv.Atoi(args[0])
+	if err != nil || volume < 0 || volume > 100 {
+		return mpdArgError("Invalid volume value: %s", args[0])
+	}
+	return c.run(shared.NewVolumeCommand(volume))
+}
+
+// volume changes the volume by a relative amount
+func (c *mpdSession) volume(args []string) error {
+	change, err := strconv.Atoi(args[0])
+	if err != nil {
+		return mpdArgError("Integer expected: %s", args[0])
+	}
+	current := int(math.Round(c.server.player.GetStatus().Volume * 100))
+	return c.run(shared.NewVolumeCommand(max(0, min(current+change, 100))))
+}
+
+func (c *mpdSession) random(args []string) error {
+	random, err := parseMPDBool(args[0])
+	if err != nil {
+		return err
+	}
+	if random == c.server.playlist.IsShuffled() {
+		return nil
+	}
+	return c.run(shared.Command{Type: shared.CmdShuffle})
+}
+
+// repeat maps MPD's repeat flag onto the repeat modes: turning it on
+// repeats the queue unless a single track already repeats
+func (c *mpdSession) repeat(args []string) error {
+	repeat, err := parseMPDBool(args[0])
+	if err != nil {
+		return err
+	}
+
+	mode := c.server.playlist.GetRepeatMode()
+	switch {
+	case !repeat:
+		mode = playlist.RepeatOff
+	case mode == playlist.RepeatOff:
+		mode = playlist.RepeatAll
+	}
+	return c.setRepeatMode(mode)
+}
+
+// single maps MPD's single flag onto repeat-one. auxbox has no mode that
+// stops after the current track, so single always repeats it.
+func (c *mpdSession) single(args []string) error {
+	value := args[0]
+	if value == "oneshot" {
+		value = "1"
+	}
+	single, err := parseMPDBool(value)
+	if err != nil {
+		return err
+	}
+
+	mode := c.server.playlist.GetRepeatMode()
+	switch {
+	case single:
+		mode = playlist.RepeatOne
+	case mode == playlist.RepeatOne:
+		mode = playlist.RepeatAll
+	}
+	return c.setRepeatMode(mode)
+}
+
+func (c *mpdSession) setRepeatMode(mode playlist.RepeatMode) error {
+	resp := c.server.setRepeatMode(mode)
+	if !resp.Success {
+		return &mpdError{code: mpdErrSystem, message: resp.Message}
+	}
+	return nil
+}
+
+// playlistInfo describes the whole queue, one song or a START:END range
+func (c *mpdSession) playlistInfo(args []string) error {
+	tracks := c.server.playlist.GetTrackList()
+	start, end := 0, len(tracks)
+	if len(args) > 0 {
+		var err error
+		if start, end, err = parseMPDRange(args[0], len(tracks)); err != nil {
+			return err
+		}
+		if !strings.Contains(args[0], ":") && start >= len(tracks) {
+			return &mpdError{code: mpdErrArg, message: "Bad song index"}
+		}
+	}
+	c.songs(tracks, start, end)
+	return nil
+}
+
+func (c *mpdSession) playlistID(args []string) error {
+	tracks := c.server.playlist.GetTrackList()
+	if len(args) == 0 {
+		c.songs(tracks, 0, len(tracks))
+		return nil
+	}
+	pos, err := c.songID(args[0])
+	if err != nil {
+		return err
+	}
+	c.songs(tracks, pos, pos+1)
+	return nil
+}
+
+// plChanges lists the songs changed since a playlist version. auxbox
+// doesn't track changes per song, so any older version gets the whole
+// queue.
+func (c *mpdSession) plChanges(args []string) error {
+	if c.unchangedSince(args[0]) {
+		return nil
+	}
+	tracks := c.server.playlist.GetTrackList()
+	start, end := 0, len(tracks)
+	if len(args) > 1 {
+		var err error
+		if start, end, err = parseMPDRange(args[1], len(tracks)); err != nil {
+			return err
+		}
+	}
+	c.songs(tracks, start, end)
+	return nil
+}
+
+func (c *mpdSession) plChangesPosID(args []string) error {
+	if c.unchangedSince(args[0]) {
+		return nil
+	}
+	tracks := c.server.playlist.GetTrackList()
+	start, end := 0, len(tracks)
+	if len(args) > 1 {
+		var err error
+		if start, end, err = parseMPDRange(args[1], len(tracks)); err != nil {
+			return err
+		}
+	}
+	for idx := start; idx < end; idx++ {
+		c.pair("cpos", idx)
+		c.pair("Id", tracks[idx].ID)
+	}
+	return nil
+}
+
+// unchangedSince reports whether the queue is still at the given version
+func (c *mpdSession) unchangedSince(version string) bool {
+	v, err := strconv.ParseUint(version, 10, 32)
+	return err == nil && uint32(v) == c.server.playlistVersion()
+}
+
+// songs describes the queue entries from start up to end
+func (c *mpdSession) songs(tracks []*shared.Track, start, end int) {
+	current := -1
+	if c.server.playlist.GetCurrentTrack() != nil {
+		current = c.server.playlist.GetCurrentIndex()
+	}
+	for idx := start; idx < end && idx < len(tracks); idx++ {
+		c.song(idx, tracks[idx], idx == current)
+	}
+}
+
+// add appends a file, folder or playlist to the queue. MPD URIs are
+// relative to a music directory, which auxbox doesn't have, so paths must
+// be absolute (or file:// URLs).
+func (c *mpdSession) add(args []string) error {
+	path, err := mpdPath(args[0])
+	if err != nil {
+		return err
+	}
+	return c.run(shared.NewEnqueueCommand(path))
+}
+
+// addID appends one file, optionally moving it to a position, and returns
+// its ID
+func (c *mpdSession) addID(args []string) error {
+	path, err := mpdPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	before := c.server.playlist.TrackCount()
+	if err := c.run(shared.NewEnqueueCommand(path)); err != nil {
+		return err
+	}
+	tracks := c.server.playlist.GetTrackList()
+	if len(tracks) <= before {
+		return &mpdError{code: mpdErrNoExist, message: "No such song"}
+	}
+	id := tracks[before].ID
+
+	if len(args) > 1 {
+		to, err := strconv.Atoi(args[1])
+		if err != nil || to < 0 || to > before {
+			return &mpdError{code: mpdErrArg, message: "Bad song index"}
+		}
+		if to != before {
+			if err := c.run(shared.NewMoveCommand(before+1, to+1)); err != nil {
+				return err
+			}
+		}
+	}
+	c.pair("Id", id)
+	return nil
+}
+
+// delete removes one song or a START:END range
+func (c *mpdSession) delete(args []string) error {
+	count := c.server.playlist.TrackCount()
+	start, end, err := parseMPDRange(args[0], count)
+	if err != nil {
+		return err
+	}
+	if start >= count || end <= start {
+		return &mpdError{code: mpdErrArg, message: "Bad song index"}
+	}
+	return c.run(shared.NewRemoveCommand(start+1, end))
+}
+
+func (c *mpdSession) deleteID(args []string) error {
+	pos, err := c.songID(args[0])
+	if err != nil {
+		return err
+	}
+	return c.run(shared.NewRemoveCommand(pos+1, pos+1))
+}
+
+// move moves one song or a START:END range so it starts at position to
+func (c *mpdSession) move(args []string) error {
+	count := c.server.playlist.TrackCount()
+	start, end, err := parseMPDRange(args[0], count)
+	if err != nil {
+		return err
+	}
+	to, err := strconv.Atoi(args[1])
+	if err != nil || start >= count || end <= start || to < 0 || to+end-start > count {
+		return &mpdError{code: mpdErrArg, message: "Bad song index"}
+	}
+	return c.moveRange(start, end, to)
+}
+
+func (c *mpdSession) moveID(args []string) error {
+	pos, err := c.songID(args[0])
+	if err != nil {
+		return err
+	}
+	to, err := strconv.Atoi(args[1])
+	if err != nil || to < 0 || to >= c.server.playlist.TrackCount() {
+		return &mpdError{code: mpdErrArg, message: "Bad song index"}
+	}
+	return c.moveRange(pos, pos+1, to)
+}
+
+// moveRange moves the songs from start up to end one at a time, keeping
+// their order, so the first lands at position to
+func (c *mpdSession) moveRange(start, end, to int) error {
+	for i := 0; i < end-start; i++ {
+		from, dest := start+i, to+i
+		if to > start {
+			// Each move shifts the rest of the range down into start
+			from, dest = start, to+end-start-1
+		}
+		if from == dest {
+			continue
+		}
+		if err := c.run(shared.NewMoveCommand(from+1, dest+1)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clear empties the queue, which stops playback
+func (c *mpdSession) clear(args []string) error {
+	count := c.server.playlist.TrackCount()
+	if count == 0 {
+		return nil
+	}
+	return c.run(shared.NewRemoveCommand(1, count))
+}
+
+// songPos parses a 0-based queue position
+func (c *mpdSession) songPos(arg string) (int, error) {
+	pos, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, mpdArgError("Integer expected: %s", arg)
+	}
+	if pos < 0 || pos >= c.server.playlist.TrackCount() {
+		return 0, &mpdError{code: mpdErrArg, message: "Bad song index"}
+	}
+	return pos, nil
+}
+
+// songID parses a song ID and returns its queue position
+func (c *mpdSession) songID(arg string) (int, error) {
+	id, err := strconv.Atoi(arg)
+	if err != nil {
+		return 0, mpdArgError("Integer expected: %s", arg)
+	}
+	pos := c.server.playlist.IndexOfID(id)
+	if pos < 0 {
+		return 0, &mpdError{code: mpdErrNoExist, message: "No such song"}
+	}
+	return pos, nil
+}
+
+// parseMPDRange parses a song position or a START:END range (END
+// exclusive, and optional to mean the end of the queue)
+func parseMPDRange(arg string, count int) (start, end int, err error) {
+	first, last, isRange := strings.Cut(arg, ":")
+	start, err = strconv.Atoi(first)
+	if err != nil || start < 0 {
+		return 0, 0, mpdArgError("Integer expected: %s", arg)
+	}
+	if !isRange {
+		return start, start + 1, nil
+	}
+	if last == "" {
+		return start, max(start, count), nil
+	}
+	end, err = strconv.Atoi(last)
+	if err != nil || end < start {
+		return 0, 0, mpdArgError("Bad range: %s", arg)
+	}
+	return start, min(end, count), nil
+}
+
+// mpdSeekTarget turns MPD seconds, e.g. "90.5" or "+10", into a seek
+// position the seek command understands
+func mpdSeekTarget(arg string) (string, error) {
+	sign := ""
+	value := arg
+	if strings.HasPrefix(arg, "+") || strings.HasPrefix(arg, "-") {
+		sign, value = arg[:1], arg[1:]
+	}
+
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 || math.IsInf(seconds, 0) {
+		return "", mpdArgError("Bad time: %s", arg)
+	}
+	return sign + time.Duration(seconds*float64(time.Second)).String(), nil
+}
+
+// mpdPath turns an add URI into a path the daemon can load
+func mpdPath(uri string) (string, error) {
+	if strings.HasPrefix(uri, "file://") {
+		parsed, err := url.Parse(uri)
+		if err != nil {
+			return "", mpdArgError("Invalid file URL: %s", uri)
+		}
+		uri = parsed.Path
+	} else if strings.Contains(uri, "://") {
+		return "", &mpdError{code: mpdErrNoExist, message: "Remote streams are not supported"}
+	}
+
+	if !strings.HasPrefix(uri, "/") && !strings.HasPrefix(uri, "~/") {
+		return "", &mpdError{code: mpdErrNoExist, message: "auxbox has no music directory; use an absolute path"}
+	}
+	return uri, nil
+}
+
+// parseMPDBool parses MPD's 0 or 1
+func parseMPDBool(arg string) (bool, error) {
+	switch arg {
+	case "0":
+		return false, nil
+	case "1":
+		return true, nil
+	}
+	return false, mpdArgError("Boolean (0/1) expected: %s", arg)
+}
+
+// mpdBool formats a flag the way MPD does
+func mpdBool(value bool) int {
+	if value {
+		return 1
+	}
+	return 0
+}
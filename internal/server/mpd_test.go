@@ -0,0 +1,325 @@
+package server
+
+import (
+	"bufio"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// mpdTestClient talks to one MPD session over an in-memory connection
+type mpdTestClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+// newMPDTestServer returns a server with three tracks loaded
+func newMPDTestServer(password string) *Server {
+	server := NewServer()
+	server.mpdPassword = password
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: "/music/a.mp3"},
+		{Filename: "b.mp3", Path: "/music/b.mp3", Title: "Bee", Artist: "The Hive"},
+		{Filename: "c.mp3", Path: "/music/c.mp3"},
+	}
+	server.LoadTracks(tracks, "/music", shared.SourceFolder)
+	// Settle the initial state, e.g. the default volume, before any idle
+	server.HandleCommand(shared.NewStatusCommand())
+	return server
+}
+
+// newMPDTestClient connects to server and reads the greeting
+func newMPDTestClient(t *testing.T, server *Server) *mpdTestClient {
+	client, conn := net.Pipe()
+	go server.serveMPDClient(conn)
+	t.Cleanup(func() { client.Close() })
+
+	c := &mpdTestClient{t: t, conn: client, reader: bufio.NewReader(client)}
+	if greeting := c.readLine(); !strings.HasPrefix(greeting, "OK MPD ") {
+		t.Fatalf("greeting = %q, want OK MPD <version>", greeting)
+	}
+	return c
+}
+
+func (c *mpdTestClient) readLine() string {
+	c.t.Helper()
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("reading response: %v", err)
+	}
+	return strings.TrimSuffix(line, "\n")
+}
+
+// send writes command lines and returns the response, up to and including
+// the final OK or ACK line
+func (c *mpdTestClient) send(lines ...string) []string {
+	c.t.Helper()
+	c.conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+	if _, err := c.conn.Write([]byte(strings.Join(lines, "\n") + "\n")); err != nil {
+		c.t.Fatalf("sending %q: %v", lines, err)
+	}
+	return c.response()
+}
+
+// response reads lines up to and including the final OK or ACK line
+func (c *mpdTestClient) response() []string {
+	c.t.Helper()
+	var response []string
+	for {
+		line := c.readLine()
+		response = append(response, line)
+		if line == "OK" || strings.HasPrefix(line, "ACK ") {
+			return response
+		}
+	}
+}
+
+// value returns the value of key in a response, or "" if it is missing
+func value(response []string, key string) string {
+	for _, line := range response {
+		if v, ok := strings.CutPrefix(line, key+": "); ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func TestMPD_ParseLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"status", []string{"status"}},
+		{"  play   2 ", []string{"play", "2"}},
+		{`add "/music/My Songs/a.mp3"`, []string{"add", "/music/My Songs/a.mp3"}},
+		{`add "/music/say \"hi\".mp3"`, []string{"add", `/music/say "hi".mp3`}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		got, err := parseMPDLine(tt.line)
+		if err != nil {
+			t.Errorf("parseMPDLine(%q) failed: %v", tt.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseMPDLine(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+
+	if _, err := parseMPDLine(`add "/music/a.mp3`); err == nil {
+		t.Error("parseMPDLine() should reject an unterminated quote")
+	}
+}
+
+func TestMPD_Status(t *testing.T) {
+	client := newMPDTestClient(t, newMPDTestServer(""))
+
+	status := client.send("status")
+	want := map[string]string{
+		"state":          "stop",
+		"playlistlength": "3",
+		"song":           "0",
+		"songid":         "1",
+		"nextsong":       "1",
+		"random":         "0",
+		"repeat":         "0",
+		"volume":         "100",
+	}
+	for key, v := range want {
+		if got := value(status, key); got != v {
+			t.Errorf("status %s = %q, want %q", key, got, v)
+		}
+	}
+
+	song := client.send("currentsong")
+	if value(song, "file") != "/music/a.mp3" || value(song, "Pos") != "0" || value(song, "Id") != "1" {
+		t.Errorf("currentsong = %q, want a.mp3 at position 0", song)
+	}
+
+	song = client.send("playlistid 2")
+	if value(song, "Title") != "Bee" || value(song, "Artist") != "The Hive" {
+		t.Errorf("playlistid 2 = %q, want the tags it was loaded with", song)
+	}
+}
+
+func TestMPD_Options(t *testing.T) {
+	server := newMPDTestServer("")
+	client := newMPDTestClient(t, server)
+
+	steps := []struct {
+		command string
+		want    playlist.RepeatMode
+	}{
+		{"repeat 1", playlist.RepeatAll},
+		{"single 1", playlist.RepeatOne},
+		{"single 0", playlist.RepeatAll},
+		{"repeat 0", playlist.RepeatOff},
+		{"single 1", playlist.RepeatOne},
+	}
+	for _, step := range steps {
+		if resp := client.send(step.command); resp[len(resp)-1] != "OK" {
+			t.Fatalf("%s = %q", step.command, resp)
+		}
+		if got := server.playlist.GetRepeatMode(); got != step.want {
+			t.Errorf("after %s repeat mode = %v, want %v", step.command, got, step.want)
+		}
+	}
+
+	client.send("random 1")
+	if !server.playlist.IsShuffled() {
+		t.Error("random 1 should shuffle")
+	}
+
+	client.send("setvol 40")
+	if got := value(client.send("status"), "volume"); got != "40" {
+		t.Errorf("volume after setvol 40 = %s, want 40", got)
+	}
+
+	if resp := client.send("random maybe"); !strings.HasPrefix(resp[0], "ACK [2@0] {random}") {
+		t.Errorf("random maybe = %q, want an argument error", resp)
+	}
+}
+
+func TestMPD_Queue(t *testing.T) {
+	server := newMPDTestServer("")
+	client := newMPDTestClient(t, server)
+
+	files := func() []string {
+		var files []string
+		for _, line := range client.send("playlistinfo") {
+			if file, ok := strings.CutPrefix(line, "file: "); ok {
+				files = append(files, file)
+			}
+		}
+		return files
+	}
+
+	version := value(client.send("status"), "playlist")
+
+	client.send("move 2 0")
+	if got, want := files(), []string{"/music/c.mp3", "/music/a.mp3", "/music/b.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("after move 2 0 queue = %q, want %q", got, want)
+	}
+
+	client.send("move 1:3 0")
+	if got, want := files(), []string{"/music/a.mp3", "/music/b.mp3", "/music/c.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("after move 1:3 0 queue = %q, want %q", got, want)
+	}
+
+	client.send("delete 0:2")
+	if got, want := files(), []string{"/music/c.mp3"}; !slices.Equal(got, want) {
+		t.Errorf("after delete 0:2 queue = %q, want %q", got, want)
+	}
+
+	if changes := client.send("plchanges " + version); value(changes, "file") != "/music/c.mp3" {
+		t.Errorf("plchanges %s = %q, want the changed queue", version, changes)
+	}
+	current := value(client.send("status"), "playlist")
+	if changes := client.send("plchanges " + current); len(changes) != 1 {
+		t.Errorf("plchanges %s = %q, want no changes", current, changes)
+	}
+
+	if resp := client.send("add music/new.mp3"); !strings.HasPrefix(resp[0], "ACK [50@0] {add}") {
+		t.Errorf("add with a relative path = %q, want an error", resp)
+	}
+	if resp := client.send("deleteid 9"); !strings.HasPrefix(resp[0], "ACK [50@0] {deleteid}") {
+		t.Errorf("deleteid 9 = %q, want No such song", resp)
+	}
+}
+
+func TestMPD_SongIDs(t *testing.T) {
+	client := newMPDTestClient(t, newMPDTestServer(""))
+
+	id := value(client.send("playlistinfo 2"), "Id")
+	client.send("move 2 0")
+	client.send("delete 1")
+	if resp := client.send("playlistid " + id); value(resp, "file") != "/music/c.mp3" {
+		t.Errorf("after move and delete playlistid %s = %q, want /music/c.mp3", id, resp)
+	}
+
+	client.send("deleteid " + id)
+	if resp := client.send("playlistinfo"); value(resp, "file") != "/music/b.mp3" || value(resp, "Id") == id {
+		t.Errorf("after deleteid %s queue = %q, want only /music/b.mp3", id, resp)
+	}
+	if resp := client.send("playid " + id); !strings.HasPrefix(resp[0], "ACK [50@0] {playid}") {
+		t.Errorf("playid %s after deleting it = %q, want No such song", id, resp)
+	}
+}
+
+func TestMPD_MultiLineTag(t *testing.T) {
+	server := newMPDTestServer("")
+	server.playlist.Append([]*shared.Track{{Filename: "d.mp3", Path: "/music/d.mp3", Comment: "Ripped from vinyl\nOK"}})
+	client := newMPDTestClient(t, server)
+
+	song := client.send("playlistinfo 3")
+	if got := value(song, "Comment"); got != "Ripped from vinyl OK" {
+		t.Errorf("Comment = %q, want the lines joined with a space", got)
+	}
+	if value(song, "Pos") != "3" {
+		t.Errorf("playlistinfo 3 = %q, want the whole song before OK", song)
+	}
+}
+
+func TestMPD_CommandList(t *testing.T) {
+	client := newMPDTestClient(t, newMPDTestServer(""))
+
+	resp := client.send("command_list_ok_begin", "ping", "status", "command_list_end")
+	if resp[0] != "list_OK" || resp[len(resp)-2] != "list_OK" || resp[len(resp)-1] != "OK" {
+		t.Errorf("command list response = %q, want list_OK after each command", resp)
+	}
+
+	resp = client.send("command_list_begin", "ping", "bogus", "ping", "command_list_end")
+	if len(resp) != 1 || !strings.HasPrefix(resp[0], "ACK [5@1] {bogus}") {
+		t.Errorf("command list with an unknown command = %q, want ACK [5@1]", resp)
+	}
+}
+
+func TestMPD_Idle(t *testing.T) {
+	server := newMPDTestServer("")
+	client := newMPDTestClient(t, server)
+
+	client.conn.Write([]byte("idle options\n"))
+	// Let the session start waiting before the change happens
+	time.Sleep(50 * time.Millisecond)
+	server.HandleCommand(shared.Command{Type: shared.CmdShuffle})
+
+	if resp := client.response(); !slices.Equal(resp, []string{"changed: options", "OK"}) {
+		t.Errorf("idle = %q, want changed: options", resp)
+	}
+
+	client.conn.Write([]byte("idle\n"))
+	if resp := client.send("noidle"); !slices.Equal(resp, []string{"OK"}) {
+		t.Errorf("noidle = %q, want OK", resp)
+	}
+
+	// Changes between idles are reported by the next one
+	server.HandleCommand(shared.NewVolumeCommand(30))
+	if resp := client.send("idle"); !slices.Equal(resp, []string{"changed: mixer", "OK"}) {
+		t.Errorf("idle after a volume change = %q, want changed: mixer", resp)
+	}
+}
+
+func TestMPD_Password(t *testing.T) {
+	client := newMPDTestClient(t, newMPDTestServer("secret"))
+
+	if resp := client.send("status"); !strings.HasPrefix(resp[0], "ACK [4@0] {status}") {
+		t.Errorf("status before password = %q, want a permission error", resp)
+	}
+	if resp := client.send("password wrong"); !strings.HasPrefix(resp[0], "ACK [3@0] {password}") {
+		t.Errorf("wrong password = %q, want a password error", resp)
+	}
+	if resp := client.send("password secret"); !slices.Equal(resp, []string{"OK"}) {
+		t.Errorf("right password = %q, want OK", resp)
+	}
+	if resp := client.send("status"); value(resp, "state") != "stop" {
+		t.Errorf("status after password = %q", resp)
+	}
+}
@@ -0,0 +1,686 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+)
+
+// MPRIS names, see https://specifications.freedesktop.org/mpris-spec/latest/
+const (
+	mprisBusName        = "org.mpris.MediaPlayer2.auxbox"
+	mprisPath           = dbus.ObjectPath("/org/mpris/MediaPlayer2")
+	mprisRootIface      = "org.mpris.MediaPlayer2"
+	mprisPlayerIface    = "org.mpris.MediaPlayer2.Player"
+	mprisTrackListIface = "org.mpris.MediaPlayer2.TrackList"
+	propertiesIface     = "org.freedesktop.DBus.Properties"
+
+	// mprisNoTrack is the track ID the spec reserves for "no track"
+	mprisNoTrack = dbus.ObjectPath("/org/mpris/MediaPlayer2/TrackList/NoTrack")
+
+	// mprisTrackPrefix starts the track IDs, which end in the queue index.
+	// Paths under /org/mpris are reserved, so they live under our own name.
+	mprisTrackPrefix = "/org/auxbox/track/"
+)
+
+// mprisMimeTypes are the formats the decoders handle
+var mprisMimeTypes = []string{
+	"audio/mpeg", "audio/flac", "audio/ogg", "audio/vorbis",
+	"audio/wav", "audio/x-wav", "audio/aiff", "audio/x-aiff",
+}
+
+// mprisService exposes the server to desktop media controls over D-Bus.
+// Methods run the same commands as the socket, so events and state saving
+// work the same; properties are read when asked for, so Position is always
+// current.
+type mprisService struct {
+	server *Server
+	conn   *dbus.Conn
+	cancel func() // Unsubscribes from the event bus
+}
+
+// SetMPRIS turns the MPRIS D-Bus interface on or off. It starts with the
+// server, on the session bus.
+func (s *Server) SetMPRIS(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mprisEnabled = enabled
+}
+
+// startMPRIS registers on the session bus if MPRIS is enabled. A failure,
+// e.g. no session bus on a headless box, is logged rather than stopping the
+// daemon.
+func (s *Server) startMPRIS() {
+	if !s.mprisEnabled {
+		return
+	}
+
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		log.Printf("MPRIS disabled: %v", err)
+		return
+	}
+	if err := s.serveMPRIS(conn); err != nil {
+		log.Printf("MPRIS disabled: %v", err)
+		conn.Close()
+		return
+	}
+	log.Printf("MPRIS registered as %s", mprisBusName)
+}
+
+// serveMPRIS exports the MPRIS interfaces on conn, claims the bus name and
+// starts forwarding events as D-Bus signals
+func (s *Server) serveMPRIS(conn *dbus.Conn) error {
+	m := &mprisService{server: s, conn: conn}
+
+	exports := []struct {
+		object  interface{}
+		iface   string
+		methods map[string]string // Go method names that differ from the D-Bus ones
+	}{
+		{mprisRoot{m}, mprisRootIface, nil},
+		{mprisPlayer{m}, mprisPlayerIface, map[string]string{"SeekBy": "Seek"}},
+		{mprisTrackList{m}, mprisTrackListIface, nil},
+		{mprisProperties{m}, propertiesIface, nil},
+		{introspect.Introspectable(mprisIntrospection), "org.freedesktop.DBus.Introspectable", nil},
+	}
+	for _, export := range exports {
+		if err := conn.ExportWithMap(export.object, export.methods, mprisPath, export.iface); err != nil {
+			return fmt.Errorf("failed to export %s: %w", export.iface, err)
+		}
+	}
+
+	reply, err := conn.RequestName(mprisBusName, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", mprisBusName, err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return fmt.Errorf("%s is already taken", mprisBusName)
+	}
+
+	events, cancel := s.events.Subscribe()
+	m.cancel = cancel
+	go m.forwardEvents(events)
+
+	s.mpris = m
+	return nil
+}
+
+// stopMPRIS leaves the session bus, if registered
+func (s *Server) stopMPRIS() {
+	if s.mpris == nil {
+		return
+	}
+
+	s.mpris.cancel()
+	if err := s.mpris.conn.Close(); err != nil {
+		log.Printf("Error closing D-Bus connection: %v", err)
+	}
+	s.mpris = nil
+}
+
+// forwardEvents turns events into PropertiesChanged and the other MPRIS
+// signals until the subscription is cancelled
+func (m *mprisService) forwardEvents(events <-chan shared.Event) {
+	for event := range events {
+		var changed []string
+		switch event.Type {
+		case shared.EventTrack:
+			changed = []string{"Metadata", "CanGoNext", "CanGoPrevious", "CanPlay", "CanPause", "CanSeek"}
+		case shared.EventPlay, shared.EventPause, shared.EventStop, shared.EventEnd:
+			changed = []string{"PlaybackStatus"}
+		case shared.EventVolume:
+			changed = []string{"Volume"}
+		case shared.EventShuffle:
+			changed = []string{"Shuffle", "CanGoNext", "CanGoPrevious"}
+		case shared.EventRepeat:
+			changed = []string{"LoopStatus"}
+		case shared.EventQueue:
+			// Track IDs are queue positions, so an edit can renumber them
+			changed = []string{"Metadata", "CanGoNext", "CanGoPrevious", "CanPlay", "CanPause", "CanSeek"}
+			m.emit(mprisTrackListIface+".TrackListReplaced", m.trackIDs(), m.currentTrackID())
+		case shared.EventSeek:
+			m.emit(mprisPlayerIface+".Seeked", m.server.player.GetPosition().Microseconds())
+		}
+
+		if len(changed) > 0 {
+			props := m.playerProperties()
+			values := make(map[string]dbus.Variant, len(changed))
+			for _, name := range changed {
+				values[name] = props[name]
+			}
+			m.emit(propertiesIface+".PropertiesChanged", mprisPlayerIface, values, []string{})
+		}
+	}
+}
+
+// emit sends a signal from the MPRIS object
+func (m *mprisService) emit(name string, values ...interface{}) {
+	if err := m.conn.Emit(mprisPath, name, values...); err != nil {
+		log.Printf("MPRIS: failed to emit %s: %v", name, err)
+	}
+}
+
+// run handles a command as if it came over the socket, turning a failure
+// into a D-Bus error
+func (m *mprisService) run(cmd shared.Command) *dbus.Error {
+	return dbusError(m.server.handleCommand(cmd))
+}
+
+// dbusError returns a D-Bus error for a failed response, or nil
+func dbusError(resp shared.Response) *dbus.Error {
+	if !resp.Success {
+		return dbus.MakeFailedError(fmt.Errorf("%s", resp.Message))
+	}
+	return nil
+}
+
+// rootProperties returns the org.mpris.MediaPlayer2 properties
+func (m *mprisService) rootProperties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"CanQuit":             dbus.MakeVariant(true),
+		"CanRaise":            dbus.MakeVariant(false),
+		"CanSetFullscreen":    dbus.MakeVariant(false),
+		"Fullscreen":          dbus.MakeVariant(false),
+		"HasTrackList":        dbus.MakeVariant(true),
+		"Identity":            dbus.MakeVariant("auxbox"),
+		"SupportedUriSchemes": dbus.MakeVariant([]string{"file"}),
+		"SupportedMimeTypes":  dbus.MakeVariant(mprisMimeTypes),
+	}
+}
+
+// playerProperties returns the org.mpris.MediaPlayer2.Player properties
+func (m *mprisService) playerProperties() map[string]dbus.Variant {
+	player, list := m.server.player, m.server.playlist
+	loaded := player.GetCurrentTrack() != nil
+
+	return map[string]dbus.Variant{
+		"PlaybackStatus": dbus.MakeVariant(m.playbackStatus()),
+		"LoopStatus":     dbus.MakeVariant(loopStatus(list.GetRepeatMode())),
+		"Rate":           dbus.MakeVariant(1.0),
+		"MinimumRate":    dbus.MakeVariant(1.0),
+		"MaximumRate":    dbus.MakeVariant(1.0),
+		"Shuffle":        dbus.MakeVariant(list.IsShuffled()),
+		"Metadata":       dbus.MakeVariant(m.currentMetadata()),
+		"Volume":         dbus.MakeVariant(player.GetStatus().Volume),
+		"Position":       dbus.MakeVariant(player.GetPosition().Microseconds()),
+		"CanGoNext":      dbus.MakeVariant(list.HasNext()),
+		"CanGoPrevious":  dbus.MakeVariant(list.HasPrevious()),
+		"CanPlay":        dbus.MakeVariant(list.TrackCount() > 0),
+		"CanPause":       dbus.MakeVariant(loaded),
+		"CanSeek":        dbus.MakeVariant(loaded),
+		"CanControl":     dbus.MakeVariant(true),
+	}
+}
+
+// trackListProperties returns the org.mpris.MediaPlayer2.TrackList properties
+func (m *mprisService) trackListProperties() map[string]dbus.Variant {
+	return map[string]dbus.Variant{
+		"Tracks":        dbus.MakeVariant(m.trackIDs()),
+		"CanEditTracks": dbus.MakeVariant(false),
+	}
+}
+
+// playbackStatus returns "Playing", "Paused" or "Stopped"
+func (m *mprisService) playbackStatus() string {
+	switch {
+	case m.server.player.IsPlaying():
+		return "Playing"
+	case m.server.player.IsPaused():
+		return "Paused"
+	default:
+		return "Stopped"
+	}
+}
+
+// loopStatus maps a repeat mode onto the MPRIS names for it
+func loopStatus(mode playlist.RepeatMode) string {
+	switch mode {
+	case playlist.RepeatOne:
+		return "Track"
+	case playlist.RepeatAll:
+		return "Playlist"
+	default:
+		return "None"
+	}
+}
+
+// trackID returns the MPRIS track ID for a queue index
+func trackID(idx int) dbus.ObjectPath {
+	return dbus.ObjectPath(mprisTrackPrefix + strconv.Itoa(idx))
+}
+
+// trackIndex returns the queue index a track ID refers to, or -1
+func (m *mprisService) trackIndex(id dbus.ObjectPath) int {
+	number, ok := strings.CutPrefix(string(id), mprisTrackPrefix)
+	if !ok {
+		return -1
+	}
+	idx, err := strconv.Atoi(number)
+	if err != nil || idx < 0 || idx >= m.server.playlist.TrackCount() {
+		return -1
+	}
+	return idx
+}
+
+// currentTrackID returns the ID of the current track, or NoTrack
+func (m *mprisService) currentTrackID() dbus.ObjectPath {
+	if m.server.playlist.GetCurrentTrack() == nil {
+		return mprisNoTrack
+	}
+	return trackID(m.server.playlist.GetCurrentIndex())
+}
+
+// trackIDs returns the IDs of every track in the queue, in queue order
+func (m *mprisService) trackIDs() []dbus.ObjectPath {
+	ids := make([]dbus.ObjectPath, m.server.playlist.TrackCount())
+	for i := range ids {
+		ids[i] = trackID(i)
+	}
+	return ids
+}
+
+// currentMetadata describes the current track, including its length and
+// tags, which are only known once the player has opened it
+func (m *mprisService) currentMetadata() map[string]dbus.Variant {
+	track := m.server.playlist.GetCurrentTrack()
+	if track == nil {
+		return map[string]dbus.Variant{"mpris:trackid": dbus.MakeVariant(mprisNoTrack)}
+	}
+
+	metadata := trackMetadata(m.server.playlist.GetCurrentIndex(), track)
+	if length := m.server.player.GetDuration(); length > 0 {
+		metadata["mpris:length"] = dbus.MakeVariant(length.Microseconds())
+	}
+
+	tags := m.server.player.GetMetadata()
+	if track.Title == "" && tags.Get("title") != "" {
+		metadata["xesam:title"] = dbus.MakeVariant(tags.Get("title"))
+	}
+	if artists := tags["ARTIST"]; len(artists) > 0 {
+		metadata["xesam:artist"] = dbus.MakeVariant(artists)
+	}
+	if album := tags.Get("album"); album != "" {
+		metadata["xesam:album"] = dbus.MakeVariant(album)
+	}
+	return metadata
+}
+
+// trackMetadata describes a queue entry from what the queue knows about it,
+// including the tags read when it was loaded
+func trackMetadata(idx int, track *shared.Track) map[string]dbus.Variant {
+	title := track.Title
+	if title == "" {
+		title = track.Filename
+	}
+	fileURL := url.URL{Scheme: "file", Path: track.Path}
+
+	metadata := map[string]dbus.Variant{
+		"mpris:trackid": dbus.MakeVariant(trackID(idx)),
+		"xesam:title":   dbus.MakeVariant(title),
+		"xesam:url":     dbus.MakeVariant(fileURL.String()),
+	}
+	if track.Artist != "" {
+		metadata["xesam:artist"] = dbus.MakeVariant([]string{track.Artist})
+	}
+	if track.Album != "" {
+		metadata["xesam:album"] = dbus.MakeVariant(track.Album)
+	}
+	if track.Genre != "" {
+		metadata["xesam:genre"] = dbus.MakeVariant(strings.Split(track.Genre, ", "))
+	}
+	if track.Comment != "" {
+		metadata["xesam:comment"] = dbus.MakeVariant([]string{track.Comment})
+	}
+	if bpm, err := strconv.Atoi(track.BPM); err == nil {
+		metadata["xesam:audioBPM"] = dbus.MakeVariant(int32(bpm))
+	}
+	if track.Rating > 0 {
+		metadata["xesam:userRating"] = dbus.MakeVariant(float64(track.Rating) / 5)
+	}
+	return metadata
+}
+
+// mprisRoot implements org.mpris.MediaPlayer2
+type mprisRoot struct{ *mprisService }
+
+// Raise does nothing; the daemon has no window
+func (r mprisRoot) Raise() *dbus.Error {
+	return nil
+}
+
+// Quit exits the daemon
+func (r mprisRoot) Quit() *dbus.Error {
+	return r.run(shared.NewExitCommand())
+}
+
+// mprisPlayer implements org.mpris.MediaPlayer2.Player
+type mprisPlayer struct{ *mprisService }
+
+func (p mprisPlayer) Next() *dbus.Error {
+	return p.run(shared.NewSkipCommand(1))
+}
+
+func (p mprisPlayer) Previous() *dbus.Error {
+	return p.run(shared.NewBackCommand(1))
+}
+
+// Pause pauses playback, and does nothing if it is not playing
+func (p mprisPlayer) Pause() *dbus.Error {
+	if !p.server.player.IsPlaying() {
+		return nil
+	}
+	return p.run(shared.NewPauseCommand())
+}
+
+func (p mprisPlayer) PlayPause() *dbus.Error {
+	if p.server.player.IsPlaying() {
+		return p.run(shared.NewPauseCommand())
+	}
+	return p.run(shared.NewPlayCommand())
+}
+
+func (p mprisPlayer) Stop() *dbus.Error {
+	return p.run(shared.NewStopCommand())
+}
+
+// Play starts or resumes playback, and does nothing if it is playing
+func (p mprisPlayer) Play() *dbus.Error {
+	if p.server.player.IsPlaying() {
+		return nil
+	}
+	return p.run(shared.NewPlayCommand())
+}
+
+// SeekBy implements Seek, moving playback by offset microseconds. Seeking
+// past the end moves on to the next track, as the spec asks.
+func (p mprisPlayer) SeekBy(offset int64) *dbus.Error {
+	if p.server.player.GetCurrentTrack() == nil {
+		return nil
+	}
+
+	delta := time.Duration(offset) * time.Microsecond
+	position, length := p.server.player.GetPosition(), p.server.player.GetDuration()
+	if length > 0 && position+delta >= length {
+		return p.Next()
+	}
+
+	target := delta.String()
+	if delta >= 0 {
+		target = "+" + target
+	}
+	return p.run(shared.NewSeekCommand(target))
+}
+
+// SetPosition seeks to position microseconds into the track. It is ignored
+// if trackID is no longer the current track or the position is out of range.
+func (p mprisPlayer) SetPosition(trackID dbus.ObjectPath, position int64) *dbus.Error {
+	if trackID != p.currentTrackID() || p.server.player.GetCurrentTrack() == nil {
+		return nil
+	}
+
+	target := time.Duration(position) * time.Microsecond
+	if target < 0 || target > p.server.player.GetDuration() {
+		return nil
+	}
+	return p.run(shared.NewSeekCommand(target.String()))
+}
+
+// OpenUri plays a local folder or playlist in place of the queue, or a
+// single file right away, ahead of the rest of the queue
+func (p mprisPlayer) OpenUri(uri string) *dbus.Error {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return dbus.MakeFailedError(fmt.Errorf("unsupported URI %q: only file:// URIs can be opened", uri))
+	}
+
+	path := filepath.Clean(parsed.Path)
+	info, err := os.Stat(path)
+	if err != nil {
+		return dbus.MakeFailedError(fmt.Errorf("path does not exist: %s", path))
+	}
+
+	if info.IsDir() {
+		return p.run(shared.Command{Type: shared.CmdPlay, Path: path, Source: shared.SourceFolder})
+	}
+	if p.server.loader.playlistFormats.FormatForExtension(path) != "" {
+		return p.run(shared.Command{Type: shared.CmdPlay, Path: path, Source: shared.SourcePlaylist})
+	}
+
+	hadTrack := p.server.playlist.GetCurrentTrack() != nil
+	if err := p.run(shared.Command{Type: shared.CmdPlayNext, Path: path}); err != nil {
+		return err
+	}
+	if hadTrack {
+		if err := p.run(shared.NewSkipCommand(1)); err != nil {
+			return err
+		}
+	}
+	return p.Play()
+}
+
+// mprisTrackList implements org.mpris.MediaPlayer2.TrackList. The queue is
+// edited through auxbox itself, so CanEditTracks is false and AddTrack and
+// RemoveTrack do nothing.
+type mprisTrackList struct{ *mprisService }
+
+// GetTracksMetadata describes the given tracks, skipping unknown IDs
+func (t mprisTrackList) GetTracksMetadata(ids []dbus.ObjectPath) ([]map[string]dbus.Variant, *dbus.Error) {
+	tracks := t.server.playlist.GetTrackList()
+	current := t.currentTrackID()
+
+	metadata := make([]map[string]dbus.Variant, 0, len(ids))
+	for _, id := range ids {
+		idx := t.trackIndex(id)
+		if idx < 0 || idx >= len(tracks) {
+			continue
+		}
+		if id == current {
+			metadata = append(metadata, t.currentMetadata())
+		} else {
+			metadata = append(metadata, trackMetadata(idx, tracks[idx]))
+		}
+	}
+	return metadata, nil
+}
+
+func (t mprisTrackList) AddTrack(uri string, after dbus.ObjectPath, setAsCurrent bool) *dbus.Error {
+	return nil
+}
+
+func (t mprisTrackList) RemoveTrack(id dbus.ObjectPath) *dbus.Error {
+	return nil
+}
+
+// GoTo jumps to a track, and does nothing if the ID is unknown
+func (t mprisTrackList) GoTo(id dbus.ObjectPath) *dbus.Error {
+	idx := t.trackIndex(id)
+	if idx < 0 {
+		return nil
+	}
+	return t.run(shared.Command{Type: shared.CmdJump, Query: strconv.Itoa(idx + 1)})
+}
+
+// mprisProperties implements org.freedesktop.DBus.Properties for all three
+// MPRIS interfaces
+type mprisProperties struct{ *mprisService }
+
+func (p mprisProperties) Get(iface, name string) (dbus.Variant, *dbus.Error) {
+	props, err := p.GetAll(iface)
+	if err != nil {
+		return dbus.Variant{}, err
+	}
+	value, ok := props[name]
+	if !ok {
+		return dbus.Variant{}, unknownPropertyError(iface, name)
+	}
+	return value, nil
+}
+
+func (p mprisProperties) GetAll(iface string) (map[string]dbus.Variant, *dbus.Error) {
+	switch iface {
+	case mprisRootIface:
+		return p.rootProperties(), nil
+	case mprisPlayerIface:
+		return p.playerProperties(), nil
+	case mprisTrackListIface:
+		return p.trackListProperties(), nil
+	}
+	return nil, dbus.NewError("org.freedesktop.DBus.Error.UnknownInterface", []interface{}{"Unknown interface " + iface})
+}
+
+// Set changes LoopStatus, Shuffle or Volume. Rate can only be 1, so
+// setting it does nothing; everything else is read-only.
+func (p mprisProperties) Set(iface, name string, value dbus.Variant) *dbus.Error {
+	if iface != mprisPlayerIface {
+		if _, err := p.Get(iface, name); err != nil {
+			return err
+		}
+		return propertyReadOnlyError(iface, name)
+	}
+
+	switch name {
+	case "LoopStatus":
+		status, ok := value.Value().(string)
+		if !ok {
+			return invalidArgsError("LoopStatus must be a string")
+		}
+		return p.setLoopStatus(status)
+	case "Shuffle":
+		shuffle, ok := value.Value().(bool)
+		if !ok {
+			return invalidArgsError("Shuffle must be a boolean")
+		}
+		if shuffle == p.server.playlist.IsShuffled() {
+			return nil
+		}
+		return p.run(shared.Command{Type: shared.CmdShuffle})
+	case "Volume":
+		volume, ok := value.Value().(float64)
+		if !ok {
+			return invalidArgsError("Volume must be a double")
+		}
+		volume = math.Max(0, math.Min(volume, 1))
+		return p.run(shared.NewVolumeCommand(int(math.Round(volume * 100))))
+	case "Rate":
+		return nil
+	}
+
+	if _, err := p.Get(iface, name); err != nil {
+		return err
+	}
+	return propertyReadOnlyError(iface, name)
+}
+
+// setLoopStatus sets the repeat mode from its MPRIS name
+func (m *mprisService) setLoopStatus(status string) *dbus.Error {
+	modes := map[string]playlist.RepeatMode{
+		"None":     playlist.RepeatOff,
+		"Track":    playlist.RepeatOne,
+		"Playlist": playlist.RepeatAll,
+	}
+	mode, ok := modes[status]
+	if !ok {
+		return invalidArgsError(fmt.Sprintf("Unknown LoopStatus %q", status))
+	}
+	return dbusError(m.server.setRepeatMode(mode))
+}
+
+func unknownPropertyError(iface, name string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.UnknownProperty", []interface{}{fmt.Sprintf("Unknown property %s.%s", iface, name)})
+}
+
+func propertyReadOnlyError(iface, name string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.PropertyReadOnly", []interface{}{fmt.Sprintf("Property %s.%s is read-only", iface, name)})
+}
+
+func invalidArgsError(message string) *dbus.Error {
+	return dbus.NewError("org.freedesktop.DBus.Error.InvalidArgs", []interface{}{message})
+}
+
+// mprisIntrospection describes the exported object for clients that look
+// before they call
+const mprisIntrospection = introspect.IntrospectDeclarationString + `<node>
+  <interface name="org.mpris.MediaPlayer2">
+    <method name="Raise"/>
+    <method name="Quit"/>
+    <property name="CanQuit" type="b" access="read"/>
+    <property name="CanRaise" type="b" access="read"/>
+    <property name="CanSetFullscreen" type="b" access="read"/>
+    <property name="Fullscreen" type="b" access="readwrite"/>
+    <property name="HasTrackList" type="b" access="read"/>
+    <property name="Identity" type="s" access="read"/>
+    <property name="SupportedUriSchemes" type="as" access="read"/>
+    <property name="SupportedMimeTypes" type="as" access="read"/>
+  </interface>
+  <interface name="org.mpris.MediaPlayer2.Player">
+    <method name="Next"/>
+    <method name="Previous"/>
+    <method name="Pause"/>
+    <method name="PlayPause"/>
+    <method name="Stop"/>
+    <method name="Play"/>
+    <method name="Seek">
+      <arg name="Offset" type="x" direction="in"/>
+    </method>
+    <method name="SetPosition">
+      <arg name="TrackId" type="o" direction="in"/>
+      <arg name="Position" type="x" direction="in"/>
+    </method>
+    <method name="OpenUri">
+      <arg name="Uri" type="s" direction="in"/>
+    </method>
+    <signal name="Seeked">
+      <arg name="Position" type="x"/>
+    </signal>
+    <property name="PlaybackStatus" type="s" access="read"/>
+    <property name="LoopStatus" type="s" access="readwrite"/>
+    <property name="Rate" type="d" access="readwrite"/>
+    <property name="Shuffle" type="b" access="readwrite"/>
+    <property name="Metadata" type="a{sv}" access="read"/>
+    <property name="Volume" type="d" access="readwrite"/>
+    <property name="Position" type="x" access="read"/>
+    <property name="MinimumRate" type="d" access="read"/>
+    <property name="MaximumRate" type="d" access="read"/>
+    <property name="CanGoNext" type="b" access="read"/>
+    <property name="CanGoPrevious" type="b" access="read"/>
+    <property name="CanPlay" type="b" access="read"/>
+    <property name="CanPause" type="b" access="read"/>
+    <property name="CanSeek" type="b" access="read"/>
+    <property name="CanControl" type="b" access="read"/>
+  </interface>
+  <interface name="org.mpris.MediaPlayer2.TrackList">
+    <method name="GetTracksMetadata">
+      <arg name="TrackIds" type="ao" direction="in"/>
+      <arg name="Metadata" type="aa{sv}" direction="out"/>
+    </method>
+    <method name="AddTrack">
+      <arg name="Uri" type="s" direction="in"/>
+      <arg name="AfterTrack" type="o" direction="in"/>
+      <arg name="SetAsCurrent" type="b" direction="in"/>
+    </method>
+    <method name="RemoveTrack">
+      <arg name="TrackId" type="o" direction="in"/>
+    </method>
+    <method name="GoTo">
+      <arg name="TrackId" type="o" direction="in"/>
+    </method>
+    <signal name="TrackListReplaced">
+      <arg name="Tracks" type="ao"/>
+      <arg name="CurrentTrack" type="o"/>
+    </signal>
+    <property name="Tracks" type="ao" access="read"/>
+    <property name="CanEditTracks" type="b" access="read"/>
+  </interface>` + prop.IntrospectDataString + introspect.IntrospectDataString + `</node>`
@@ -0,0 +1,212 @@
+package server
+
+import (
+	"bufio"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+	"github.com/godbus/dbus/v5"
+)
+
+// privateSessionBus starts a dbus-daemon for the test and returns its address
+func privateSessionBus(t *testing.T) string {
+	path, err := exec.LookPath("dbus-daemon")
+	if err != nil {
+		t.Skip("dbus-daemon is not installed")
+	}
+
+	daemon := exec.Command(path, "--session", "--nofork", "--print-address")
+	stdout, err := daemon.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := daemon.Start(); err != nil {
+		t.Skipf("could not start dbus-daemon: %v", err)
+	}
+	t.Cleanup(func() {
+		daemon.Process.Kill()
+		daemon.Wait()
+	})
+
+	address, err := bufio.NewReader(stdout).ReadString('\n')
+	if err != nil {
+		t.Fatalf("could not read the dbus-daemon address: %v", err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// newMPRISTestServer returns a server with three tracks loaded and serving
+// MPRIS on a private bus, and the MPRIS object as seen by a client
+func newMPRISTestServer(t *testing.T) (*Server, *dbus.Conn, dbus.BusObject) {
+	address := privateSessionBus(t)
+
+	server := NewServer()
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: "/music/a.mp3"},
+		{Filename: "b.mp3", Path: "/music/b.mp3", Title: "Bee"},
+		{Filename: "c.mp3", Path: "/music/c.mp3"},
+	}
+	server.LoadTracks(tracks, "/music", shared.SourceFolder)
+	// Settle the initial state, e.g. the default volume, before any signals
+	server.HandleCommand(shared.NewStatusCommand())
+
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("server could not connect: %v", err)
+	}
+	if err := server.serveMPRIS(conn); err != nil {
+		t.Fatalf("serveMPRIS() failed: %v", err)
+	}
+	t.Cleanup(server.stopMPRIS)
+
+	client, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatalf("client could not connect: %v", err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	return server, client, client.Object(mprisBusName, mprisPath)
+}
+
+// getProperty reads an MPRIS property, failing the test on error
+func getProperty(t *testing.T, object dbus.BusObject, name string) interface{} {
+	t.Helper()
+	value, err := object.GetProperty(name)
+	if err != nil {
+		t.Fatalf("GetProperty(%s) failed: %v", name, err)
+	}
+	return value.Value()
+}
+
+func TestMPRIS_Properties(t *testing.T) {
+	_, _, object := newMPRISTestServer(t)
+
+	tests := []struct {
+		name string
+		want interface{}
+	}{
+		{mprisRootIface + ".Identity", "auxbox"},
+		{mprisRootIface + ".HasTrackList", true},
+		{mprisPlayerIface + ".PlaybackStatus", "Stopped"},
+		{mprisPlayerIface + ".LoopStatus", "None"},
+		{mprisPlayerIface + ".Shuffle", false},
+		{mprisPlayerIface + ".CanGoNext", true},
+		{mprisPlayerIface + ".CanGoPrevious", false},
+		{mprisPlayerIface + ".Rate", 1.0},
+		{mprisTrackListIface + ".CanEditTracks", false},
+	}
+	for _, tt := range tests {
+		if got := getProperty(t, object, tt.name); got != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	metadata := getProperty(t, object, mprisPlayerIface+".Metadata").(map[string]dbus.Variant)
+	if id := metadata["mpris:trackid"].Value(); id != trackID(0) {
+		t.Errorf("mpris:trackid = %v, want %v", id, trackID(0))
+	}
+	if url := metadata["xesam:url"].Value(); url != "file:///music/a.mp3" {
+		t.Errorf("xesam:url = %v, want file:///music/a.mp3", url)
+	}
+
+	ids := getProperty(t, object, mprisTrackListIface+".Tracks").([]dbus.ObjectPath)
+	if len(ids) != 3 {
+		t.Fatalf("Tracks has %d entries, want 3", len(ids))
+	}
+
+	var described []map[string]dbus.Variant
+	if err := object.Call(mprisTrackListIface+".GetTracksMetadata", 0, ids[1:2]).Store(&described); err != nil {
+		t.Fatalf("GetTracksMetadata failed: %v", err)
+	}
+	if len(described) != 1 || described[0]["xesam:title"].Value() != "Bee" {
+		t.Errorf("GetTracksMetadata(%v) = %v, want the title Bee", ids[1:2], described)
+	}
+
+	if _, err := object.GetProperty(mprisPlayerIface + ".Nonsense"); err == nil {
+		t.Error("Getting an unknown property should fail")
+	}
+}
+
+func TestMPRIS_SetProperties(t *testing.T) {
+	server, _, object := newMPRISTestServer(t)
+
+	for _, tt := range []struct {
+		status string
+		want   playlist.RepeatMode
+	}{
+		{"Playlist", playlist.RepeatAll},
+		{"None", playlist.RepeatOff},
+		{"Track", playlist.RepeatOne},
+	} {
+		if err := object.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant(tt.status)); err != nil {
+			t.Fatalf("Setting LoopStatus to %s failed: %v", tt.status, err)
+		}
+		if got := server.playlist.GetRepeatMode(); got != tt.want {
+			t.Errorf("LoopStatus %s gave repeat mode %v, want %v", tt.status, got, tt.want)
+		}
+	}
+
+	if err := object.SetProperty(mprisPlayerIface+".Shuffle", dbus.MakeVariant(true)); err != nil {
+		t.Fatalf("Setting Shuffle failed: %v", err)
+	}
+	if !server.playlist.IsShuffled() {
+		t.Error("Setting Shuffle to true should shuffle the queue")
+	}
+
+	if err := object.SetProperty(mprisPlayerIface+".Volume", dbus.MakeVariant(0.4)); err != nil {
+		t.Fatalf("Setting Volume failed: %v", err)
+	}
+	if got := getProperty(t, object, mprisPlayerIface+".Volume"); got != 0.4 {
+		t.Errorf("Volume = %v, want 0.4", got)
+	}
+
+	if err := object.SetProperty(mprisPlayerIface+".LoopStatus", dbus.MakeVariant("Sometimes")); err == nil {
+		t.Error("Setting an unknown LoopStatus should fail")
+	}
+	if err := object.SetProperty(mprisPlayerIface+".PlaybackStatus", dbus.MakeVariant("Playing")); err == nil {
+		t.Error("Setting a read-only property should fail")
+	}
+}
+
+func TestMPRIS_Signals(t *testing.T) {
+	server, client, _ := newMPRISTestServer(t)
+
+	if err := client.AddMatchSignal(dbus.WithMatchObjectPath(mprisPath)); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	client.Signal(signals)
+
+	// waitForSignal returns the next signal with the given name
+	waitForSignal := func(name string) *dbus.Signal {
+		t.Helper()
+		timeout := time.After(5 * time.Second)
+		for {
+			select {
+			case signal := <-signals:
+				if signal.Name == name {
+					return signal
+				}
+			case <-timeout:
+				t.Fatalf("no %s signal", name)
+			}
+		}
+	}
+
+	server.HandleCommand(shared.Command{Type: shared.CmdShuffle})
+	signal := waitForSignal(propertiesIface + ".PropertiesChanged")
+	changed := signal.Body[1].(map[string]dbus.Variant)
+	if shuffle, ok := changed["Shuffle"]; !ok || shuffle.Value() != true {
+		t.Errorf("PropertiesChanged carried %v, want Shuffle true", changed)
+	}
+
+	server.HandleCommand(shared.NewMoveCommand(3, 1))
+	signal = waitForSignal(mprisTrackListIface + ".TrackListReplaced")
+	if ids := signal.Body[0].([]dbus.ObjectPath); len(ids) != 3 {
+		t.Errorf("TrackListReplaced listed %d tracks, want 3", len(ids))
+	}
+}
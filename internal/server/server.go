@@ -3,8 +3,11 @@ package server
 import (
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cerberussg/auxbox/internal/audio"
@@ -19,30 +22,71 @@ type Server struct {
 	playlist  *playlist.Playlist
 	isRunning bool
 	mu        sync.RWMutex
+	exit      func(code int) // Ends the process after `auxbox exit`; os.Exit but in tests
+
+	// Session saving, off unless SetStatePath is called
+	statePath   string
+	stateMu     sync.Mutex // Guards statePath and writes to the state file
+	stopPersist chan struct{}
+
+	// Events pushed to subscribed clients; see publishChanges
+	events       *shared.EventBus
+	eventMu      sync.Mutex
+	lastEvents   eventState
+	queueVersion atomic.Uint32 // Counts queue changes; see playlistVersion
+
+	// Optional HTTP API; see SetHTTP
+	httpAddr   string
+	httpToken  string
+	httpServer *http.Server
+
+	// Optional MPRIS D-Bus interface; see SetMPRIS
+	mprisEnabled bool
+	mpris        *mprisService
+
+	// Optional MPD protocol server; see SetMPD
+	mpdAddr     string
+	mpdPassword string
+	mpdListener net.Listener
+	mpdClients  map[net.Conn]struct{}
+	mpdMu       sync.Mutex // Guards mpdClients
 
 	playbackHandler   *commands.PlaybackHandler
 	navigationHandler *commands.NavigationHandler
 	infoHandler       *commands.InfoHandler
+	saveHandler       *commands.SaveHandler
+	queueHandler      *commands.QueueHandler
+	tagHandler        *commands.TagHandler
 	loader            *Loader
 }
 
 func NewServer() *Server {
 	player := audio.NewPlayer()
 	playlistObj := playlist.NewPlaylist()
+	events := shared.NewEventBus()
+	transport := shared.NewUnixSocketTransport()
+	transport.SetEventBus(events)
 
 	server := &Server{
-		transport: shared.NewUnixSocketTransport(),
+		transport: transport,
 		player:    player,
 		playlist:  playlistObj,
 		isRunning: false,
+		exit:      os.Exit,
+		events:    events,
 
 		playbackHandler:   commands.NewPlaybackHandler(player, playlistObj),
 		navigationHandler: commands.NewNavigationHandler(player, playlistObj),
 		infoHandler:       commands.NewInfoHandler(player, playlistObj),
+		saveHandler:       commands.NewSaveHandler(playlistObj),
+		queueHandler:      commands.NewQueueHandler(player, playlistObj),
+		tagHandler:        commands.NewTagHandler(playlistObj),
 		loader:            NewLoader(),
 	}
 
 	player.SetOnTrackComplete(server.onTrackComplete)
+	player.SetOnError(server.publishError)
+	player.SetGaplessSource(playlistObj.PeekNext, server.onGaplessAdvance)
 
 	return server
 }
@@ -58,6 +102,11 @@ func (s *Server) Start() error {
 		return fmt.Errorf("daemon is already running")
 	}
 	s.isRunning = true
+	s.stopPersist = make(chan struct{})
+	go s.persistLoop(s.stopPersist)
+	s.startHTTP()
+	s.startMPRIS()
+	s.startMPD()
 	s.mu.Unlock()
 
 	log.Println("Starting auxbox daemon...")
@@ -74,6 +123,10 @@ func (s *Server) Stop() error {
 	}
 
 	log.Println("Stopping auxbox daemon...")
+	close(s.stopPersist)
+	s.stopHTTP()
+	s.stopMPRIS()
+	s.stopMPD()
 
 	if err := s.player.Stop(); err != nil {
 		log.Printf("Error stopping player: %v", err)
@@ -94,6 +147,31 @@ func (s *Server) HandleCommand(cmd shared.Command) shared.Response {
 func (s *Server) handleCommand(cmd shared.Command) shared.Response {
 	log.Printf("Received command: %s", cmd.Type)
 
+	resp := s.dispatchCommand(cmd)
+	if resp.Success && changesQueue(cmd) {
+		s.publishQueueChange()
+	}
+	if resp.Success && cmd.Type == shared.CmdSeek {
+		s.publishSeek()
+	}
+	s.publishChanges()
+	if resp.Success && changesSession(cmd.Type) {
+		s.persistState()
+	}
+	return resp
+}
+
+// changesSession reports whether a successful command may have changed
+// anything the saved session holds
+func changesSession(cmdType shared.CommandType) bool {
+	switch cmdType {
+	case shared.CmdStatus, shared.CmdList, shared.CmdSave, shared.CmdExit:
+		return false
+	}
+	return true
+}
+
+func (s *Server) dispatchCommand(cmd shared.Command) shared.Response {
 	switch cmd.Type {
 	case shared.CmdPlay:
 		return s.handlePlayCommand(cmd)
@@ -105,16 +183,46 @@ func (s *Server) handleCommand(cmd shared.Command) shared.Response {
 		return s.navigationHandler.HandleSkip(cmd)
 	case shared.CmdBack:
 		return s.navigationHandler.HandleBack(cmd)
+	case shared.CmdJump:
+		return s.navigationHandler.HandleJump(cmd)
+	case shared.CmdSeek:
+		return s.playbackHandler.HandleSeek(cmd)
 	case shared.CmdShuffle:
 		return s.handleShuffleCommand()
 	case shared.CmdRepeat:
 		return s.handleRepeatCommand()
+	case shared.CmdGapless:
+		return s.handleGaplessCommand()
 	case shared.CmdStatus:
 		return s.infoHandler.HandleStatus()
 	case shared.CmdList:
 		return s.infoHandler.HandleList()
 	case shared.CmdVolume:
 		return s.infoHandler.HandleVolume(cmd)
+	case shared.CmdResample:
+		return s.infoHandler.HandleResample(cmd)
+	case shared.CmdCrossfade:
+		return s.infoHandler.HandleCrossfade(cmd)
+	case shared.CmdSave:
+		return s.handleSaveCommand(cmd)
+	case shared.CmdEnqueue:
+		return s.handleEnqueueCommand(cmd, false)
+	case shared.CmdPlayNext:
+		return s.handleEnqueueCommand(cmd, true)
+	case shared.CmdRemove:
+		return s.queueHandler.HandleRemove(cmd)
+	case shared.CmdMove:
+		return s.queueHandler.HandleMove(cmd)
+	case shared.CmdClear:
+		return s.queueHandler.HandleClear()
+	case shared.CmdStars:
+		return s.tagHandler.HandleStars(cmd)
+	case shared.CmdGenre:
+		return s.tagHandler.HandleGenre(cmd)
+	case shared.CmdLabel:
+		return s.tagHandler.HandleLabel(cmd)
+	case shared.CmdResume:
+		return s.handleResumeCommand()
 	case shared.CmdExit:
 		return s.handleExitCommand()
 	default:
@@ -143,13 +251,16 @@ func (s *Server) handlePlayWithSource(cmd shared.Command) shared.Response {
 		return shared.NewErrorResponse(fmt.Sprintf("Path does not exist: %s", expandedPath))
 	}
 
+	var skipped []shared.SkippedEntry
+
 	switch cmd.Source {
 	case shared.SourceFolder:
 		if err := s.LoadFolder(expandedPath); err != nil {
 			return shared.NewErrorResponse(fmt.Sprintf("Failed to load folder: %v", err))
 		}
 	case shared.SourcePlaylist:
-		if err := s.LoadPlaylist(expandedPath); err != nil {
+		skipped, err = s.LoadPlaylist(expandedPath)
+		if err != nil {
 			return shared.NewErrorResponse(fmt.Sprintf("Failed to load playlist: %v", err))
 		}
 	default:
@@ -158,6 +269,9 @@ func (s *Server) handlePlayWithSource(cmd shared.Command) shared.Response {
 
 	trackCount := s.playlist.TrackCount()
 	if trackCount == 0 {
+		if len(skipped) > 0 {
+			return shared.NewErrorResponse(fmt.Sprintf("No playable tracks in playlist (%d entries skipped)", len(skipped)))
+		}
 		return shared.NewErrorResponse("No audio files found in the specified location")
 	}
 
@@ -198,10 +312,15 @@ func (s *Server) handlePlayWithSource(cmd shared.Command) shared.Response {
 		modesMsg += ")"
 	}
 
-	log.Printf("Loaded %d tracks from %s: %s%s and started playback", trackCount, cmd.Source, expandedPath, modesMsg)
+	skippedMsg := ""
+	if len(skipped) > 0 {
+		skippedMsg = fmt.Sprintf(", skipped %d entries", len(skipped))
+	}
+
+	log.Printf("Loaded %d tracks from %s: %s%s and started playback%s", trackCount, cmd.Source, expandedPath, modesMsg, skippedMsg)
 	return shared.NewSuccessResponse(
-		fmt.Sprintf("Loaded %d tracks from %s%s and started playback", trackCount, cmd.Source, modesMsg),
-		nil,
+		fmt.Sprintf("Loaded %d tracks from %s%s and started playback%s", trackCount, cmd.Source, modesMsg, skippedMsg),
+		shared.LoadResult{TrackCount: trackCount, Skipped: skipped},
 	)
 }
 
@@ -212,6 +331,7 @@ func (s *Server) handleShuffleCommand() shared.Response {
 	}
 
 	nowShuffled := s.playlist.ToggleShuffle()
+	s.player.RefreshPreload()
 
 	if nowShuffled {
 		log.Println("Playlist shuffled")
@@ -229,6 +349,7 @@ func (s *Server) handleRepeatCommand() shared.Response {
 	}
 
 	newMode := s.playlist.ToggleRepeat()
+	s.player.RefreshPreload()
 
 	var message string
 	switch newMode {
@@ -246,19 +367,83 @@ func (s *Server) handleRepeatCommand() shared.Response {
 	return shared.NewSuccessResponse(message, nil)
 }
 
+// setRepeatMode runs the repeat command until the playlist is in mode, for
+// clients that set the mode rather than cycle it. Repeat cycles through
+// three modes, so two steps reach any of them.
+func (s *Server) setRepeatMode(mode playlist.RepeatMode) shared.Response {
+	resp := shared.NewSuccessResponse("Repeat "+mode.String(), nil)
+	for i := 0; i < 2 && s.playlist.GetRepeatMode() != mode; i++ {
+		if resp = s.handleCommand(shared.Command{Type: shared.CmdRepeat}); !resp.Success {
+			return resp
+		}
+	}
+	return resp
+}
+
+func (s *Server) handleGaplessCommand() shared.Response {
+	enabled := !s.player.IsGapless()
+	s.player.SetGapless(enabled)
+
+	if enabled {
+		log.Println("Gapless playback enabled")
+		return shared.NewSuccessResponse("Gapless playback enabled", nil)
+	}
+	log.Println("Gapless playback disabled")
+	return shared.NewSuccessResponse("Gapless playback disabled", nil)
+}
+
+func (s *Server) handleSaveCommand(cmd shared.Command) shared.Response {
+	expandedPath, err := s.loader.ExpandPath(cmd.Path)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Invalid path: %v", err))
+	}
+
+	cmd.Path = expandedPath
+	return s.saveHandler.HandleSave(cmd)
+}
+
+// handleEnqueueCommand adds a folder, playlist or file to the queue without
+// replacing it
+func (s *Server) handleEnqueueCommand(cmd shared.Command, playNext bool) shared.Response {
+	if cmd.Path == "" {
+		return shared.NewErrorResponse("No path given")
+	}
+
+	expandedPath, err := s.loader.ExpandPath(cmd.Path)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Invalid path: %v", err))
+	}
+
+	tracks, skipped, err := s.loader.LoadPath(expandedPath)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to load %s: %v", cmd.Path, err))
+	}
+	for _, entry := range skipped {
+		log.Printf("Enqueue: Skipped %s (%s)", entry.Location, entry.Reason)
+	}
+	s.tagHandler.Learn(tracks)
+
+	return s.queueHandler.HandleAdd(tracks, skipped, playNext)
+}
+
 func (s *Server) handleExitCommand() shared.Response {
 	go func() {
+		s.persistState()
+
 		if err := s.player.Close(); err != nil {
 			log.Printf("Error closing player: %v", err)
 		}
 
 		s.Stop()
-		os.Exit(0)
+		s.exit(0)
 	}()
 
 	return shared.NewSuccessResponse("Exiting daemon", nil)
 }
 
+// onTrackComplete runs when the stream runs dry. In gapless and crossfade
+// modes the player moves on to the preloaded track by itself, so this only
+// fires at the end of the queue or when preloading failed.
 func (s *Server) onTrackComplete() {
 	log.Println("Track completed, scheduling auto-advance")
 
@@ -271,60 +456,52 @@ func (s *Server) onTrackComplete() {
 
 		repeatMode := s.playlist.GetRepeatMode()
 
-		if repeatMode == playlist.RepeatOne {
-			log.Println("Repeat-one mode: replaying current track")
-			currentTrack := s.playlist.GetCurrentTrack()
-			if currentTrack != nil {
-				s.player.SetCurrentTrack(currentTrack)
-				if err := s.player.Play(); err != nil {
-					log.Printf("Failed to replay track: %v", err)
-				} else {
-					log.Printf("Now replaying: %s", currentTrack.Filename)
-				}
-			}
+		// Advance follows shuffle and repeat: repeat-one stays on the current
+		// track and repeat-all wraps around to the first
+		if !s.playlist.Advance() {
+			log.Println("Reached end of playlist, playback complete (repeat off)")
+			s.publishEnd()
+			s.publishChanges()
 			return
 		}
 
-		if s.playlist.Next() {
-			nextTrack := s.playlist.GetCurrentTrack()
-			if nextTrack != nil {
-				log.Printf("Auto-advancing to: %s", nextTrack.Filename)
-
-				s.player.SetCurrentTrack(nextTrack)
-
-				if err := s.player.Play(); err != nil {
-					log.Printf("Failed to auto-play next track: %v", err)
-				} else {
-					log.Printf("Now auto-playing: %s", nextTrack.Filename)
-				}
-			} else {
-				log.Println("Next track is nil, cannot advance")
-			}
+		nextTrack := s.playlist.GetCurrentTrack()
+		if nextTrack == nil {
+			log.Println("Next track is nil, cannot advance")
 			return
 		}
 
-		if repeatMode == playlist.RepeatAll {
-			log.Println("Repeat-all mode: looping back to first track")
-			if s.playlist.SetCurrentIndex(0) {
-				firstTrack := s.playlist.GetCurrentTrack()
-				if firstTrack != nil {
-					log.Printf("Looping to first track: %s", firstTrack.Filename)
-
-					s.player.SetCurrentTrack(firstTrack)
-
-					if err := s.player.Play(); err != nil {
-						log.Printf("Failed to auto-play first track on loop: %v", err)
-					} else {
-						log.Printf("Now auto-playing (looped): %s", firstTrack.Filename)
-					}
-				}
-			}
+		switch repeatMode {
+		case playlist.RepeatOne:
+			log.Printf("Repeat-one mode: replaying %s", nextTrack.Filename)
+		default:
+			log.Printf("Auto-advancing to: %s", nextTrack.Filename)
+		}
+
+		s.player.SetCurrentTrack(nextTrack)
+
+		if err := s.player.Play(); err != nil {
+			log.Printf("Failed to auto-play next track: %v", err)
 		} else {
-			log.Println("Reached end of playlist, playback complete (repeat off)")
+			log.Printf("Now auto-playing: %s", nextTrack.Filename)
 		}
+		s.publishChanges()
+		s.persistState()
 	}()
 }
 
+// onGaplessAdvance keeps the playlist in step after the player has switched
+// to the preloaded track
+func (s *Server) onGaplessAdvance(track *shared.Track) {
+	if !s.playlist.Advance() {
+		log.Println("Gapless switch past the end of the playlist")
+		return
+	}
+	log.Printf("Gapless or crossfade transition to: %s", track.Filename)
+	s.publishChanges()
+	s.persistState()
+}
+
 // Helper methods
 
 func (s *Server) LoadFolder(folderPath string) error {
@@ -338,23 +515,30 @@ func (s *Server) LoadFolder(folderPath string) error {
 		log.Printf("LoadFolder: LoadTracks failed: %v", err)
 		return err
 	}
+	s.tagHandler.Learn(tracks)
 
 	log.Printf("LoadFolder: Successfully loaded %d tracks into playlist", len(tracks))
 	return nil
 }
 
-func (s *Server) LoadPlaylist(playlistPath string) error {
-	tracks, err := s.loader.LoadPlaylist(playlistPath)
+// LoadPlaylist loads a playlist file and returns the entries that were skipped
+func (s *Server) LoadPlaylist(playlistPath string) ([]shared.SkippedEntry, error) {
+	tracks, skipped, err := s.loader.LoadPlaylist(playlistPath)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	for _, entry := range skipped {
+		log.Printf("LoadPlaylist: Skipped %s (%s)", entry.Location, entry.Reason)
 	}
 
 	err = s.playlist.LoadTracks(tracks, playlistPath, shared.SourcePlaylist)
 	if err != nil {
 		log.Printf("LoadPlaylist: LoadTracks failed: %v", err)
-		return err
+		return skipped, err
 	}
+	s.tagHandler.Learn(tracks)
 
 	log.Printf("LoadPlaylist: Successfully loaded %d tracks into playlist", len(tracks))
-	return nil
+	return skipped, nil
 }
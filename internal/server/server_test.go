@@ -3,8 +3,13 @@ package server
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/cerberussg/auxbox/internal/audio/decoders"
+	"github.com/cerberussg/auxbox/internal/server/commands"
 	"github.com/cerberussg/auxbox/internal/shared"
 )
 
@@ -472,8 +477,200 @@ func TestServer_HandleVolumeCommand(t *testing.T) {
 	}
 }
 
+func TestServer_HandleResampleCommand(t *testing.T) {
+	server := NewServer()
+
+	// Default quality is reported when none is given
+	resp := server.HandleCommand(shared.NewResampleCommand(-1))
+	if !resp.Success {
+		t.Fatalf("Get resample quality failed: %s", resp.Message)
+	}
+	if resp.Message != "Resample quality: 4" {
+		t.Errorf("Expected default quality 4, got: %s", resp.Message)
+	}
+
+	resp = server.HandleCommand(shared.NewResampleCommand(6))
+	if !resp.Success {
+		t.Fatalf("Set resample quality failed: %s", resp.Message)
+	}
+	if got := server.player.GetResampleQuality(); got != 6 {
+		t.Errorf("Player resample quality should be 6, got %d", got)
+	}
+
+	// Out of range values are rejected and leave the setting unchanged
+	resp = server.HandleCommand(shared.NewResampleCommand(64))
+	if resp.Success {
+		t.Error("Resample quality 64 should be rejected")
+	}
+	if got := server.player.GetResampleQuality(); got != 6 {
+		t.Errorf("Rejected quality should not change the setting, got %d", got)
+	}
+}
+
+func TestServer_HandleGaplessCommand(t *testing.T) {
+	server := NewServer()
+
+	resp := server.HandleCommand(shared.NewGaplessCommand())
+	if !resp.Success || resp.Message != "Gapless playback enabled" {
+		t.Errorf("First toggle should enable gapless, got: %s", resp.Message)
+	}
+	if !server.player.IsGapless() {
+		t.Error("Player should be in gapless mode")
+	}
+
+	resp = server.HandleCommand(shared.NewGaplessCommand())
+	if !resp.Success || resp.Message != "Gapless playback disabled" {
+		t.Errorf("Second toggle should disable gapless, got: %s", resp.Message)
+	}
+	if server.player.IsGapless() {
+		t.Error("Player should not be in gapless mode")
+	}
+}
+
+func TestServer_HandleCrossfadeCommand(t *testing.T) {
+	server := NewServer()
+
+	resp := server.HandleCommand(shared.NewCrossfadeCommand(-1, false))
+	if !resp.Success || resp.Message != "Crossfade off" {
+		t.Errorf("Crossfade should be off by default, got: %s", resp.Message)
+	}
+
+	resp = server.HandleCommand(shared.NewCrossfadeCommand(2.5, true))
+	if !resp.Success || resp.Message != "Crossfade set to 2.5s (also on skip/back)" {
+		t.Errorf("Unexpected response setting crossfade: %s", resp.Message)
+	}
+	duration, fadeSkips := server.player.GetCrossfade()
+	if duration != 2500*time.Millisecond || !fadeSkips {
+		t.Errorf("Player crossfade = %v, %v, want 2.5s, true", duration, fadeSkips)
+	}
+
+	// Out of range values are rejected and leave the setting unchanged
+	resp = server.HandleCommand(shared.NewCrossfadeCommand(60, false))
+	if resp.Success {
+		t.Error("Crossfade of 60s should be rejected")
+	}
+	if duration, _ := server.player.GetCrossfade(); duration != 2500*time.Millisecond {
+		t.Errorf("Rejected crossfade should not change the setting, got %v", duration)
+	}
+
+	resp = server.HandleCommand(shared.NewCrossfadeCommand(0, true))
+	if !resp.Success || resp.Message != "Crossfade off" {
+		t.Errorf("Unexpected response turning crossfade off: %s", resp.Message)
+	}
+}
+
+func TestServer_HandleSeekCommand(t *testing.T) {
+	server := NewServer()
+
+	resp := server.HandleCommand(shared.NewSeekCommand("sometime"))
+	if resp.Success {
+		t.Error("Seek with an invalid position should fail")
+	}
+	if !strings.Contains(resp.Message, "Invalid seek position") {
+		t.Errorf("Unexpected error message: %s", resp.Message)
+	}
+
+	resp = server.HandleCommand(shared.NewSeekCommand("1:30"))
+	if resp.Success || resp.Message != "No track loaded" {
+		t.Errorf("Seek without a track should fail, got: %s", resp.Message)
+	}
+}
+
+func TestServer_HandleJumpCommand(t *testing.T) {
+	server := NewServer()
+	server.playlist.LoadTracks([]*shared.Track{
+		{Filename: "01 - Intro.mp3", Path: "/nonexistent/01 - Intro.mp3"},
+		{Filename: "02 - Daft Punk - One More Time.mp3", Path: "/nonexistent/02.mp3"},
+		{Filename: "03 - Daft Punk - Digital Love.mp3", Path: "/nonexistent/03.mp3"},
+	}, "/nonexistent", shared.SourceFolder)
+
+	resp := server.HandleCommand(shared.NewJumpCommand("3"))
+	if !resp.Success || resp.Message != "Jumped to 3. 03 - Daft Punk - Digital Love.mp3" {
+		t.Errorf("Jump by number failed: %s", resp.Message)
+	}
+	if got := server.playlist.GetCurrentIndex(); got != 2 {
+		t.Errorf("Current index after jump = %d, want 2", got)
+	}
+
+	// The first of several matches wins, and the rest are listed
+	resp = server.HandleCommand(shared.NewJumpCommand("daft punk"))
+	if !resp.Success || !strings.HasPrefix(resp.Message, "Jumped to 2. ") {
+		t.Errorf("Jump by name failed: %s", resp.Message)
+	}
+	if !strings.Contains(resp.Message, "1 other track(s) also match") || !strings.Contains(resp.Message, "3. 03 - Daft Punk") {
+		t.Errorf("Ambiguous jump should list the other match, got: %s", resp.Message)
+	}
+
+	for _, query := range []string{"0", "4", "polka", ""} {
+		if resp := server.HandleCommand(shared.NewJumpCommand(query)); resp.Success {
+			t.Errorf("Jump to %q should fail", query)
+		}
+	}
+}
+
+func TestServer_QueueEditing(t *testing.T) {
+	server := NewServer()
+	server.playlist.LoadTracks([]*shared.Track{
+		{Filename: "a.mp3", Path: "/nonexistent/a.mp3"},
+		{Filename: "b.mp3", Path: "/nonexistent/b.mp3"},
+		{Filename: "c.mp3", Path: "/nonexistent/c.mp3"},
+	}, "/nonexistent", shared.SourceFolder)
+	server.playlist.SetCurrentIndex(1)
+
+	tmpDir := t.TempDir()
+	newTrack := filepath.Join(tmpDir, "new.mp3")
+	if err := os.WriteFile(newTrack, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	queue := func() string {
+		var names []string
+		for _, track := range server.playlist.GetTrackList() {
+			names = append(names, strings.TrimSuffix(track.Filename, ".mp3"))
+		}
+		return strings.Join(names, " ")
+	}
+
+	steps := []struct {
+		cmd         shared.Command
+		wantQueue   string
+		wantCurrent string
+	}{
+		{shared.NewPlayNextCommand(newTrack), "a b new c", "b.mp3"},
+		{shared.NewEnqueueCommand(tmpDir), "a b new c new", "b.mp3"},
+		{shared.NewMoveCommand(4, 1), "c a b new new", "b.mp3"},
+		{shared.NewRemoveCommand(1, 2), "b new new", "b.mp3"},
+		{shared.NewClearCommand(), "b", "b.mp3"},
+	}
+
+	for _, step := range steps {
+		resp := server.HandleCommand(step.cmd)
+		if !resp.Success {
+			t.Fatalf("%s failed: %s", step.cmd.Type, resp.Message)
+		}
+		if got := queue(); got != step.wantQueue {
+			t.Errorf("After %s: queue = %q, want %q", step.cmd.Type, got, step.wantQueue)
+		}
+		if got := server.playlist.GetCurrentTrack().Filename; got != step.wantCurrent {
+			t.Errorf("After %s: current = %s, want %s", step.cmd.Type, got, step.wantCurrent)
+		}
+	}
+
+	for _, cmd := range []shared.Command{
+		shared.NewRemoveCommand(2, 2),
+		shared.NewMoveCommand(1, 3),
+		shared.NewEnqueueCommand(filepath.Join(tmpDir, "missing.mp3")),
+	} {
+		if resp := server.HandleCommand(cmd); resp.Success {
+			t.Errorf("%s %+v should fail", cmd.Type, cmd)
+		}
+	}
+}
+
 func TestServer_HandleExitCommand(t *testing.T) {
 	server := NewServer()
+	exited := make(chan int, 1)
+	server.exit = func(code int) { exited <- code }
 
 	// Test exit command
 	exitCmd := shared.NewExitCommand()
@@ -487,7 +684,246 @@ func TestServer_HandleExitCommand(t *testing.T) {
 		t.Errorf("Exit response should mention exiting, got: %s", resp.Message)
 	}
 
-	// Note: We can't easily test the actual os.Exit() call in unit tests
-	// The goroutine will attempt to stop the server and exit, but in tests
-	// this won't actually terminate the test process
+	// The daemon shuts down in the background, then exits
+	select {
+	case code := <-exited:
+		if code != 0 {
+			t.Errorf("Exit code = %d, want 0", code)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Exit command never exited")
+	}
+}
+
+func TestServer_HandleSaveCommand(t *testing.T) {
+	server := NewServer()
+	tmpDir := t.TempDir()
+
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: filepath.Join(tmpDir, "music", "a.mp3")},
+		{Filename: "b.mp3", Path: filepath.Join(tmpDir, "music", "b.mp3")},
+	}
+	server.LoadTracks(tracks, tmpDir, shared.SourceFolder)
+
+	// Relative paths are written relative to the playlist file
+	savePath := filepath.Join(tmpDir, "set.m3u8")
+	resp := server.HandleCommand(shared.NewSaveCommand(savePath, true))
+	if !resp.Success {
+		t.Fatalf("Save command failed: %s", resp.Message)
+	}
+
+	data, err := os.ReadFile(savePath)
+	if err != nil {
+		t.Fatalf("Saved playlist not readable: %v", err)
+	}
+	if !containsString(string(data), "\nmusic/a.mp3\n") || !containsString(string(data), "\nmusic/b.mp3\n") {
+		t.Errorf("Saved playlist should contain relative paths, got:\n%s", data)
+	}
+
+	// A shuffled queue is saved in the order it plays
+	for _, name := range []string{"c.mp3", "d.mp3", "e.mp3", "f.mp3"} {
+		tracks = append(tracks, &shared.Track{Filename: name, Path: filepath.Join(tmpDir, "music", name)})
+	}
+	server.LoadTracks(tracks, tmpDir, shared.SourceFolder)
+	server.playlist.Seed(3)
+	server.playlist.Shuffle()
+	var want []string
+	for _, track := range server.playlist.PlayOrder() {
+		want = append(want, track.Path)
+	}
+	if resp := server.HandleCommand(shared.NewSaveCommand(savePath, false)); !resp.Success {
+		t.Fatalf("Save command failed: %s", resp.Message)
+	}
+	data, _ = os.ReadFile(savePath)
+	var got []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "/") {
+			got = append(got, line)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Saved shuffled queue = %q, want play order %q", got, want)
+	}
+	if reflect.DeepEqual(got, []string{tracks[0].Path, tracks[1].Path, tracks[2].Path, tracks[3].Path, tracks[4].Path, tracks[5].Path}) {
+		t.Error("Seeded shuffle should not be in queue order")
+	}
+
+	// Saving through a symlink replaces its target and keeps the target's mode
+	if err := os.Chmod(savePath, 0600); err != nil {
+		t.Fatal(err)
+	}
+	linkPath := filepath.Join(tmpDir, "link.m3u8")
+	if err := os.Symlink(savePath, linkPath); err != nil {
+		t.Fatal(err)
+	}
+	if resp := server.HandleCommand(shared.NewSaveCommand(linkPath, false)); !resp.Success {
+		t.Fatalf("Save command failed: %s", resp.Message)
+	}
+	if info, err := os.Lstat(linkPath); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Error("Saving through a symlink should keep the link")
+	}
+	info, err := os.Stat(savePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Saved playlist mode = %v, want the existing 0600", info.Mode().Perm())
+	}
+
+	// Unknown extensions are rejected
+	resp = server.HandleCommand(shared.NewSaveCommand(filepath.Join(tmpDir, "set.txt"), false))
+	if resp.Success {
+		t.Error("Save command should fail for an unsupported extension")
+	}
+}
+
+func TestServer_HandleStarsCommand(t *testing.T) {
+	server := NewServer()
+	tmpDir := t.TempDir()
+
+	// Frame syncs stand in for the audio; the rating goes in a new ID3 tag
+	path := filepath.Join(tmpDir, "a.mp3")
+	if err := os.WriteFile(path, []byte(strings.Repeat("\xFF\xFB\x90\x00", 64)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server.LoadTracks([]*shared.Track{{Filename: "a.mp3", Path: path}}, tmpDir, shared.SourceFolder)
+
+	resp := server.HandleCommand(shared.NewStarsCommand(4))
+	if !resp.Success {
+		t.Fatalf("Stars command failed: %s", resp.Message)
+	}
+
+	status := server.HandleCommand(shared.NewStatusCommand())
+	if info, ok := status.Data.(shared.TrackInfo); !ok || info.Rating != 4 {
+		t.Errorf("Status after rating = %+v, want 4 stars", status.Data)
+	}
+	tags, err := decoders.NewFormatRegistry().ReadTags(path)
+	if err != nil || tags.Get("RATING") != "4" {
+		t.Errorf("File tags after rating = %q, %v, want RATING 4", tags, err)
+	}
+
+	if resp := server.HandleCommand(shared.NewStarsCommand(6)); resp.Success {
+		t.Error("Stars command should reject 6 stars")
+	}
+}
+
+func TestServer_HandleGenreAndLabelCommands(t *testing.T) {
+	server := NewServer()
+	tmpDir := t.TempDir()
+	vocabularyPath := filepath.Join(tmpDir, "state", "vocabulary.json")
+	if err := server.SetVocabularyPath(vocabularyPath); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(tmpDir, "a.mp3")
+	if err := os.WriteFile(path, []byte(strings.Repeat("\xFF\xFB\x90\x00", 64)), 0644); err != nil {
+		t.Fatal(err)
+	}
+	server.LoadTracks([]*shared.Track{{Filename: "a.mp3", Path: path}}, tmpDir, shared.SourceFolder)
+
+	if resp := server.HandleCommand(shared.NewGenreCommand("Tech House", " Deep House ")); !resp.Success {
+		t.Fatalf("Genre command failed: %s", resp.Message)
+	}
+	if resp := server.HandleCommand(shared.NewLabelCommand("Drumcode")); !resp.Success {
+		t.Fatalf("Label command failed: %s", resp.Message)
+	}
+
+	// A new tag is ID3v2.3, which has no lists, so the genres share one frame
+	tags, err := decoders.NewFormatRegistry().ReadTags(path)
+	if err != nil || tags.Get("GENRE") != "Tech House, Deep House" || tags.Get("LABEL") != "Drumcode" {
+		t.Errorf("File tags = %q, %v, want two genres and a label", tags, err)
+	}
+	status := server.HandleCommand(shared.NewStatusCommand())
+	if info, ok := status.Data.(shared.TrackInfo); !ok || info.Genre != "Tech House, Deep House" || info.Label != "Drumcode" {
+		t.Errorf("Status = %+v, want the new genres and label", status.Data)
+	}
+
+	// Typos of known genres are caught unless they are meant to be new
+	resp := server.HandleCommand(shared.NewGenreCommand("Tech Hosue"))
+	if resp.Success || !strings.Contains(resp.Message, `"Tech House"`) {
+		t.Errorf("Genre with a typo = %q, want it flagged", resp.Message)
+	}
+	if resp := server.HandleCommand(shared.NewGenreCommand("tech house")); !resp.Success {
+		t.Errorf("Genre in another case failed: %s", resp.Message)
+	}
+	if tags, _ := decoders.NewFormatRegistry().ReadTags(path); tags.Get("GENRE") != "Tech House" {
+		t.Errorf("GENRE = %q, want the known spelling", tags.Get("GENRE"))
+	}
+	cmd := shared.NewGenreCommand("Tech Hosue")
+	cmd.AllowNew = true
+	if resp := server.HandleCommand(cmd); !resp.Success {
+		t.Errorf("Genre with --new failed: %s", resp.Message)
+	}
+
+	if resp := server.HandleCommand(shared.NewLabelCommand("A, B")); !resp.Success {
+		t.Errorf("Label command failed: %s", resp.Message)
+	}
+	if resp := server.HandleCommand(shared.Command{Type: shared.CmdLabel, Values: []string{"A", "B"}}); resp.Success {
+		t.Error("Label command should reject two labels")
+	}
+	if resp := server.HandleCommand(shared.Command{Type: shared.CmdLabel, Clear: true}); !resp.Success {
+		t.Errorf("Clearing the label failed: %s", resp.Message)
+	}
+	if tags, _ := decoders.NewFormatRegistry().ReadTags(path); tags.Get("LABEL") != "" {
+		t.Errorf("LABEL after clearing = %q", tags.Get("LABEL"))
+	}
+
+	// A value added by mistake can be forgotten
+	cmd = shared.NewGenreCommand("tech hosue")
+	cmd.Forget = true
+	if resp := server.HandleCommand(cmd); !resp.Success {
+		t.Errorf("Forgetting a genre failed: %s", resp.Message)
+	}
+	if resp := server.HandleCommand(cmd); resp.Success {
+		t.Error("Forgetting an unknown genre should fail")
+	}
+
+	// The vocabulary is saved for the next daemon, and for completion
+	query := server.HandleCommand(shared.NewGenreCommand())
+	if values, ok := query.Data.(shared.TagValues); !ok || values.Current != "Tech Hosue" {
+		t.Errorf("Genre query = %+v, want the current genre", query.Data)
+	}
+	vocabulary, err := commands.LoadVocabulary(vocabularyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := vocabulary.Known("GENRE"), []string{"Deep House", "Tech House"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Known genres = %q, want %q", got, want)
+	}
+	if got, want := vocabulary.Complete("LABEL", "dr"), []string{"Drumcode"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels starting with dr = %q, want %q", got, want)
+	}
+}
+
+func TestServer_LearnsTagsOfLoadedTracks(t *testing.T) {
+	server := NewServer()
+	tmpDir := t.TempDir()
+	if err := server.SetVocabularyPath(filepath.Join(tmpDir, "vocabulary.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A stand-in MP3 with just an ID3v1 tag: genre 8 is Jazz
+	v1 := make([]byte, 128)
+	copy(v1, "TAG")
+	v1[127] = 8
+	musicDir := filepath.Join(tmpDir, "music")
+	if err := os.MkdirAll(musicDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	data := append([]byte(strings.Repeat("\xFF\xFB\x90\x00", 64)), v1...)
+	if err := os.WriteFile(filepath.Join(musicDir, "a.mp3"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := server.LoadFolder(musicDir); err != nil {
+		t.Fatal(err)
+	}
+
+	// A library's existing genres are known before any are set
+	path := filepath.Join(musicDir, "a.mp3")
+	if resp := server.HandleCommand(shared.NewGenreCommand("Jaz")); resp.Success || !strings.Contains(resp.Message, `"Jazz"`) {
+		t.Errorf("Genre Jaz = %q, want it flagged as a typo of Jazz", resp.Message)
+	}
+	if tags, _ := decoders.NewFormatRegistry().ReadTags(path); tags.Get("GENRE") != "Jazz" {
+		t.Errorf("GENRE after a rejected typo = %q, want Jazz", tags.Get("GENRE"))
+	}
 }
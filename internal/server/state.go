@@ -0,0 +1,213 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cerberussg/auxbox/internal/audio"
+	"github.com/cerberussg/auxbox/internal/playlist"
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+// stateSaveInterval is how often the session is saved while playing, so the
+// position survives a crash or power loss
+const stateSaveInterval = 10 * time.Second
+
+// SessionState is what the daemon saves so `auxbox resume` can pick up
+// where it left off
+type SessionState struct {
+	Queue           playlist.State `json:"queue"`
+	Position        float64        `json:"position"` // Seconds into the current track
+	Volume          float64        `json:"volume"`
+	Gapless         bool           `json:"gapless,omitempty"`
+	Crossfade       float64        `json:"crossfade,omitempty"` // Seconds
+	FadeSkips       bool           `json:"fade_skips,omitempty"`
+	ResampleQuality int            `json:"resample_quality"`
+	SavedAt         time.Time      `json:"saved_at"`
+}
+
+// DefaultStatePath returns where the session is saved:
+// $XDG_STATE_HOME/auxbox/session.json, or ~/.local/state/auxbox/session.json
+func DefaultStatePath() string {
+	stateDir := os.Getenv("XDG_STATE_HOME")
+	if stateDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(os.TempDir(), "auxbox-session.json")
+		}
+		stateDir = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(stateDir, "auxbox", "session.json")
+}
+
+// LoadState reads a saved session
+func LoadState(path string) (*SessionState, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var state SessionState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if len(state.Queue.Tracks) == 0 {
+		return nil, fmt.Errorf("saved session in %s has no tracks", path)
+	}
+	return &state, nil
+}
+
+// writeState saves a session, replacing the file in one step so a crash
+// mid-write never leaves a truncated session behind
+func writeState(path string, state *SessionState) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	return nil
+}
+
+// SetStatePath enables saving the session to path on every change. An empty
+// path turns saving off, which is the default.
+func (s *Server) SetStatePath(path string) {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+	s.statePath = path
+}
+
+// SaveState writes the current session to the state file, if one is set
+func (s *Server) SaveState() error {
+	s.stateMu.Lock()
+	defer s.stateMu.Unlock()
+
+	if s.statePath == "" || s.playlist.TrackCount() == 0 {
+		return nil
+	}
+	return writeState(s.statePath, s.captureState())
+}
+
+// persistState saves the session, logging rather than returning failures
+func (s *Server) persistState() {
+	if err := s.SaveState(); err != nil {
+		log.Printf("Failed to save session: %v", err)
+	}
+}
+
+// persistLoop saves the session periodically while playing, until stop is closed
+func (s *Server) persistLoop(stop <-chan struct{}) {
+	ticker := time.NewTicker(stateSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if s.player.IsPlaying() {
+				s.persistState()
+			}
+		}
+	}
+}
+
+// captureState collects the session from the playlist and player
+func (s *Server) captureState() *SessionState {
+	crossfade, fadeSkips := s.player.GetCrossfade()
+
+	return &SessionState{
+		Queue:           s.playlist.Snapshot(),
+		Position:        s.player.GetPosition().Seconds(),
+		Volume:          s.player.GetStatus().Volume,
+		Gapless:         s.player.IsGapless(),
+		Crossfade:       crossfade.Seconds(),
+		FadeSkips:       fadeSkips,
+		ResampleQuality: s.player.GetResampleQuality(),
+		SavedAt:         time.Now(),
+	}
+}
+
+// restoreState loads a saved session into the playlist and player, leaving
+// the saved track loaded at its saved position (assumes s.mu is held)
+func (s *Server) restoreState(state *SessionState) error {
+	if err := s.playlist.Restore(state.Queue); err != nil {
+		return err
+	}
+
+	if err := s.player.SetVolume(state.Volume); err != nil {
+		return fmt.Errorf("invalid saved volume: %w", err)
+	}
+	if state.ResampleQuality != 0 {
+		if err := s.player.SetResampleQuality(state.ResampleQuality); err != nil {
+			return fmt.Errorf("invalid saved resample quality: %w", err)
+		}
+	}
+	s.player.SetGapless(state.Gapless)
+	crossfade := time.Duration(state.Crossfade * float64(time.Second))
+	if err := s.player.SetCrossfade(crossfade, state.FadeSkips); err != nil {
+		return fmt.Errorf("invalid saved crossfade: %w", err)
+	}
+
+	track := s.playlist.GetCurrentTrack()
+	s.player.SetCurrentTrack(track)
+	if s.player.GetCurrentTrack() == nil {
+		return fmt.Errorf("failed to load %s", track.Path)
+	}
+
+	if position := time.Duration(state.Position * float64(time.Second)); position > 0 {
+		if err := s.player.Seek(position); err != nil {
+			log.Printf("Resume: %v, starting %s from the beginning", err, track.Filename)
+		}
+	}
+	return nil
+}
+
+// handleResumeCommand restores the saved session and starts playback
+func (s *Server) handleResumeCommand() shared.Response {
+	s.stateMu.Lock()
+	path := s.statePath
+	s.stateMu.Unlock()
+	if path == "" {
+		return shared.NewErrorResponse("Session saving is not enabled")
+	}
+
+	state, err := LoadState(path)
+	if err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("No session to resume: %v", err))
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.restoreState(state); err != nil {
+		return shared.NewErrorResponse(fmt.Sprintf("Failed to restore session: %v", err))
+	}
+
+	playResp := s.playbackHandler.HandlePlay()
+	if !playResp.Success {
+		return playResp
+	}
+
+	track := s.playlist.GetCurrentTrack()
+	trackCount := s.playlist.TrackCount()
+	message := fmt.Sprintf("Resumed %d. %s at %s (%d tracks)",
+		s.playlist.GetCurrentIndex()+1, track.Filename, audio.FormatDuration(s.player.GetPosition()), trackCount)
+	log.Println(message)
+	return shared.NewSuccessResponse(message, shared.LoadResult{TrackCount: trackCount})
+}
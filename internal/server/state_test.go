@@ -0,0 +1,76 @@
+package server
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cerberussg/auxbox/internal/shared"
+)
+
+func TestState_DefaultStatePath(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", "/tmp/state")
+	if got := DefaultStatePath(); got != "/tmp/state/auxbox/session.json" {
+		t.Errorf("DefaultStatePath() = %q", got)
+	}
+}
+
+func TestState_WriteAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auxbox", "session.json")
+
+	state := &SessionState{Position: 93.5, Volume: 0.6, Crossfade: 4, ResampleQuality: 6}
+	state.Queue.Tracks = []*shared.Track{{Filename: "a.mp3", Path: "/music/a.mp3"}}
+	if err := writeState(path, state); err != nil {
+		t.Fatalf("writeState() failed: %v", err)
+	}
+
+	loaded, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("LoadState() failed: %v", err)
+	}
+	if loaded.Position != 93.5 || loaded.Volume != 0.6 || loaded.Crossfade != 4 || loaded.Queue.Tracks[0].Path != "/music/a.mp3" {
+		t.Errorf("LoadState() = %+v, want what was written", loaded)
+	}
+
+	if _, err := LoadState(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadState() should fail for a missing file")
+	}
+}
+
+func TestServer_SavesSessionOnChange(t *testing.T) {
+	server := NewServer()
+	path := filepath.Join(t.TempDir(), "session.json")
+	server.SetStatePath(path)
+
+	tracks := []*shared.Track{
+		{Filename: "a.mp3", Path: "/music/a.mp3"},
+		{Filename: "b.mp3", Path: "/music/b.mp3"},
+	}
+	server.LoadTracks(tracks, "/music", shared.SourceFolder)
+
+	if resp := server.HandleCommand(shared.Command{Type: shared.CmdShuffle}); !resp.Success {
+		t.Fatalf("Shuffle command failed: %s", resp.Message)
+	}
+
+	state, err := LoadState(path)
+	if err != nil {
+		t.Fatalf("Session not saved after shuffle: %v", err)
+	}
+	if !state.Queue.Shuffled || state.Queue.Source != "/music" || len(state.Queue.Tracks) != 2 {
+		t.Errorf("Saved session = %+v, want the shuffled queue", state.Queue)
+	}
+}
+
+func TestServer_HandleResumeCommand_NoSession(t *testing.T) {
+	server := NewServer()
+
+	// Saving is off by default
+	if resp := server.HandleCommand(shared.NewResumeCommand()); resp.Success {
+		t.Error("Resume should fail when session saving is off")
+	}
+
+	server.SetStatePath(filepath.Join(t.TempDir(), "session.json"))
+	resp := server.HandleCommand(shared.NewResumeCommand())
+	if resp.Success || !containsString(resp.Message, "No session") {
+		t.Errorf("Resume without a saved session = %+v, want an error", resp)
+	}
+}
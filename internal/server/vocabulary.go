@@ -0,0 +1,24 @@
+package server
+
+import (
+	"path/filepath"
+
+	"github.com/cerberussg/auxbox/internal/server/commands"
+)
+
+// DefaultVocabularyPath returns where the genres and labels used before are
+// kept: vocabulary.json in the same directory as the saved session
+func DefaultVocabularyPath() string {
+	return filepath.Join(filepath.Dir(DefaultStatePath()), "vocabulary.json")
+}
+
+// SetVocabularyPath loads the genres and labels used before from path and
+// saves new ones there. Without it they are only kept in memory.
+func (s *Server) SetVocabularyPath(path string) error {
+	vocabulary, err := commands.LoadVocabulary(path)
+	if err != nil {
+		return err
+	}
+	s.tagHandler.SetVocabulary(vocabulary)
+	return nil
+}
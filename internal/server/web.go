@@ -0,0 +1,21 @@
+package server
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// webFiles holds the web remote: a single page that drives the HTTP API
+//
+//go:embed web
+var webFiles embed.FS
+
+// webRemoteHandler serves the web remote page
+func webRemoteHandler() http.Handler {
+	files, err := fs.Sub(webFiles, "web")
+	if err != nil {
+		panic(err) // The embedded directory is always there
+	}
+	return http.FileServer(http.FS(files))
+}
@@ -48,10 +48,78 @@ func NewVolumeCommand(volume int) Command {
 	return Command{Type: CmdVolume, Volume: volume}
 }
 
+func NewResampleCommand(quality int) Command {
+	return Command{Type: CmdResample, Quality: quality}
+}
+
+func NewGaplessCommand() Command {
+	return Command{Type: CmdGapless}
+}
+
+func NewCrossfadeCommand(seconds float64, fadeSkips bool) Command {
+	return Command{Type: CmdCrossfade, Seconds: seconds, FadeSkips: fadeSkips}
+}
+
+func NewSeekCommand(position string) Command {
+	return Command{Type: CmdSeek, Position: position}
+}
+
+func NewJumpCommand(query string) Command {
+	return Command{Type: CmdJump, Query: query}
+}
+
+func NewEnqueueCommand(path string) Command {
+	return Command{Type: CmdEnqueue, Path: path}
+}
+
+func NewPlayNextCommand(path string) Command {
+	return Command{Type: CmdPlayNext, Path: path}
+}
+
+func NewRemoveCommand(from, to int) Command {
+	return Command{Type: CmdRemove, From: from, To: to}
+}
+
+func NewMoveCommand(from, to int) Command {
+	return Command{Type: CmdMove, From: from, To: to}
+}
+
+func NewClearCommand() Command {
+	return Command{Type: CmdClear}
+}
+
+func NewStarsCommand(stars int) Command {
+	return Command{Type: CmdStars, Stars: stars}
+}
+
+func NewGenreCommand(genres ...string) Command {
+	return Command{Type: CmdGenre, Values: genres}
+}
+
+func NewLabelCommand(label string) Command {
+	cmd := Command{Type: CmdLabel}
+	if label != "" {
+		cmd.Values = []string{label}
+	}
+	return cmd
+}
+
 func NewExitCommand() Command {
 	return Command{Type: CmdExit}
 }
 
+func NewResumeCommand() Command {
+	return Command{Type: CmdResume}
+}
+
+func NewSubscribeCommand() Command {
+	return Command{Type: CmdSubscribe}
+}
+
+func NewSaveCommand(path string, relative bool) Command {
+	return Command{Type: CmdSave, Path: path, Relative: relative}
+}
+
 // Response builders - helper methods for creating common responses
 
 func NewSuccessResponse(message string, data interface{}) Response {
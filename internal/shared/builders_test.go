@@ -1,6 +1,9 @@
 package shared
 
-import "testing"
+import (
+	"reflect"
+	"testing"
+)
 
 func TestNewPlayCommand(t *testing.T) {
 	cmd := NewPlayCommand()
@@ -165,6 +168,100 @@ func TestNewVolumeCommand(t *testing.T) {
 	}
 }
 
+func TestNewResampleCommand(t *testing.T) {
+	cmd := NewResampleCommand(6)
+	if cmd.Type != CmdResample {
+		t.Errorf("NewResampleCommand() Type = %v, want %v", cmd.Type, CmdResample)
+	}
+	if cmd.Quality != 6 {
+		t.Errorf("NewResampleCommand() Quality = %d, want 6", cmd.Quality)
+	}
+}
+
+func TestNewGaplessCommand(t *testing.T) {
+	cmd := NewGaplessCommand()
+	if cmd.Type != CmdGapless {
+		t.Errorf("NewGaplessCommand() Type = %v, want %v", cmd.Type, CmdGapless)
+	}
+}
+
+func TestNewCrossfadeCommand(t *testing.T) {
+	cmd := NewCrossfadeCommand(4.5, true)
+	if cmd.Type != CmdCrossfade {
+		t.Errorf("NewCrossfadeCommand() Type = %v, want %v", cmd.Type, CmdCrossfade)
+	}
+	if cmd.Seconds != 4.5 || !cmd.FadeSkips {
+		t.Errorf("NewCrossfadeCommand() Seconds, FadeSkips = %v, %v, want 4.5, true", cmd.Seconds, cmd.FadeSkips)
+	}
+}
+
+func TestNewSeekCommand(t *testing.T) {
+	cmd := NewSeekCommand("+15s")
+	if cmd.Type != CmdSeek {
+		t.Errorf("NewSeekCommand() Type = %v, want %v", cmd.Type, CmdSeek)
+	}
+	if cmd.Position != "+15s" {
+		t.Errorf("NewSeekCommand() Position = %q, want %q", cmd.Position, "+15s")
+	}
+}
+
+func TestNewJumpCommand(t *testing.T) {
+	cmd := NewJumpCommand("one more time")
+	if cmd.Type != CmdJump {
+		t.Errorf("NewJumpCommand() Type = %v, want %v", cmd.Type, CmdJump)
+	}
+	if cmd.Query != "one more time" {
+		t.Errorf("NewJumpCommand() Query = %q, want %q", cmd.Query, "one more time")
+	}
+}
+
+func TestQueueEditCommands(t *testing.T) {
+	tests := []struct {
+		name string
+		cmd  Command
+		want Command
+	}{
+		{"enqueue", NewEnqueueCommand("/music/new"), Command{Type: CmdEnqueue, Path: "/music/new"}},
+		{"play next", NewPlayNextCommand("/music/a.mp3"), Command{Type: CmdPlayNext, Path: "/music/a.mp3"}},
+		{"remove", NewRemoveCommand(3, 5), Command{Type: CmdRemove, From: 3, To: 5}},
+		{"move", NewMoveCommand(7, 2), Command{Type: CmdMove, From: 7, To: 2}},
+		{"clear", NewClearCommand(), Command{Type: CmdClear}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.cmd.Type != tt.want.Type || tt.cmd.Path != tt.want.Path || tt.cmd.From != tt.want.From || tt.cmd.To != tt.want.To {
+				t.Errorf("got %+v, want %+v", tt.cmd, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewStarsCommand(t *testing.T) {
+	cmd := NewStarsCommand(4)
+	if cmd.Type != CmdStars {
+		t.Errorf("NewStarsCommand() Type = %v, want %v", cmd.Type, CmdStars)
+	}
+	if cmd.Stars != 4 {
+		t.Errorf("NewStarsCommand() Stars = %d, want 4", cmd.Stars)
+	}
+}
+
+func TestTagCommands(t *testing.T) {
+	cmd := NewGenreCommand("Deep House", "Tech House")
+	if cmd.Type != CmdGenre || !reflect.DeepEqual(cmd.Values, []string{"Deep House", "Tech House"}) {
+		t.Errorf("NewGenreCommand() = %+v, want two genres", cmd)
+	}
+
+	cmd = NewLabelCommand("Drumcode")
+	if cmd.Type != CmdLabel || !reflect.DeepEqual(cmd.Values, []string{"Drumcode"}) {
+		t.Errorf("NewLabelCommand() = %+v, want Drumcode", cmd)
+	}
+	if cmd := NewLabelCommand(""); cmd.Values != nil {
+		t.Errorf("NewLabelCommand(\"\") Values = %q, want none", cmd.Values)
+	}
+}
+
 func TestNewExitCommand(t *testing.T) {
 	cmd := NewExitCommand()
 
@@ -241,6 +338,7 @@ func TestBuilderConsistency(t *testing.T) {
 		NewListCommand(),
 		NewStopCommand(),
 		NewExitCommand(),
+		NewResumeCommand(),
 	}
 
 	for i, cmd := range commands {
@@ -3,8 +3,9 @@ package shared
 type CommandType string
 
 const (
-	CmdStart CommandType = "start"
-	CmdExit  CommandType = "exit"
+	CmdStart  CommandType = "start"
+	CmdExit   CommandType = "exit"
+	CmdResume CommandType = "resume"
 
 	CmdPlay    CommandType = "play"
 	CmdPause   CommandType = "pause"
@@ -15,8 +16,27 @@ const (
 	CmdShuffle CommandType = "shuffle"
 	CmdRepeat  CommandType = "repeat"
 
-	CmdStatus CommandType = "status"
-	CmdList   CommandType = "list"
+	CmdStatus    CommandType = "status"
+	CmdList      CommandType = "list"
+	CmdSubscribe CommandType = "subscribe" // Keep the connection open and stream events
+
+	CmdSave CommandType = "save"
+
+	CmdResample  CommandType = "resample"
+	CmdGapless   CommandType = "gapless"
+	CmdCrossfade CommandType = "crossfade"
+	CmdSeek      CommandType = "seek"
+	CmdJump      CommandType = "jump"
+
+	CmdEnqueue  CommandType = "enqueue"
+	CmdPlayNext CommandType = "playnext"
+	CmdRemove   CommandType = "remove"
+	CmdMove     CommandType = "move"
+	CmdClear    CommandType = "clear"
+
+	CmdStars CommandType = "stars"
+	CmdGenre CommandType = "genre"
+	CmdLabel CommandType = "label"
 )
 
 type Command struct {
@@ -28,6 +48,25 @@ type Command struct {
 	Path    string      `json:"path,omitempty"`
 	Shuffle bool        `json:"shuffle,omitempty"`
 	Repeat  bool        `json:"repeat,omitempty"`
+
+	Relative bool `json:"relative,omitempty"` // Save playlist with paths relative to the playlist file
+	Quality  int  `json:"quality,omitempty"`  // Resampler quality; 0 queries the current setting
+
+	Seconds   float64 `json:"seconds,omitempty"`    // Crossfade duration; -1 queries the current setting
+	FadeSkips bool    `json:"fade_skips,omitempty"` // Crossfade on skip/back as well as auto-advance
+
+	Position string `json:"position,omitempty"` // Seek target such as "1:30", "+15s", "-10s" or "50%"
+	Query    string `json:"query,omitempty"`    // Jump target: a 1-based queue number or part of a filename
+
+	From int `json:"from,omitempty"` // Queue position (1-based) to remove from or move
+	To   int `json:"to,omitempty"`   // Last position to remove, or where to move to
+
+	Stars int `json:"stars,omitempty"` // Rating for the current track, 0 (unrated) to 5
+
+	Values   []string `json:"values,omitempty"`    // Genres or label for the current track; none shows the current ones
+	Clear    bool     `json:"clear,omitempty"`     // Remove the genre or label instead
+	Forget   bool     `json:"forget,omitempty"`    // Drop Values from the known genres or labels instead
+	AllowNew bool     `json:"allow_new,omitempty"` // Accept values that look like typos of known ones
 }
 
 type Response struct {
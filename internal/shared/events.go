@@ -0,0 +1,97 @@
+package shared
+
+import (
+	"sync"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventTrack   EventType = "track"   // A different track became current
+	EventPlay    EventType = "play"    // Playback started or resumed
+	EventPause   EventType = "pause"   // Playback paused
+	EventStop    EventType = "stop"    // Playback stopped
+	EventSeek    EventType = "seek"    // Playback moved within the current track
+	EventVolume  EventType = "volume"  // Volume changed
+	EventShuffle EventType = "shuffle" // Shuffle turned on or off
+	EventRepeat  EventType = "repeat"  // Repeat mode changed
+	EventQueue   EventType = "queue"   // Tracks were loaded, added, removed or moved
+	EventError   EventType = "error"   // A track failed to load or decode
+	EventEnd     EventType = "end"     // Playback reached the end of the queue
+)
+
+// Event is pushed to subscribed clients, one JSON object per line
+type Event struct {
+	Type    EventType  `json:"type"`
+	Time    time.Time  `json:"time"`
+	Message string     `json:"message,omitempty"` // Human-readable summary
+	Track   *TrackInfo `json:"track,omitempty"`   // Current track, if any
+
+	// Set for the matching event type only
+	Volume      *int   `json:"volume,omitempty"`       // Percentage
+	Shuffle     *bool  `json:"shuffle,omitempty"`      // Whether shuffle is on
+	Repeat      string `json:"repeat,omitempty"`       // "off", "all" or "one"
+	TotalTracks int    `json:"total_tracks,omitempty"` // Queue length after a queue change
+}
+
+// eventBufferSize is how many events a subscriber can fall behind by
+const eventBufferSize = 64
+
+// EventBus fans events out to subscribers. Publish never blocks: a
+// subscriber that falls behind misses events rather than stalling playback.
+type EventBus struct {
+	mu          sync.Mutex
+	subscribers map[chan Event]struct{}
+}
+
+// NewEventBus creates an event bus with no subscribers
+func NewEventBus() *EventBus {
+	return &EventBus{subscribers: make(map[chan Event]struct{})}
+}
+
+// Subscribe returns a channel of events and a function that unsubscribes
+// and closes the channel. The function is safe to call more than once.
+func (b *EventBus) Subscribe() (<-chan Event, func()) {
+	ch := make(chan Event, eventBufferSize)
+
+	b.mu.Lock()
+	b.subscribers[ch] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+	return ch, cancel
+}
+
+// Publish sends an event to every subscriber, stamping it with the current
+// time if it has none
+func (b *EventBus) Publish(event Event) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// SubscriberCount returns the number of current subscribers
+func (b *EventBus) SubscriberCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subscribers)
+}
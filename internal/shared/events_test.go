@@ -0,0 +1,55 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventBus_PublishToSubscribers(t *testing.T) {
+	bus := NewEventBus()
+	first, cancelFirst := bus.Subscribe()
+	second, cancelSecond := bus.Subscribe()
+	defer cancelSecond()
+
+	bus.Publish(Event{Type: EventPlay})
+
+	for _, ch := range []<-chan Event{first, second} {
+		event := <-ch
+		if event.Type != EventPlay || event.Time.IsZero() {
+			t.Errorf("Received %+v, want a timestamped play event", event)
+		}
+	}
+
+	// Cancelling closes the channel and may be repeated
+	cancelFirst()
+	cancelFirst()
+	if _, ok := <-first; ok {
+		t.Error("Channel should be closed after cancel")
+	}
+	if got := bus.SubscriberCount(); got != 1 {
+		t.Errorf("SubscriberCount() = %d, want 1", got)
+	}
+}
+
+func TestEventBus_SlowSubscriberDoesNotBlock(t *testing.T) {
+	bus := NewEventBus()
+	events, cancel := bus.Subscribe()
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < eventBufferSize*2; i++ {
+			bus.Publish(Event{Type: EventVolume})
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a subscriber that isn't reading")
+	}
+	if len(events) != eventBufferSize {
+		t.Errorf("Subscriber buffered %d events, want %d", len(events), eventBufferSize)
+	}
+}
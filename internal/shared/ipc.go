@@ -18,6 +18,7 @@ type UnixSocketTransport struct {
 	socketPath string
 	listener   net.Listener
 	conn       net.Conn
+	events     *EventBus // Source for subscribe connections, nil if unsupported
 }
 
 func NewUnixSocketTransport() *UnixSocketTransport {
@@ -71,6 +72,53 @@ func (u *UnixSocketTransport) Send(cmd Command) (*Response, error) {
 	return &resp, nil
 }
 
+// Subscribe sends a subscribe command and calls onEvent for every event the
+// daemon pushes, until the connection closes
+func (u *UnixSocketTransport) Subscribe(onEvent func(Event)) error {
+	conn, err := net.Dial("unix", u.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to connect to daemon: %w (is auxbox daemon running?)", err)
+	}
+	defer conn.Close()
+
+	data, err := NewSubscribeCommand().ToJSON()
+	if err != nil {
+		return fmt.Errorf("failed to serialize command: %w", err)
+	}
+	if _, err := fmt.Fprintf(conn, "%s\n", data); err != nil {
+		return fmt.Errorf("failed to send command: %w", err)
+	}
+
+	scanner := bufio.NewScanner(conn)
+	buf := make([]byte, 0, 64*1024)
+	scanner.Buffer(buf, 512*1024)
+
+	if !scanner.Scan() {
+		return fmt.Errorf("failed to read response: %w", scanner.Err())
+	}
+	resp, err := ResponseFromJSON(scanner.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if !resp.Success {
+		return fmt.Errorf("subscribe failed: %s", resp.Message)
+	}
+
+	for scanner.Scan() {
+		event, err := EventFromJSON(scanner.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to parse event: %w", err)
+		}
+		onEvent(event)
+	}
+	return scanner.Err()
+}
+
+// SetEventBus sets where subscribe connections get their events from
+func (u *UnixSocketTransport) SetEventBus(events *EventBus) {
+	u.events = events
+}
+
 func (u *UnixSocketTransport) Listen(handler func(Command) Response) error {
 	os.Remove(u.socketPath)
 
@@ -110,6 +158,11 @@ func (u *UnixSocketTransport) handleConnection(conn net.Conn, handler func(Comma
 			continue
 		}
 
+		if cmd.Type == CmdSubscribe {
+			u.streamEvents(conn, scanner)
+			return
+		}
+
 		resp := handler(cmd)
 
 		respData, err := resp.ToJSON()
@@ -128,6 +181,42 @@ func (u *UnixSocketTransport) handleConnection(conn net.Conn, handler func(Comma
 	}
 }
 
+// streamEvents turns the connection into an event stream, one JSON event
+// per line, until the client hangs up
+func (u *UnixSocketTransport) streamEvents(conn net.Conn, scanner *bufio.Scanner) {
+	if u.events == nil {
+		resp, _ := NewErrorResponse("events are not supported").ToJSON()
+		fmt.Fprintf(conn, "%s\n", resp)
+		return
+	}
+
+	events, cancel := u.events.Subscribe()
+	defer cancel()
+
+	resp, _ := NewSuccessResponse("Subscribed", nil).ToJSON()
+	if _, err := fmt.Fprintf(conn, "%s\n", resp); err != nil {
+		return
+	}
+
+	// Clients don't send anything after subscribing; a finished read means
+	// they hung up
+	go func() {
+		for scanner.Scan() {
+		}
+		cancel()
+	}()
+
+	for event := range events {
+		data, err := event.ToJSON()
+		if err != nil {
+			continue
+		}
+		if _, err := fmt.Fprintf(conn, "%s\n", data); err != nil {
+			return
+		}
+	}
+}
+
 func (u *UnixSocketTransport) Close() error {
 	if u.listener != nil {
 		u.listener.Close()
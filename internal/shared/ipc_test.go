@@ -420,6 +420,45 @@ func containsSubstring(s, substr string) bool {
 	return false
 }
 
+func TestUnixSocketTransport_Subscribe(t *testing.T) {
+	transport := NewUnixSocketTransport()
+	defer transport.Close()
+
+	bus := NewEventBus()
+	transport.SetEventBus(bus)
+	go transport.Listen(func(cmd Command) Response {
+		return NewSuccessResponse("ok", nil)
+	})
+	time.Sleep(100 * time.Millisecond)
+
+	received := make(chan Event)
+	go NewUnixSocketTransport().Subscribe(func(event Event) {
+		received <- event
+	})
+
+	// Wait for the subscription to reach the bus
+	deadline := time.Now().Add(time.Second)
+	for bus.SubscriberCount() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	bus.Publish(Event{Type: EventTrack, Message: "Now playing a.mp3", Track: &TrackInfo{Filename: "a.mp3"}})
+
+	select {
+	case event := <-received:
+		if event.Type != EventTrack || event.Track == nil || event.Track.Filename != "a.mp3" {
+			t.Errorf("Received %+v, want the track event", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Subscriber didn't receive the event")
+	}
+
+	// Regular commands still work alongside a subscription
+	if resp, err := NewUnixSocketTransport().Send(NewStatusCommand()); err != nil || !resp.Success {
+		t.Errorf("Send() during subscription = %v, %v", resp, err)
+	}
+}
+
 // TestMain can be used for setup/teardown if needed
 func TestMain(m *testing.M) {
 	// Clean up any leftover socket files before running tests
@@ -0,0 +1,135 @@
+package shared
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// SeekKind says how a seek target relates to the track
+type SeekKind int
+
+const (
+	SeekAbsolute SeekKind = iota // Offset from the start of the track
+	SeekRelative                 // Signed offset from the current position
+	SeekPercent                  // Percentage of the track's duration
+)
+
+// SeekTarget is a parsed seek position such as "1:30", "+15s" or "50%"
+type SeekTarget struct {
+	Kind    SeekKind
+	Offset  time.Duration // Used by SeekAbsolute and SeekRelative
+	Percent float64       // Used by SeekPercent, from 0 to 100
+}
+
+// ParseSeekTarget parses a seek position. Absolute positions are given as
+// a clock ("1:30", "1:02:03"), plain seconds ("90") or a Go duration
+// ("1m30s"); a leading + or - makes them relative, and a trailing % makes
+// the number a percentage of the track.
+func ParseSeekTarget(s string) (SeekTarget, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return SeekTarget{}, fmt.Errorf("empty seek position")
+	}
+
+	if number, ok := strings.CutSuffix(s, "%"); ok {
+		percent, err := strconv.ParseFloat(number, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return SeekTarget{}, fmt.Errorf("invalid percentage %q: use 0%% to 100%%", s)
+		}
+		return SeekTarget{Kind: SeekPercent, Percent: percent}, nil
+	}
+
+	target := SeekTarget{Kind: SeekAbsolute}
+	sign := time.Duration(1)
+	value := s
+	switch s[0] {
+	case '+':
+		target.Kind = SeekRelative
+		value = s[1:]
+	case '-':
+		target.Kind = SeekRelative
+		sign = -1
+		value = s[1:]
+	}
+
+	offset, err := parseSeekDuration(value)
+	if err != nil {
+		return SeekTarget{}, fmt.Errorf("invalid seek position %q: use 1:30, +15s, -10s or 50%%", s)
+	}
+	target.Offset = sign * offset
+	return target, nil
+}
+
+// parseSeekDuration parses an unsigned clock, seconds or Go duration value
+func parseSeekDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, fmt.Errorf("missing time")
+	}
+
+	var d time.Duration
+	var err error
+	switch {
+	case strings.Contains(s, ":"):
+		d, err = parseClock(s)
+	case unicode.IsLetter(rune(s[len(s)-1])):
+		d, err = time.ParseDuration(s)
+	default:
+		var seconds float64
+		seconds, err = strconv.ParseFloat(s, 64)
+		d = time.Duration(seconds * float64(time.Second))
+	}
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, fmt.Errorf("negative time")
+	}
+	return d, nil
+}
+
+// parseClock parses m:ss or h:mm:ss, where the seconds may have a fraction
+func parseClock(s string) (time.Duration, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("too many fields")
+	}
+
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil || seconds < 0 || seconds >= 60 {
+		return 0, fmt.Errorf("invalid seconds %q", parts[len(parts)-1])
+	}
+	d := time.Duration(seconds * float64(time.Second))
+
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || (i > 0 && n >= 60) {
+			return 0, fmt.Errorf("invalid field %q", parts[i])
+		}
+		d += time.Duration(n) * unit
+		unit *= 60
+	}
+	return d, nil
+}
+
+// Resolve returns the absolute position the target points to, given the
+// current position and the track's duration, clamped to the track. An
+// unknown duration, zero, only clamps at the start.
+func (t SeekTarget) Resolve(current, duration time.Duration) time.Duration {
+	var position time.Duration
+	switch t.Kind {
+	case SeekRelative:
+		position = current + t.Offset
+	case SeekPercent:
+		position = time.Duration(float64(duration) * t.Percent / 100)
+	default:
+		position = t.Offset
+	}
+	if duration > 0 {
+		position = min(position, duration)
+	}
+	return max(0, position)
+}
@@ -0,0 +1,80 @@
+package shared
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSeekTarget(t *testing.T) {
+	tests := []struct {
+		input string
+		want  SeekTarget
+	}{
+		{"1:30", SeekTarget{Kind: SeekAbsolute, Offset: 90 * time.Second}},
+		{"1:02:03", SeekTarget{Kind: SeekAbsolute, Offset: time.Hour + 2*time.Minute + 3*time.Second}},
+		{"0:07.5", SeekTarget{Kind: SeekAbsolute, Offset: 7500 * time.Millisecond}},
+		{"90", SeekTarget{Kind: SeekAbsolute, Offset: 90 * time.Second}},
+		{"1m30s", SeekTarget{Kind: SeekAbsolute, Offset: 90 * time.Second}},
+		{"+15s", SeekTarget{Kind: SeekRelative, Offset: 15 * time.Second}},
+		{"-10s", SeekTarget{Kind: SeekRelative, Offset: -10 * time.Second}},
+		{"-1:00", SeekTarget{Kind: SeekRelative, Offset: -time.Minute}},
+		{"50%", SeekTarget{Kind: SeekPercent, Percent: 50}},
+		{"12.5%", SeekTarget{Kind: SeekPercent, Percent: 12.5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseSeekTarget(tt.input)
+			if err != nil {
+				t.Fatalf("ParseSeekTarget(%q) error = %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSeekTarget(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSeekTarget_Invalid(t *testing.T) {
+	for _, input := range []string{"", "+", "abc", "1:75", "1:2:3:4", "150%", "-5%", "--5s", "1:-30"} {
+		if _, err := ParseSeekTarget(input); err == nil {
+			t.Errorf("ParseSeekTarget(%q) should fail", input)
+		}
+	}
+}
+
+func TestSeekTarget_Resolve(t *testing.T) {
+	current := time.Minute
+	duration := 4 * time.Minute
+
+	tests := []struct {
+		name   string
+		target SeekTarget
+		want   time.Duration
+	}{
+		{"absolute", SeekTarget{Kind: SeekAbsolute, Offset: 90 * time.Second}, 90 * time.Second},
+		{"forward", SeekTarget{Kind: SeekRelative, Offset: 15 * time.Second}, 75 * time.Second},
+		{"backward", SeekTarget{Kind: SeekRelative, Offset: -10 * time.Second}, 50 * time.Second},
+		{"percent", SeekTarget{Kind: SeekPercent, Percent: 50}, 2 * time.Minute},
+		{"before start", SeekTarget{Kind: SeekRelative, Offset: -5 * time.Minute}, 0},
+		{"past end", SeekTarget{Kind: SeekAbsolute, Offset: 10 * time.Minute}, duration},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.target.Resolve(current, duration); got != tt.want {
+				t.Errorf("Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// With the duration unknown, only the start is a limit
+	forward := SeekTarget{Kind: SeekRelative, Offset: 15 * time.Second}
+	if got := forward.Resolve(current, 0); got != 75*time.Second {
+		t.Errorf("Resolve() with unknown duration = %v, want 1m15s", got)
+	}
+	backward := SeekTarget{Kind: SeekRelative, Offset: -5 * time.Minute}
+	if got := backward.Resolve(current, 0); got != 0 {
+		t.Errorf("Resolve() before start with unknown duration = %v, want 0", got)
+	}
+}
@@ -21,3 +21,13 @@ func ResponseFromJSON(data []byte) (Response, error) {
 	err := json.Unmarshal(data, &resp)
 	return resp, err
 }
+
+func (e Event) ToJSON() ([]byte, error) {
+	return json.Marshal(e)
+}
+
+func EventFromJSON(data []byte) (Event, error) {
+	var event Event
+	err := json.Unmarshal(data, &event)
+	return event, err
+}
@@ -230,7 +230,7 @@ func TestResponse_ToJSON(t *testing.T) {
 					Duration: "3:45",
 				},
 			},
-			wantJSON: `{"success":true,"message":"Current status","data":{"filename":"test.mp3","path":"","duration":"3:45"}}`,
+			wantJSON: `{"success":true,"message":"Current status","data":{"filename":"test.mp3","path":"","duration":"3:45","volume":0}}`,
 		},
 		{
 			name: "response with playlist data",
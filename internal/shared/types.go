@@ -1,5 +1,7 @@
 package shared
 
+import "strings"
+
 type SourceType string
 
 const (
@@ -12,24 +14,95 @@ const (
 type Track struct {
 	Filename string `json:"filename"`
 	Path     string `json:"path"`
+	Title    string `json:"title,omitempty"` // From the file's tags, or the playlist's metadata
 	Duration string `json:"duration,omitempty"`
+	Artist   string `json:"artist,omitempty"`
+	Album    string `json:"album,omitempty"`
+	Genre    string `json:"genre,omitempty"`
+	BPM      string `json:"bpm,omitempty"`
+	Key      string `json:"key,omitempty"` // Musical key, e.g. "Am" or "8A"
+	Label    string `json:"label,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+	Rating   int    `json:"rating,omitempty"` // Stars, 0 (unrated) to 5
+	ID       int    `json:"-"`                // Queue entry ID given by the playlist; kept across queue edits
+}
+
+// DisplayName returns "Artist - Title" for tagged tracks, otherwise the
+// title or, failing that, the filename
+func (t *Track) DisplayName() string {
+	return displayName(t.Artist, t.Title, t.Filename)
 }
 
 type TrackInfo struct {
 	Filename    string `json:"filename"`
 	Path        string `json:"path"`
+	Title       string `json:"title,omitempty"`        // Display title, if known
 	Duration    string `json:"duration,omitempty"`     // e.g. "4:12"
 	Position    string `json:"position,omitempty"`     // e.g. "2:34"
 	TrackNumber int    `json:"track_number,omitempty"` // Current track in queue
 	TotalTracks int    `json:"total_tracks,omitempty"` // Total tracks in queue
 	Source      string `json:"source,omitempty"`       // Source folder/playlist name
+	SampleRate  int    `json:"sample_rate,omitempty"`  // Track sample rate in Hz
+	OutputRate  int    `json:"output_rate,omitempty"`  // Speaker sample rate in Hz
+	State       string `json:"state,omitempty"`        // "playing", "paused" or "stopped"
+	Volume      int    `json:"volume"`                 // Percentage, sent even when muted
+	Shuffle     bool   `json:"shuffle,omitempty"`      // Whether shuffle is on
+	Repeat      string `json:"repeat,omitempty"`       // "off", "all" or "one"
+	Artist      string `json:"artist,omitempty"`
+	Album       string `json:"album,omitempty"`
+	Genre       string `json:"genre,omitempty"`
+	BPM         string `json:"bpm,omitempty"`
+	Key         string `json:"key,omitempty"`
+	Label       string `json:"label,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Rating      int    `json:"rating,omitempty"` // Stars, 0 (unrated) to 5
+}
+
+// DisplayName returns "Artist - Title" for tagged tracks, otherwise the
+// title or, failing that, the filename
+func (t *TrackInfo) DisplayName() string {
+	return displayName(t.Artist, t.Title, t.Filename)
+}
+
+func displayName(artist, title, filename string) string {
+	switch {
+	case artist != "" && title != "":
+		return artist + " - " + title
+	case title != "":
+		return title
+	}
+	return filename
+}
+
+// FormatStars shows a rating as filled and empty stars, e.g. "★★★★☆"
+func FormatStars(stars int) string {
+	stars = min(max(stars, 0), 5)
+	return strings.Repeat("★", stars) + strings.Repeat("☆", 5-stars)
 }
 
 type PlaylistInfo struct {
 	Source     string   `json:"source"`      // Folder path or playlist name
 	SourceType string   `json:"source_type"` // "folder", "playlist", etc
-	Tracks     []string `json:"tracks"`      // List of track filenames (windowed for large playlists)
+	Tracks     []string `json:"tracks"`      // Track display names (windowed for large playlists)
 	CurrentIdx int      `json:"current_idx"` // Index of current track (0-based)
 	StartIdx   int      `json:"start_idx"`   // Index of first track in window (0-based)
 	TotalCount int      `json:"total_count"` // Total number of tracks in playlist
 }
+
+// SkippedEntry describes a playlist entry that could not be loaded
+type SkippedEntry struct {
+	Location string `json:"location"` // Entry as written in the playlist
+	Reason   string `json:"reason"`   // Why it was skipped, e.g. "file not found"
+}
+
+// TagValues is returned when a genre or label command is given no values
+type TagValues struct {
+	Current string   `json:"current,omitempty"` // The current track's value
+	Known   []string `json:"known,omitempty"`   // Values used before, for completion
+}
+
+// LoadResult is returned with a successful play command that loaded a source
+type LoadResult struct {
+	TrackCount int            `json:"track_count"`
+	Skipped    []SkippedEntry `json:"skipped,omitempty"`
+}